@@ -14,11 +14,15 @@ import (
 
 	"dannyswat/jiceot/internal"
 	"dannyswat/jiceot/internal/auth"
+	"dannyswat/jiceot/internal/calendar"
 	"dannyswat/jiceot/internal/dashboard"
 	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/forecast"
 	"dannyswat/jiceot/internal/notifications"
 	"dannyswat/jiceot/internal/reports"
+	"dannyswat/jiceot/internal/telegram"
 	"dannyswat/jiceot/internal/users"
+	"dannyswat/jiceot/internal/webhooks"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -58,11 +62,58 @@ func main() {
 	userDeviceService := users.NewUserDeviceService(db)
 	walletService := expenses.NewWalletService(db)
 	paymentService := expenses.NewPaymentService(db)
+	transferService := expenses.NewTransferService(db)
+	statementService := expenses.NewStatementService(db)
+	occurrenceService := expenses.NewOccurrenceService(db)
+	installmentService := expenses.NewInstallmentService(db)
+	reconciliationService := expenses.NewReconciliationService(db)
+	mergeService := expenses.NewMergeService(db)
 	expenseTypeService := expenses.NewExpenseTypeService(db)
 	expenseService := expenses.NewExpenseService(db)
+	webhookDispatcher := webhooks.NewDispatcher(db)
+	webhookService := webhooks.NewWebhookService(db, webhookDispatcher)
+	walletService.SetEventPublisher(webhookService)
+	paymentService.SetEventPublisher(webhookService)
+	expenseService.SetEventPublisher(webhookService)
+	transferService.SetEventPublisher(webhookService)
+	installmentService.SetEventPublisher(webhookService)
+	reconciliationService.SetEventPublisher(webhookService)
+	mergeService.SetEventPublisher(webhookService)
 	dashboardService := dashboard.NewDashboardService(db)
 	reportsService := reports.NewReportsService(db)
+	vapidKeys, err := notifications.ParseVAPIDKeys(config.VAPIDPrivateKey, config.VAPIDSubject)
+	if err != nil {
+		log.Fatal("Failed to load VAPID key:", err)
+	}
+	notificationChannels := notifications.NewChannelRegistry(
+		notifications.NewBarkClient(db, config.AppURL),
+		notifications.NewNtfyChannel(config.AppURL),
+		notifications.NewGotifyChannel(config.AppURL),
+		notifications.NewWebPushChannel(db, vapidKeys, config.AppURL),
+	)
+	if config.SMTPHost != "" {
+		notificationChannels.Register(notifications.NewEmailChannel(notifications.SMTPConfig{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+			Security: config.SMTPSecurity,
+		}))
+	}
+	var telegramBot *telegram.Bot
+	if config.TelegramBotToken != "" {
+		telegramClient := telegram.NewClient(config.TelegramAPIURL, config.TelegramBotToken)
+		notificationChannels.Register(telegram.NewChannel(telegramClient))
+		telegramBot = telegram.NewBot(db, telegramClient, expenseService)
+	}
 	notificationSettingService := notifications.NewNotificationSettingService(db)
+	notificationDestinationService := notifications.NewNotificationDestinationService(db, notificationChannels)
+	webPushService := notifications.NewWebPushService(db, vapidKeys)
+	calendarService := calendar.NewCalendarService(db)
+	forecastService := forecast.NewForecastService(db)
+	plannedExpenseService := forecast.NewPlannedExpenseService(db)
+	forecast.RegisterMergeReferences(mergeService)
 
 	// Initialize handlers
 	authHandler := auth.NewAuthHandler(userService, userDeviceService, config, auth.NewRateLimiter(5, 1))
@@ -70,11 +121,23 @@ func main() {
 	userDeviceHandler := users.NewUserDeviceHandler(userDeviceService)
 	walletHandler := expenses.NewWalletHandler(walletService)
 	paymentHandler := expenses.NewPaymentHandler(paymentService)
+	transferHandler := expenses.NewTransferHandler(transferService)
+	statementHandler := expenses.NewStatementHandler(statementService)
+	occurrenceHandler := expenses.NewOccurrenceHandler(occurrenceService)
+	installmentHandler := expenses.NewInstallmentHandler(installmentService)
+	reconciliationHandler := expenses.NewReconciliationHandler(reconciliationService)
+	mergeHandler := expenses.NewMergeHandler(mergeService)
 	expenseTypeHandler := expenses.NewExpenseTypeHandler(expenseTypeService)
 	expenseHandler := expenses.NewExpenseHandler(expenseService)
 	dashboardHandler := dashboard.NewDashboardHandler(dashboardService)
 	reportsHandler := reports.NewReportsHandler(reportsService)
 	notificationSettingHandler := notifications.NewNotificationSettingHandler(notificationSettingService)
+	notificationDestinationHandler := notifications.NewNotificationDestinationHandler(notificationDestinationService)
+	webPushHandler := notifications.NewWebPushHandler(webPushService, userDeviceService)
+	webhookHandler := webhooks.NewWebhookHandler(webhookService)
+	telegramHandler := telegram.NewHandler(telegramBot)
+	calendarHandler := calendar.NewCalendarHandler(calendarService, userService)
+	forecastHandler := forecast.NewForecastHandler(forecastService, plannedExpenseService)
 	shortcutHandler := expenses.NewShortcutHandler(expenseService, expenseTypeService, walletService)
 
 	// Initialize Echo
@@ -114,6 +177,9 @@ func main() {
 	api.POST("/auth/register", authHandler.Register)
 	api.POST("/auth/refresh", authHandler.RefreshToken)
 
+	// Calendar feed (per-user feed token in the path)
+	api.GET("/calendar/:token", calendarHandler.GetFeed)
+
 	// Protected routes (authentication required)
 	protected := api.Group("")
 	protected.Use(auth.JWTMiddleware(config))
@@ -126,7 +192,10 @@ func main() {
 	// User routes
 	protected.PUT("/user/preferences/currency", userHandler.UpdateCurrencySymbol)
 	protected.PUT("/user/preferences/language", userHandler.UpdateLanguage)
+	protected.PUT("/user/preferences/installment-view", userHandler.UpdateInstallmentView)
 	protected.POST("/user/preferences/automation-key/rotate", userHandler.RotateAutomationAPIKey)
+	protected.POST("/user/preferences/calendar-token/rotate", userHandler.RotateCalendarFeedToken)
+	protected.DELETE("/user/preferences/calendar-token", userHandler.DisableCalendarFeed)
 	protected.DELETE("/user/account", userHandler.DeleteUserAccount)
 
 	// Device management routes
@@ -137,12 +206,40 @@ func main() {
 	// Notification settings routes
 	protected.GET("/notification-settings", notificationSettingHandler.GetSettings)
 	protected.PUT("/notification-settings", notificationSettingHandler.UpdateSettings)
-	protected.POST("/notification-settings/test", notificationSettingHandler.TestBark)
+	protected.POST("/notification-settings/test", notificationDestinationHandler.TestAllDestinations)
+	protected.GET("/notification-destinations", notificationDestinationHandler.ListDestinations)
+	protected.POST("/notification-destinations", notificationDestinationHandler.CreateDestination)
+	protected.PUT("/notification-destinations/:id", notificationDestinationHandler.UpdateDestination)
+	protected.DELETE("/notification-destinations/:id", notificationDestinationHandler.DeleteDestination)
+	protected.POST("/notification-destinations/:id/test", notificationDestinationHandler.TestDestination)
+	protected.POST("/notification-destinations/:id/verify", notificationDestinationHandler.VerifyDestination)
+	protected.POST("/notification-destinations/:id/resend-verification", notificationDestinationHandler.ResendVerification)
+	protected.GET("/notification-deliveries", notificationDestinationHandler.ListDeliveries)
+
+	// Web Push routes (the subscription belongs to the current device)
+	protected.GET("/web-push", webPushHandler.GetStatus)
+	protected.PUT("/web-push/subscription", webPushHandler.Subscribe)
+	protected.DELETE("/web-push/subscription", webPushHandler.Unsubscribe)
+
+	// Webhook routes
+	protected.GET("/webhooks", webhookHandler.ListWebhooks)
+	protected.POST("/webhooks", webhookHandler.CreateWebhook)
+	protected.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+	protected.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+	protected.POST("/webhooks/:id/rotate-secret", webhookHandler.RotateSecret)
+	protected.POST("/webhooks/:id/test", webhookHandler.TestWebhook)
+	protected.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+	protected.POST("/webhook-deliveries/:id/redeliver", webhookHandler.Redeliver)
+
+	// Telegram routes
+	protected.GET("/telegram", telegramHandler.GetStatus)
+	protected.POST("/telegram/link", telegramHandler.CreateLink)
 
 	// Dashboard routes
 	protected.GET("/dashboard/stats", dashboardHandler.GetDashboardStats)
 	protected.GET("/dashboard/due-wallets", dashboardHandler.GetDueWallets)
 	protected.GET("/dashboard/due-expenses", dashboardHandler.GetDueExpenses)
+	protected.GET("/dashboard/utilization-warnings", dashboardHandler.GetUtilizationWarnings)
 
 	// Reports routes
 	protected.GET("/reports/monthly", reportsHandler.GetMonthlyReport)
@@ -151,12 +248,24 @@ func main() {
 	// Wallet routes
 	protected.GET("/wallets", walletHandler.ListWallets)
 	protected.POST("/wallets", walletHandler.CreateWallet)
+	protected.POST("/wallets/schedule-preview", walletHandler.PreviewWalletSchedule)
 	protected.GET("/wallets/:id", walletHandler.GetWallet)
 	protected.PUT("/wallets/:id", walletHandler.UpdateWallet)
 	protected.DELETE("/wallets/:id", walletHandler.DeleteWallet)
 	protected.POST("/wallets/:id/toggle", walletHandler.ToggleWallet)
 	protected.GET("/wallets/:id/payments", walletHandler.GetWalletPayments)
 	protected.GET("/wallets/:id/unbilled-expenses", walletHandler.GetUnbilledExpenses)
+	protected.GET("/wallets/:id/statements", statementHandler.ListWalletStatements)
+	protected.GET("/wallets/:id/occurrences", occurrenceHandler.ListWalletOccurrences)
+	protected.GET("/wallets/:id/utilization", walletHandler.GetUtilization)
+	protected.GET("/wallets/:id/utilization/history", walletHandler.GetUtilizationHistory)
+	protected.POST("/wallets/:id/reconciliation/preview", reconciliationHandler.PreviewReconciliation)
+	protected.POST("/wallets/:id/reconciliation/confirm", reconciliationHandler.ConfirmReconciliation)
+	protected.GET("/wallets/:id/merge-preview", mergeHandler.PreviewWalletMerge)
+	protected.POST("/wallets/:id/merge", mergeHandler.MergeWallets)
+
+	// Statement routes
+	protected.GET("/statements/:id", statementHandler.GetStatement)
 
 	// Payment routes
 	protected.GET("/payments", paymentHandler.ListPayments)
@@ -165,17 +274,49 @@ func main() {
 	protected.GET("/payments/:id", paymentHandler.GetPayment)
 	protected.PUT("/payments/:id", paymentHandler.UpdatePayment)
 	protected.DELETE("/payments/:id", paymentHandler.DeletePayment)
+	protected.GET("/payments/:id/suggestions", paymentHandler.GetPaymentSuggestions)
+	protected.POST("/payments/:id/suggestions/accept", paymentHandler.AcceptPaymentSuggestion)
+
+	// Transfer routes
+	protected.GET("/transfers", transferHandler.ListTransfers)
+	protected.POST("/transfers", transferHandler.CreateTransfer)
+	protected.GET("/transfers/:id", transferHandler.GetTransfer)
+	protected.DELETE("/transfers/:id", transferHandler.DeleteTransfer)
+
+	// Installment plan routes
+	protected.GET("/installment-plans", installmentHandler.ListInstallmentPlans)
+	protected.POST("/installment-plans", installmentHandler.CreateInstallmentPlan)
+	protected.GET("/installment-plans/:id", installmentHandler.GetInstallmentPlan)
+	protected.DELETE("/installment-plans/:id", installmentHandler.DeleteInstallmentPlan)
 
 	// Expense Type routes
 	protected.GET("/expense-types", expenseTypeHandler.ListExpenseTypes)
 	protected.POST("/expense-types", expenseTypeHandler.CreateExpenseType)
 	protected.GET("/expense-types/tree", expenseTypeHandler.GetExpenseTypeTree)
+	protected.POST("/expense-types/schedule-preview", expenseTypeHandler.PreviewExpenseTypeSchedule)
+	protected.POST("/expense-types/batch", expenseTypeHandler.BatchCreateExpenseTypes)
+	protected.GET("/expense-types/presets", expenseTypeHandler.ListExpenseTypePresets)
+	protected.POST("/expense-types/presets/:id/apply", expenseTypeHandler.ApplyExpenseTypePreset)
+	protected.GET("/expense-types/export", expenseTypeHandler.ExportExpenseTypes)
+	protected.POST("/expense-types/import", expenseTypeHandler.ImportExpenseTypes)
 	protected.GET("/expense-types/:id", expenseTypeHandler.GetExpenseType)
 	protected.PUT("/expense-types/:id", expenseTypeHandler.UpdateExpenseType)
 	protected.PUT("/expense-types/:id/default-amount", expenseTypeHandler.UpdateExpenseTypeDefaultAmount)
+	protected.GET("/expense-types/:id/amounts", expenseTypeHandler.ListExpenseTypeAmounts)
+	protected.DELETE("/expense-types/:id/amounts/:amountId", expenseTypeHandler.CancelExpenseTypeAmount)
 	protected.PUT("/expense-types/:id/postpone", expenseTypeHandler.PostponeExpenseType)
 	protected.DELETE("/expense-types/:id", expenseTypeHandler.DeleteExpenseType)
 	protected.POST("/expense-types/:id/toggle", expenseTypeHandler.ToggleExpenseType)
+	protected.POST("/expense-types/:id/move", expenseTypeHandler.MoveExpenseType)
+	protected.GET("/expense-types/:id/merge-preview", mergeHandler.PreviewExpenseTypeMerge)
+	protected.POST("/expense-types/:id/merge", mergeHandler.MergeExpenseTypes)
+	protected.GET("/expense-types/:id/occurrences", occurrenceHandler.ListExpenseTypeOccurrences)
+
+	// Occurrence routes
+	protected.POST("/occurrences/:id/skip", occurrenceHandler.SkipOccurrence)
+
+	// Holiday calendars used by business day rules
+	protected.GET("/holiday-regions", expenseTypeHandler.ListHolidayRegions)
 
 	// Expense routes
 	protected.GET("/expenses", expenseHandler.ListExpenses)
@@ -185,16 +326,35 @@ func main() {
 	protected.PUT("/expenses/:id", expenseHandler.UpdateExpense)
 	protected.DELETE("/expenses/:id", expenseHandler.DeleteExpense)
 
+	// Forecast routes
+	protected.GET("/forecast", forecastHandler.GetForecast)
+	protected.GET("/planned-expenses", forecastHandler.ListPlannedExpenses)
+	protected.POST("/planned-expenses", forecastHandler.CreatePlannedExpense)
+	protected.GET("/planned-expenses/:id", forecastHandler.GetPlannedExpense)
+	protected.PUT("/planned-expenses/:id", forecastHandler.UpdatePlannedExpense)
+	protected.DELETE("/planned-expenses/:id", forecastHandler.DeletePlannedExpense)
+
 	// Automation routes (per-user automation API key via query string)
 	automation := api.Group("/automation")
 	automation.Use(auth.AutomationAPIKeyMiddleware(userService))
 	automation.POST("/expense", shortcutHandler.CreateAutomationExpense)
 
 	// Start background notifier
-	notifier := notifications.NewNotifier(db)
+	notifier := notifications.NewNotifier(db, notificationChannels)
+	notifier.SetEventPublisher(webhookService)
 	notifier.Start()
 	defer notifier.Stop()
 
+	// Start webhook dispatcher
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+
+	// Start Telegram bot
+	if telegramBot != nil {
+		telegramBot.Start()
+		defer telegramBot.Stop()
+	}
+
 	// Start server
 	e.GET("*", func(c echo.Context) error {
 		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache, no-store, must-revalidate")
@@ -213,7 +373,19 @@ func migrateSchema(db *gorm.DB) error {
 		&expenses.ExpenseType{},
 		&expenses.Payment{},
 		&expenses.Expense{},
+		&expenses.Transfer{},
+		&expenses.Statement{},
+		&expenses.InstallmentPlan{},
+		&expenses.RecurrenceOccurrence{},
+		&expenses.ExpenseTypeAmount{},
+		&forecast.PlannedExpense{},
 		&notifications.NotificationSetting{},
+		&notifications.NotificationDestination{},
+		&notifications.NotificationDelivery{},
+		&notifications.PushSubscription{},
+		&webhooks.Webhook{},
+		&webhooks.WebhookDelivery{},
+		&telegram.LinkCode{},
 	); err != nil {
 		return err
 	}
@@ -232,6 +404,30 @@ func migrateSchema(db *gorm.DB) error {
 		{model: &expenses.Expense{}, name: "ExpenseType"},
 		{model: &expenses.Expense{}, name: "Wallet"},
 		{model: &expenses.Expense{}, name: "Payment"},
+		{model: &expenses.Transfer{}, name: "FromWallet"},
+		{model: &expenses.Transfer{}, name: "ToWallet"},
+		{model: &expenses.Transfer{}, name: "Payment"},
+		{model: &expenses.Statement{}, name: "Wallet"},
+		{model: &expenses.Statement{}, name: "Expenses"},
+		{model: &expenses.InstallmentPlan{}, name: "ExpenseType"},
+		{model: &expenses.InstallmentPlan{}, name: "Wallet"},
+		{model: &expenses.InstallmentPlan{}, name: "Charges"},
+		{model: &expenses.ExpenseTypeAmount{}, name: "ExpenseType"},
+		{model: &expenses.RecurrenceOccurrence{}, name: "ExpenseType"},
+		{model: &expenses.RecurrenceOccurrence{}, name: "Wallet"},
+		{model: &expenses.RecurrenceOccurrence{}, name: "Expense"},
+		{model: &expenses.RecurrenceOccurrence{}, name: "Payment"},
+		{model: &forecast.PlannedExpense{}, name: "ExpenseType"},
+		{model: &forecast.PlannedExpense{}, name: "Wallet"},
+		{model: &notifications.NotificationDestination{}, name: "User"},
+		{model: &notifications.NotificationDelivery{}, name: "User"},
+		{model: &notifications.NotificationDelivery{}, name: "Destination"},
+		{model: &notifications.PushSubscription{}, name: "User"},
+		{model: &notifications.PushSubscription{}, name: "Device"},
+		{model: &notifications.PushSubscription{}, name: "Destination"},
+		{model: &webhooks.Webhook{}, name: "User"},
+		{model: &webhooks.WebhookDelivery{}, name: "Webhook"},
+		{model: &telegram.LinkCode{}, name: "User"},
 	}
 
 	for _, constraint := range constraints {
@@ -244,7 +440,7 @@ func migrateSchema(db *gorm.DB) error {
 		}
 	}
 
-	return nil
+	return notifications.MigrateLegacyBarkDestinations(db)
 }
 
 func precompressedStaticMiddleware(root string) echo.MiddlewareFunc {
@@ -0,0 +1,42 @@
+package calendar
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/users"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CalendarHandler struct {
+	service     *CalendarService
+	userService *users.UserService
+}
+
+func NewCalendarHandler(service *CalendarService, userService *users.UserService) *CalendarHandler {
+	return &CalendarHandler{service: service, userService: userService}
+}
+
+// GetFeed handles GET /api/calendar/:token. The token may carry an .ics
+// suffix so calendar apps recognise the URL.
+func (h *CalendarHandler) GetFeed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	user, err := h.userService.GetUserByCalendarFeedToken(token)
+	if err != nil {
+		if err == users.ErrUserNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Calendar feed not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load calendar feed"})
+	}
+
+	feed, err := h.service.BuildFeed(*user, time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to build calendar feed"})
+	}
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="jiceot.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
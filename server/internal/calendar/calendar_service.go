@@ -0,0 +1,272 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/dashboard"
+	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/notifications"
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+// FeedMonths is how many months, starting with the current one, the feed
+// covers.
+const FeedMonths = 3
+
+// defaultReminderMinutes is used when the user has not set a reminder time.
+const defaultReminderMinutes = 9 * 60
+
+const feedRefreshInterval = 6 * time.Hour
+
+// CalendarService builds the .ics feed of upcoming card payments and
+// recurring expenses from the dashboard's due-date logic.
+type CalendarService struct {
+	db        *gorm.DB
+	dashboard *dashboard.DashboardService
+	settings  *notifications.NotificationSettingService
+}
+
+func NewCalendarService(db *gorm.DB) *CalendarService {
+	return &CalendarService{
+		db:        db,
+		dashboard: dashboard.NewDashboardService(db),
+		settings:  notifications.NewNotificationSettingService(db),
+	}
+}
+
+// BuildFeed renders the user's feed as of now. Each event has a UID derived
+// from the wallet or expense type and its due date, so calendar apps update
+// an occurrence in place instead of duplicating it.
+func (s *CalendarService) BuildFeed(user users.User, now time.Time) ([]byte, error) {
+	setting, err := s.settings.GetByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification settings: %w", err)
+	}
+	alarm := alarmMinutes(setting.DueDaysAhead, setting.ReminderTime)
+
+	events, err := s.walletEvents(user, now, alarm)
+	if err != nil {
+		return nil, err
+	}
+	expenseEvents, err := s.expenseEvents(user, now, alarm)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, expenseEvents...)
+
+	feed := Calendar{
+		Name:            "Jiceot",
+		RefreshInterval: feedRefreshInterval,
+		Events:          events,
+	}
+	return feed.Encode(now), nil
+}
+
+func (s *CalendarService) walletEvents(user users.User, now time.Time, alarm *int) ([]Event, error) {
+	events := make([]Event, 0)
+	seen := make(map[string]bool)
+	dueWallets := make([]dashboard.DueWallet, 0)
+	month := expenses.BeginningOfMonth(now.Year(), int(now.Month()))
+	for index := 0; index < FeedMonths; index++ {
+		response, err := s.dashboard.GetDueWallets(user.ID, month.Year(), int(month.Month()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load due wallets: %w", err)
+		}
+		for _, wallet := range response.DueWallets {
+			uid := occurrenceUID("wallet", wallet.ID, wallet.NextDueDate)
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+			dueWallets = append(dueWallets, wallet)
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	if len(dueWallets) == 0 {
+		return events, nil
+	}
+
+	ids := make([]uint, 0, len(dueWallets))
+	dueDates := make([]string, 0, len(dueWallets))
+	for _, wallet := range dueWallets {
+		ids = append(ids, wallet.ID)
+		dueDates = append(dueDates, wallet.NextDueDate)
+	}
+	var statements []expenses.Statement
+	if err := s.db.Where("user_id = ? AND wallet_id IN ? AND due_date IN ?", user.ID, ids, dueDates).Find(&statements).Error; err != nil {
+		return nil, fmt.Errorf("failed to load statements: %w", err)
+	}
+	statementByDue := make(map[string]expenses.Statement, len(statements))
+	for _, statement := range statements {
+		statementByDue[occurrenceUID("wallet", statement.WalletID, statement.DueDate.Format(expenses.DateOnlyLayout))] = statement
+	}
+
+	type outstandingRow struct {
+		WalletID uint
+		Amount   float64
+	}
+	var rows []outstandingRow
+	if err := s.db.Model(&expenses.Expense{}).
+		Select("wallet_id, COALESCE(SUM(amount), 0) AS amount").
+		Where("user_id = ? AND wallet_id IN ? AND payment_id IS NULL", user.ID, ids).
+		Group("wallet_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load outstanding balances: %w", err)
+	}
+	outstanding := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		outstanding[row.WalletID] = row.Amount
+	}
+
+	for _, wallet := range dueWallets {
+		date, err := expenses.ParseDateOnly(wallet.NextDueDate)
+		if err != nil {
+			continue
+		}
+		uid := occurrenceUID("wallet", wallet.ID, wallet.NextDueDate)
+		var description string
+		if statement, ok := statementByDue[uid]; ok {
+			description = fmt.Sprintf("Statement balance: %s\nMinimum payment: %s",
+				formatAmount(user.CurrencySymbol, statement.StatementBalance),
+				formatAmount(user.CurrencySymbol, statement.MinimumPayment))
+		} else {
+			description = "Outstanding: " + formatAmount(user.CurrencySymbol, outstanding[wallet.ID])
+		}
+		events = append(events, Event{
+			UID:          uid,
+			Date:         date,
+			Summary:      wallet.Name + " payment due",
+			Description:  description,
+			AlarmMinutes: alarm,
+		})
+	}
+	return events, nil
+}
+
+// expenseEvents adds one event per due date of each recurring expense type
+// inside the feed window. Flexible types only show their suggested date, as
+// the cycles after it depend on when it is paid.
+func (s *CalendarService) expenseEvents(user users.User, now time.Time, alarm *int) ([]Event, error) {
+	last := expenses.BeginningOfMonth(now.Year(), int(now.Month())).AddDate(0, FeedMonths-1, 0)
+	windowEnd := expenses.EndOfMonth(last.Year(), int(last.Month()))
+	response, err := s.dashboard.GetDueExpenses(user.ID, last.Year(), int(last.Month()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load due expenses: %w", err)
+	}
+	items := append(append([]dashboard.DueExpense{}, response.FixedDue...), response.FlexibleSuggested...)
+	events := make([]Event, 0, len(items))
+	if len(items) == 0 {
+		return events, nil
+	}
+
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	var expenseTypes []expenses.ExpenseType
+	if err := s.db.Where("user_id = ? AND id IN ?", user.ID, ids).Find(&expenseTypes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load expense types: %w", err)
+	}
+	expenseTypeByID := make(map[uint]expenses.ExpenseType, len(expenseTypes))
+	for _, expenseType := range expenseTypes {
+		expenseTypeByID[expenseType.ID] = expenseType
+	}
+	amounts, err := expenses.LoadAmountSchedule(s.db, user.ID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load expense amounts: %w", err)
+	}
+
+	for _, item := range items {
+		first, err := expenses.ParseDateOnly(item.NextDueDate)
+		if err != nil {
+			continue
+		}
+		dates := []time.Time{first}
+		expenseType, ok := expenseTypeByID[item.ID]
+		if ok && expenseType.RecurringType == expenses.RecurringTypeFixedDay {
+			dates = expenseTypeDueDates(expenseType, first, windowEnd)
+		}
+		for _, date := range dates {
+			amount := item.DefaultAmount
+			if ok {
+				amount = amounts.AmountOn(expenseType, date)
+			}
+			description := ""
+			if amount > 0 {
+				description = "Amount: " + formatAmount(user.CurrencySymbol, amount)
+			}
+			dueDate := date.Format(expenses.DateOnlyLayout)
+			events = append(events, Event{
+				UID:          occurrenceUID("expense-type", item.ID, dueDate),
+				Date:         date,
+				Summary:      item.Name + " due",
+				Description:  description,
+				AlarmMinutes: alarm,
+			})
+		}
+	}
+	return events, nil
+}
+
+// expenseTypeDueDates returns first and the type's following due dates up to
+// end, stopping early at the type's end date or remaining occurrence count.
+func expenseTypeDueDates(expenseType expenses.ExpenseType, first, end time.Time) []time.Time {
+	dates := []time.Time{first}
+	for {
+		if expenseType.RemainingOccurrences != nil && len(dates) >= *expenseType.RemainingOccurrences {
+			return dates
+		}
+		previous := dates[len(dates)-1]
+		next, err := expenses.FollowingExpenseTypeDueDate(expenseType, previous)
+		if err != nil || next == nil || next.After(end) || !next.After(previous) {
+			return dates
+		}
+		dates = append(dates, *next)
+	}
+}
+
+func occurrenceUID(kind string, id uint, dueDate string) string {
+	return fmt.Sprintf("%s-%d-%s@jiceot", kind, id, strings.ReplaceAll(dueDate, "-", ""))
+}
+
+// alarmMinutes places the reminder DueDaysAhead days before the due date at
+// the user's reminder time.
+func alarmMinutes(daysAhead int, reminderTime string) *int {
+	if daysAhead < 0 {
+		daysAhead = 0
+	}
+	minutes := defaultReminderMinutes
+	if hour, minute, ok := parseReminderTime(reminderTime); ok {
+		minutes = hour*60 + minute
+	}
+	offset := minutes - daysAhead*24*60
+	return &offset
+}
+
+func parseReminderTime(value string) (int, int, bool) {
+	hourText, minuteText, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, 0, false
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+	minute, err := strconv.Atoi(minuteText)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+func formatAmount(symbol string, amount float64) string {
+	if symbol == "" {
+		symbol = users.DefaultCurrencySymbol
+	}
+	return fmt.Sprintf("%s%.2f", symbol, amount)
+}
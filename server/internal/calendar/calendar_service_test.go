@@ -0,0 +1,53 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+)
+
+func TestExpenseTypeDueDates(t *testing.T) {
+	endDate := mustParseDate(t, "2025-02-20")
+	two := 2
+	tests := []struct {
+		name      string
+		endDate   *time.Time
+		remaining *int
+		want      string
+	}{
+		{name: "every cycle in window", want: "2025-01-15,2025-02-15,2025-03-15"},
+		{name: "stops at end date", endDate: &endDate, want: "2025-01-15,2025-02-15"},
+		{name: "stops at remaining count", remaining: &two, want: "2025-01-15,2025-02-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expenseType := expenses.ExpenseType{
+				RecurringType:        expenses.RecurringTypeFixedDay,
+				RecurringPeriod:      expenses.RecurringPeriodMonthly,
+				RecurringDueDay:      15,
+				EndDate:              tt.endDate,
+				RemainingOccurrences: tt.remaining,
+			}
+			dates := expenseTypeDueDates(expenseType, mustParseDate(t, "2025-01-15"), mustParseDate(t, "2025-03-31"))
+			formatted := make([]string, 0, len(dates))
+			for _, date := range dates {
+				formatted = append(formatted, date.Format(expenses.DateOnlyLayout))
+			}
+			if got := strings.Join(formatted, ","); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func mustParseDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := expenses.ParseDateOnly(value)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", value, err)
+	}
+	return date
+}
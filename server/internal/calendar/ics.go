@@ -0,0 +1,144 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405Z"
+	icsLineLimit      = 75
+)
+
+// Event is one all-day entry in a feed. AlarmMinutes is the reminder offset
+// from the start of the day; negative values fire on earlier days. A nil
+// AlarmMinutes adds no reminder.
+type Event struct {
+	UID          string
+	Date         time.Time
+	Summary      string
+	Description  string
+	AlarmMinutes *int
+}
+
+// Calendar is a minimal RFC 5545 VCALENDAR of all-day events.
+type Calendar struct {
+	Name            string
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Encode renders the calendar with CRLF line endings and lines folded at 75
+// octets. stamp is written as DTSTAMP on every event.
+func (c Calendar) Encode(stamp time.Time) []byte {
+	var builder strings.Builder
+	write := func(name, value string) {
+		builder.WriteString(foldLine(name + ":" + value))
+		builder.WriteString("\r\n")
+	}
+
+	write("BEGIN", "VCALENDAR")
+	write("VERSION", "2.0")
+	write("PRODID", "-//Jiceot//Due Calendar//EN")
+	write("CALSCALE", "GREGORIAN")
+	write("METHOD", "PUBLISH")
+	if c.Name != "" {
+		write("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		duration := formatDuration(int(c.RefreshInterval / time.Minute))
+		write("REFRESH-INTERVAL;VALUE=DURATION", duration)
+		write("X-PUBLISHED-TTL", duration)
+	}
+
+	stampValue := stamp.UTC().Format(icsDateTimeLayout)
+	for _, event := range c.Events {
+		write("BEGIN", "VEVENT")
+		write("UID", escapeText(event.UID))
+		write("DTSTAMP", stampValue)
+		write("DTSTART;VALUE=DATE", event.Date.Format(icsDateLayout))
+		write("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(icsDateLayout))
+		write("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			write("DESCRIPTION", escapeText(event.Description))
+		}
+		write("TRANSP", "TRANSPARENT")
+		if event.AlarmMinutes != nil {
+			write("BEGIN", "VALARM")
+			write("ACTION", "DISPLAY")
+			write("DESCRIPTION", escapeText(event.Summary))
+			write("TRIGGER", formatDuration(*event.AlarmMinutes))
+			write("END", "VALARM")
+		}
+		write("END", "VEVENT")
+	}
+	write("END", "VCALENDAR")
+	return []byte(builder.String())
+}
+
+// escapeText escapes a TEXT value as required by RFC 5545 section 3.3.11.
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// foldLine splits a content line into 75-octet chunks joined by CRLF and a
+// space, never splitting a UTF-8 sequence.
+func foldLine(line string) string {
+	if len(line) <= icsLineLimit {
+		return line
+	}
+	var builder strings.Builder
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		builder.WriteString(line[:cut])
+		builder.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit.
+		limit = icsLineLimit - 1
+	}
+	builder.WriteString(line)
+	return builder.String()
+}
+
+// formatDuration renders a signed number of minutes as an RFC 5545 duration,
+// e.g. -2880 as -P2D and -2340 as -P1DT15H.
+func formatDuration(minutes int) string {
+	sign := ""
+	if minutes < 0 {
+		sign = "-"
+		minutes = -minutes
+	}
+	days := minutes / (24 * 60)
+	hours := minutes % (24 * 60) / 60
+	mins := minutes % 60
+
+	value := sign + "P"
+	if days > 0 {
+		value += fmt.Sprintf("%dD", days)
+	}
+	if hours > 0 || mins > 0 || days == 0 {
+		value += "T"
+		if hours > 0 {
+			value += fmt.Sprintf("%dH", hours)
+		}
+		if mins > 0 || hours == 0 {
+			value += fmt.Sprintf("%dM", mins)
+		}
+	}
+	return value
+}
@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFoldLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("信用卡", 20)
+	folded := foldLine(line)
+	for index, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icsLineLimit {
+			t.Fatalf("line %d has %d octets", index, len(part))
+		}
+		if index > 0 && !strings.HasPrefix(part, " ") {
+			t.Fatalf("continuation line %d does not start with a space", index)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Fatalf("unfolding did not restore the original line")
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	got := escapeText("Rent; utilities, etc.\\\nsecond line")
+	want := `Rent\; utilities\, etc.\\\nsecond line`
+	if got != want {
+		t.Fatalf("escapeText = %q, want %q", got, want)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    string
+	}{
+		{minutes: 0, want: "PT0M"},
+		{minutes: 540, want: "PT9H"},
+		{minutes: -2880, want: "-P2D"},
+		{minutes: -2340, want: "-P1DT15H"},
+		{minutes: -4290, want: "-P2DT23H30M"},
+		{minutes: 360, want: "PT6H"},
+	}
+	for _, test := range tests {
+		if got := formatDuration(test.minutes); got != test.want {
+			t.Fatalf("formatDuration(%d) = %s, want %s", test.minutes, got, test.want)
+		}
+	}
+}
+
+func TestAlarmMinutes(t *testing.T) {
+	if got := *alarmMinutes(3, "09:00"); got != 540-3*24*60 {
+		t.Fatalf("alarmMinutes(3, 09:00) = %d", got)
+	}
+	if got := *alarmMinutes(0, ""); got != defaultReminderMinutes {
+		t.Fatalf("alarmMinutes(0, \"\") = %d, want %d", got, defaultReminderMinutes)
+	}
+}
+
+func TestCalendarEncode(t *testing.T) {
+	alarm := -2340
+	feed := Calendar{
+		Name:            "Jiceot",
+		RefreshInterval: 6 * time.Hour,
+		Events: []Event{{
+			UID:          "wallet-7-20250405@jiceot",
+			Date:         time.Date(2025, 4, 5, 0, 0, 0, 0, time.UTC),
+			Summary:      "Visa payment due",
+			Description:  "Outstanding: $120.00",
+			AlarmMinutes: &alarm,
+		}},
+	}
+	output := string(feed.Encode(time.Date(2025, 4, 1, 8, 30, 0, 0, time.UTC)))
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT6H\r\n",
+		"UID:wallet-7-20250405@jiceot\r\n",
+		"DTSTAMP:20250401T083000Z\r\n",
+		"DTSTART;VALUE=DATE:20250405\r\n",
+		"DTEND;VALUE=DATE:20250406\r\n",
+		"TRIGGER:-P1DT15H\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("feed is missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -93,3 +93,17 @@ func (h *DashboardHandler) GetDueExpenses(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, dueExpenses)
 }
+
+// GetUtilizationWarnings returns credit wallets over their utilization alert
+func (h *DashboardHandler) GetUtilizationWarnings(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+
+	warnings, err := h.service.GetUtilizationWarnings(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load utilization warnings",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"warnings": warnings, "total": len(warnings)})
+}
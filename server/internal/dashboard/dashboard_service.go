@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/users"
 
 	"gorm.io/gorm"
 )
@@ -14,14 +15,26 @@ type DashboardService struct {
 }
 
 type DashboardStats struct {
-	TotalExpenses    float64      `json:"total_expenses"`
-	PaymentsMade     int64        `json:"payments_made"`
-	PendingWallets   int          `json:"pending_wallets"`
-	PendingExpenses  int          `json:"pending_expenses"`
-	Categories       int64        `json:"categories"`
-	DueWallets       []DueWallet  `json:"due_wallets"`
-	FixedExpenses    []DueExpense `json:"fixed_expenses"`
-	FlexibleExpenses []DueExpense `json:"flexible_expenses"`
+	TotalExpenses       float64              `json:"total_expenses"`
+	PaymentsMade        int64                `json:"payments_made"`
+	PendingWallets      int                  `json:"pending_wallets"`
+	PendingExpenses     int                  `json:"pending_expenses"`
+	Categories          int64                `json:"categories"`
+	DueWallets          []DueWallet          `json:"due_wallets"`
+	FixedExpenses       []DueExpense         `json:"fixed_expenses"`
+	FlexibleExpenses    []DueExpense         `json:"flexible_expenses"`
+	UtilizationWarnings []UtilizationWarning `json:"utilization_warnings"`
+}
+
+type UtilizationWarning struct {
+	WalletID         uint    `json:"wallet_id"`
+	Name             string  `json:"name"`
+	Icon             string  `json:"icon"`
+	Color            string  `json:"color"`
+	CreditLimit      float64 `json:"credit_limit"`
+	Outstanding      float64 `json:"outstanding"`
+	Utilization      float64 `json:"utilization"`
+	UtilizationAlert float64 `json:"utilization_alert"`
 }
 
 type DueWallet struct {
@@ -35,6 +48,7 @@ type DueWallet struct {
 	DaysUntilDue int     `json:"days_until_due"`
 	Status       string  `json:"status"`
 	HasPayment   bool    `json:"has_payment"`
+	IsFinal      bool    `json:"is_final"`
 	LastPaidAt   *string `json:"last_paid_at,omitempty"`
 }
 
@@ -56,6 +70,7 @@ type DueExpense struct {
 	NextDueDate     string  `json:"next_due_date"`
 	DaysUntilDue    int     `json:"days_until_due"`
 	Status          string  `json:"status"`
+	IsFinal         bool    `json:"is_final"`
 }
 
 type DueExpensesResponse struct {
@@ -74,10 +89,26 @@ func (s *DashboardService) GetDashboardStats(userID uint) (*DashboardStats, erro
 	start := expenses.BeginningOfMonth(now.Year(), int(now.Month()))
 	end := expenses.EndOfMonth(now.Year(), int(now.Month()))
 
+	var user users.User
+	if err := s.db.Select("id", "installment_view").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
 	var totalExpenses float64
-	if err := s.db.Model(&expenses.Expense{}).Where("user_id = ? AND date >= ? AND date <= ?", userID, start, end).Select("COALESCE(SUM(amount), 0)").Scan(&totalExpenses).Error; err != nil {
+	expenseQuery := s.db.Model(&expenses.Expense{}).Where("user_id = ? AND date >= ? AND date <= ?", userID, start, end)
+	if user.InstallmentView == users.InstallmentViewFull {
+		expenseQuery = expenseQuery.Where("installment_plan_id IS NULL")
+	}
+	if err := expenseQuery.Select("COALESCE(SUM(amount), 0)").Scan(&totalExpenses).Error; err != nil {
 		return nil, err
 	}
+	if user.InstallmentView == users.InstallmentViewFull {
+		var purchases float64
+		if err := s.db.Model(&expenses.InstallmentPlan{}).Where("user_id = ? AND purchase_date >= ? AND purchase_date <= ?", userID, start, end).Select("COALESCE(SUM(total_amount), 0)").Scan(&purchases).Error; err != nil {
+			return nil, err
+		}
+		totalExpenses += purchases
+	}
 
 	var paymentsMade int64
 	if err := s.db.Model(&expenses.Payment{}).Where("user_id = ? AND date >= ? AND date <= ?", userID, start, end).Count(&paymentsMade).Error; err != nil {
@@ -98,6 +129,11 @@ func (s *DashboardService) GetDashboardStats(userID uint) (*DashboardStats, erro
 		return nil, err
 	}
 
+	utilizationWarnings, err := s.GetUtilizationWarnings(userID)
+	if err != nil {
+		return nil, err
+	}
+
 	pendingCount := 0
 	for _, w := range dueWallets.DueWallets {
 		if w.DaysUntilDue <= 5 {
@@ -118,14 +154,15 @@ func (s *DashboardService) GetDashboardStats(userID uint) (*DashboardStats, erro
 	}
 
 	stats := &DashboardStats{
-		TotalExpenses:    totalExpenses,
-		PaymentsMade:     paymentsMade,
-		PendingWallets:   pendingCount,
-		PendingExpenses:  pendingExpenseCount,
-		Categories:       categoryCount,
-		DueWallets:       limitDueWallets(dueWallets.DueWallets, 5),
-		FixedExpenses:    limitDueExpenses(dueExpenses.FixedDue, 5),
-		FlexibleExpenses: limitDueExpenses(dueExpenses.FlexibleSuggested, 5),
+		TotalExpenses:       totalExpenses,
+		PaymentsMade:        paymentsMade,
+		PendingWallets:      pendingCount,
+		PendingExpenses:     pendingExpenseCount,
+		Categories:          categoryCount,
+		DueWallets:          limitDueWallets(dueWallets.DueWallets, 5),
+		FixedExpenses:       limitDueExpenses(dueExpenses.FixedDue, 5),
+		FlexibleExpenses:    limitDueExpenses(dueExpenses.FlexibleSuggested, 5),
+		UtilizationWarnings: utilizationWarnings,
 	}
 
 	return stats, nil
@@ -166,7 +203,7 @@ func (s *DashboardService) GetDueWallets(userID uint, year, month int) (*DueWall
 			lastPayment = &payment
 		}
 		nextDue := expenses.NextWalletDueDate(wallet, periodStart, lastPayment)
-		if nextDue.After(periodEnd) {
+		if nextDue.After(periodEnd) || wallet.EndsBefore(nextDue) {
 			continue
 		}
 		monthKey := nextDue.Format("2006-01")
@@ -186,6 +223,7 @@ func (s *DashboardService) GetDueWallets(userID uint, year, month int) (*DueWall
 			DaysUntilDue: dayDiff(now, nextDue),
 			Status:       status,
 			HasPayment:   false,
+			IsFinal:      expenses.IsFinalWalletDueDate(wallet, nextDue),
 		}
 		if lastPayment, ok := lastPaymentByWallet[wallet.ID]; ok {
 			formatted := lastPayment.Date.Format(expenses.DateOnlyLayout)
@@ -239,6 +277,15 @@ func (s *DashboardService) GetDueExpenses(userID uint, year, month int) (*DueExp
 		}
 	}
 
+	typeIDs := make([]uint, 0, len(expenseTypes))
+	for _, expenseType := range expenseTypes {
+		typeIDs = append(typeIDs, expenseType.ID)
+	}
+	amounts, err := expenses.LoadAmountSchedule(s.db, userID, typeIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	fixedDue := make([]DueExpense, 0)
 	flexibleSuggested := make([]DueExpense, 0)
 	for _, expenseType := range expenseTypes {
@@ -259,12 +306,13 @@ func (s *DashboardService) GetDueExpenses(userID uint, year, month int) (*DueExp
 			Name:            expenseType.Name,
 			Icon:            expenseType.Icon,
 			Color:           expenseType.Color,
-			DefaultAmount:   expenseType.DefaultAmount,
+			DefaultAmount:   amounts.AmountOn(expenseType, *nextDue),
 			RecurringType:   expenseType.RecurringType,
 			RecurringPeriod: expenseType.RecurringPeriod,
 			ReminderType:    expenses.EffectiveReminderType(expenseType),
 			NextDueDate:     nextDue.Format(expenses.DateOnlyLayout),
 			DaysUntilDue:    dayDiff(now, *nextDue),
+			IsFinal:         expenses.IsFinalExpenseTypeDueDate(expenseType, *nextDue),
 		}
 		if !shouldIncludeDueExpense(entry.ReminderType, entry.DaysUntilDue) {
 			continue
@@ -291,6 +339,60 @@ func (s *DashboardService) GetDueExpenses(userID uint, year, month int) (*DueExp
 	return &DueExpensesResponse{FixedDue: fixedDue, FlexibleSuggested: flexibleSuggested, Year: year, Month: month}, nil
 }
 
+// GetUtilizationWarnings lists credit wallets whose unpaid expenses have
+// reached the wallet's utilization alert percentage.
+func (s *DashboardService) GetUtilizationWarnings(userID uint) ([]UtilizationWarning, error) {
+	var wallets []expenses.Wallet
+	if err := s.db.Where("user_id = ? AND is_credit = ? AND stopped = ? AND credit_limit > 0", userID, true, false).Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	warnings := make([]UtilizationWarning, 0)
+	if len(wallets) == 0 {
+		return warnings, nil
+	}
+
+	type outstandingRow struct {
+		WalletID uint
+		Amount   float64
+	}
+	var rows []outstandingRow
+	if err := s.db.Model(&expenses.Expense{}).
+		Select("wallet_id, COALESCE(SUM(amount), 0) AS amount").
+		Where("user_id = ? AND wallet_id IN ? AND payment_id IS NULL", userID, walletIDs(wallets)).
+		Group("wallet_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	outstandingByWallet := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		outstandingByWallet[row.WalletID] = row.Amount
+	}
+
+	for _, wallet := range wallets {
+		outstanding := outstandingByWallet[wallet.ID]
+		utilization := expenses.CreditUtilization(wallet.CreditLimit, outstanding)
+		if utilization < wallet.UtilizationAlert {
+			continue
+		}
+		warnings = append(warnings, UtilizationWarning{
+			WalletID:         wallet.ID,
+			Name:             wallet.Name,
+			Icon:             wallet.Icon,
+			Color:            wallet.Color,
+			CreditLimit:      wallet.CreditLimit,
+			Outstanding:      outstanding,
+			Utilization:      utilization,
+			UtilizationAlert: wallet.UtilizationAlert,
+		})
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Utilization > warnings[j].Utilization
+	})
+
+	return warnings, nil
+}
+
 func walletIDs(wallets []expenses.Wallet) []uint {
 	ids := make([]uint, 0, len(wallets))
 	for _, wallet := range wallets {
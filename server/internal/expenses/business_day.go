@@ -0,0 +1,184 @@
+package expenses
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Business day rules say how a due date that falls on a weekend or a public
+// holiday is moved, the way banks shift card due dates.
+const (
+	BusinessDayRuleNone     = "none"
+	BusinessDayRulePrevious = "previous"
+	BusinessDayRuleNext     = "next"
+)
+
+// maxBusinessDayShift bounds the search for a business day; no calendar has
+// two weeks of consecutive holidays.
+const maxBusinessDayShift = 14
+
+var (
+	ErrInvalidBusinessDayRule = errors.New("business day rule must be one of: none, previous, next")
+	ErrUnknownHolidayRegion   = errors.New("unknown holiday region")
+)
+
+//go:embed holidays/*.json
+var holidayFiles embed.FS
+
+// HolidayRegion is one bundled public holiday calendar.
+type HolidayRegion struct {
+	Region   string    `json:"region"`
+	Name     string    `json:"name"`
+	Holidays []Holiday `json:"holidays"`
+}
+
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+var (
+	holidayRegionsOnce sync.Once
+	holidayRegions     map[string]HolidayRegion
+	holidayDates       map[string]map[string]bool
+	holidayRegionsErr  error
+)
+
+func loadHolidayRegions() error {
+	holidayRegionsOnce.Do(func() {
+		entries, err := holidayFiles.ReadDir("holidays")
+		if err != nil {
+			holidayRegionsErr = fmt.Errorf("failed to read holiday calendars: %w", err)
+			return
+		}
+		holidayRegions = make(map[string]HolidayRegion, len(entries))
+		holidayDates = make(map[string]map[string]bool, len(entries))
+		for _, entry := range entries {
+			data, err := holidayFiles.ReadFile("holidays/" + entry.Name())
+			if err != nil {
+				holidayRegionsErr = fmt.Errorf("failed to read holiday calendar %s: %w", entry.Name(), err)
+				return
+			}
+			var region HolidayRegion
+			if err := json.Unmarshal(data, &region); err != nil {
+				holidayRegionsErr = fmt.Errorf("failed to parse holiday calendar %s: %w", entry.Name(), err)
+				return
+			}
+			dates := make(map[string]bool, len(region.Holidays))
+			for _, holiday := range region.Holidays {
+				dates[holiday.Date] = true
+			}
+			holidayRegions[region.Region] = region
+			holidayDates[region.Region] = dates
+		}
+	})
+	return holidayRegionsErr
+}
+
+// ListHolidayRegions returns the bundled holiday calendars ordered by region
+// code.
+func ListHolidayRegions() ([]HolidayRegion, error) {
+	if err := loadHolidayRegions(); err != nil {
+		return nil, err
+	}
+	regions := make([]HolidayRegion, 0, len(holidayRegions))
+	for _, region := range holidayRegions {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Region < regions[j].Region })
+	return regions, nil
+}
+
+// IsBusinessDay reports whether date is a weekday that is not a public
+// holiday in region. An empty region only skips weekends.
+func IsBusinessDay(date time.Time, region string) bool {
+	switch date.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	if region == "" || loadHolidayRegions() != nil {
+		return true
+	}
+	return !holidayDates[region][date.Format(DateOnlyLayout)]
+}
+
+// AdjustToBusinessDay moves date to the previous or next business day when
+// it falls on a weekend or holiday. BusinessDayRuleNone returns it unchanged.
+func AdjustToBusinessDay(date time.Time, rule, region string) time.Time {
+	date = NormalizeDateOnly(date)
+	step := 0
+	switch rule {
+	case BusinessDayRulePrevious:
+		step = -1
+	case BusinessDayRuleNext:
+		step = 1
+	default:
+		return date
+	}
+	for shift := 0; shift < maxBusinessDayShift && !IsBusinessDay(date, region); shift++ {
+		date = date.AddDate(0, 0, step)
+	}
+	return date
+}
+
+// normalizeBusinessDaySettings validates a business day rule and holiday
+// region, defaulting an empty rule to none.
+func normalizeBusinessDaySettings(rule, region string) (string, string, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	if rule == "" {
+		rule = BusinessDayRuleNone
+	}
+	switch rule {
+	case BusinessDayRuleNone, BusinessDayRulePrevious, BusinessDayRuleNext:
+	default:
+		return "", "", ErrInvalidBusinessDayRule
+	}
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if region == "" {
+		return rule, "", nil
+	}
+	if err := loadHolidayRegions(); err != nil {
+		return "", "", err
+	}
+	if _, ok := holidayRegions[region]; !ok {
+		return "", "", ErrUnknownHolidayRegion
+	}
+	return rule, region, nil
+}
+
+// resolveBusinessDaySettings validates the business day settings of an
+// update, keeping the stored values for fields the update leaves out.
+func resolveBusinessDaySettings(rule, region *string, existingRule, existingRegion string) (string, string, error) {
+	if rule != nil {
+		existingRule = *rule
+	}
+	if region != nil {
+		existingRegion = *region
+	}
+	return normalizeBusinessDaySettings(existingRule, existingRegion)
+}
+
+// holidayCalendarEnd returns the last day covered by a region's bundled
+// calendar, the end of the latest year it lists holidays for.
+func holidayCalendarEnd(region string) (time.Time, bool) {
+	if region == "" || loadHolidayRegions() != nil {
+		return time.Time{}, false
+	}
+	latest := 0
+	for _, holiday := range holidayRegions[region].Holidays {
+		date, err := ParseDateOnly(holiday.Date)
+		if err == nil && date.Year() > latest {
+			latest = date.Year()
+		}
+	}
+	if latest == 0 {
+		return time.Time{}, false
+	}
+	return time.Date(latest, time.December, 31, 0, 0, 0, 0, time.UTC), true
+}
@@ -0,0 +1,107 @@
+package expenses
+
+import "testing"
+
+func TestAdjustToBusinessDay(t *testing.T) {
+	tests := []struct {
+		name   string
+		date   string
+		rule   string
+		region string
+		want   string
+	}{
+		{name: "weekday is unchanged", date: "2025-03-12", rule: BusinessDayRulePrevious, want: "2025-03-12"},
+		{name: "no rule keeps weekend", date: "2025-03-16", rule: BusinessDayRuleNone, want: "2025-03-16"},
+		{name: "sunday to previous friday", date: "2025-03-16", rule: BusinessDayRulePrevious, want: "2025-03-14"},
+		{name: "sunday to next monday", date: "2025-03-16", rule: BusinessDayRuleNext, want: "2025-03-17"},
+		{name: "holiday without region is a business day", date: "2025-12-25", rule: BusinessDayRuleNext, want: "2025-12-25"},
+		{name: "christmas and boxing day skip to monday", date: "2025-12-25", rule: BusinessDayRuleNext, region: "GB", want: "2025-12-29"},
+		{name: "easter weekend backs to thursday", date: "2025-04-21", rule: BusinessDayRulePrevious, region: "HK", want: "2025-04-17"},
+		{name: "lunar new year moves forward", date: "2026-02-17", rule: BusinessDayRuleNext, region: "HK", want: "2026-02-20"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := AdjustToBusinessDay(mustParseDate(t, test.date), test.rule, test.region)
+			if got.Format(DateOnlyLayout) != test.want {
+				t.Fatalf("AdjustToBusinessDay(%s, %s, %s) = %s, want %s", test.date, test.rule, test.region, got.Format(DateOnlyLayout), test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBusinessDaySettings(t *testing.T) {
+	rule, region, err := normalizeBusinessDaySettings("", " hk ")
+	if err != nil || rule != BusinessDayRuleNone || region != "HK" {
+		t.Fatalf("normalizeBusinessDaySettings = %q, %q, %v", rule, region, err)
+	}
+	if _, _, err := normalizeBusinessDaySettings("nearest", ""); err != ErrInvalidBusinessDayRule {
+		t.Fatalf("expected ErrInvalidBusinessDayRule, got %v", err)
+	}
+	if _, _, err := normalizeBusinessDaySettings(BusinessDayRuleNext, "XX"); err != ErrUnknownHolidayRegion {
+		t.Fatalf("expected ErrUnknownHolidayRegion, got %v", err)
+	}
+}
+
+func TestResolveBusinessDaySettings(t *testing.T) {
+	rule, region, err := resolveBusinessDaySettings(nil, nil, BusinessDayRuleNext, "HK")
+	if err != nil || rule != BusinessDayRuleNext || region != "HK" {
+		t.Fatalf("expected stored settings to be kept, got %q, %q, %v", rule, region, err)
+	}
+	empty, previous := "", BusinessDayRulePrevious
+	rule, region, err = resolveBusinessDaySettings(&previous, &empty, BusinessDayRuleNext, "HK")
+	if err != nil || rule != BusinessDayRulePrevious || region != "" {
+		t.Fatalf("expected submitted settings, got %q, %q, %v", rule, region, err)
+	}
+}
+
+func TestHolidayCalendarsAreValid(t *testing.T) {
+	regions, err := ListHolidayRegions()
+	if err != nil {
+		t.Fatalf("ListHolidayRegions returned error: %v", err)
+	}
+	if len(regions) == 0 {
+		t.Fatal("expected bundled holiday regions")
+	}
+	for _, region := range regions {
+		if region.Region == "" || region.Name == "" {
+			t.Fatalf("holiday region %+v has no code or name", region)
+		}
+		for _, holiday := range region.Holidays {
+			if _, err := ParseDateOnly(holiday.Date); err != nil {
+				t.Fatalf("region %s has invalid date %q", region.Region, holiday.Date)
+			}
+		}
+	}
+}
+
+func TestBusinessDayRuleOnDueDates(t *testing.T) {
+	// 2025-11-30 is a Sunday.
+	wallet := Wallet{IsCredit: true, BillPeriod: WalletPeriodMonthly, BillDueDay: 30, BusinessDayRule: BusinessDayRulePrevious}
+	got := NextWalletDueDate(wallet, mustParseDate(t, "2025-11-01"), nil)
+	if got.Format(DateOnlyLayout) != "2025-11-28" {
+		t.Fatalf("NextWalletDueDate = %s, want 2025-11-28", got.Format(DateOnlyLayout))
+	}
+	got = NextWalletDueDate(wallet, mustParseDate(t, "2025-11-20"), &Payment{ID: 1, Date: mustParseDate(t, "2025-11-28")})
+	if got.Format(DateOnlyLayout) != "2025-12-30" {
+		t.Fatalf("payment on the moved date should settle November, got %s", got.Format(DateOnlyLayout))
+	}
+	got = StatementDueDate(wallet, mustParseDate(t, "2025-11-10"))
+	if got.Format(DateOnlyLayout) != "2025-11-28" {
+		t.Fatalf("StatementDueDate = %s, want 2025-11-28", got.Format(DateOnlyLayout))
+	}
+
+	expenseType := ExpenseType{
+		RecurringType:   RecurringTypeFixedDay,
+		RecurrenceRule:  "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYMONTHDAY=30",
+		BusinessDayRule: BusinessDayRulePrevious,
+	}
+	lastExpense := mustParseDate(t, "2025-11-28")
+	next, err := NextExpenseTypeDueDate(expenseType, mustParseDate(t, "2025-11-28"), &lastExpense)
+	if err != nil {
+		t.Fatalf("NextExpenseTypeDueDate returned error: %v", err)
+	}
+	if next == nil || next.Format(DateOnlyLayout) != "2025-12-30" {
+		t.Fatalf("NextExpenseTypeDueDate = %v, want 2025-12-30", next)
+	}
+}
@@ -0,0 +1,44 @@
+package expenses
+
+// Event types published when expenses, payments and wallets change.
+const (
+	EventExpenseCreated = "expense.created"
+	EventExpenseUpdated = "expense.updated"
+	EventExpenseDeleted = "expense.deleted"
+	EventPaymentCreated = "payment.created"
+	EventPaymentUpdated = "payment.updated"
+	EventPaymentDeleted = "payment.deleted"
+	EventWalletCreated  = "wallet.created"
+	EventWalletUpdated  = "wallet.updated"
+	EventWalletDeleted  = "wallet.deleted"
+)
+
+// EventPublisher receives change events after they are committed. Publish
+// must not block on delivery; it returns how many subscribers the event was
+// queued for.
+type EventPublisher interface {
+	Publish(userID uint, eventType string, data interface{}) int
+}
+
+// DeletedEvent is the data of a *.deleted event.
+type DeletedEvent struct {
+	ID uint `json:"id"`
+}
+
+// eventSource is embedded by services that publish change events. Services
+// built without a publisher publish nothing; services that build others pass
+// their publisher on to them.
+type eventSource struct {
+	events EventPublisher
+}
+
+// SetEventPublisher sets where change events are published.
+func (s *eventSource) SetEventPublisher(publisher EventPublisher) {
+	s.events = publisher
+}
+
+func (s *eventSource) publish(userID uint, eventType string, data interface{}) {
+	if s.events != nil {
+		s.events.Publish(userID, eventType, data)
+	}
+}
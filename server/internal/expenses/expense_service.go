@@ -16,6 +16,7 @@ var (
 
 type ExpenseService struct {
 	db *gorm.DB
+	eventSource
 }
 
 type CreateExpenseRequest struct {
@@ -99,7 +100,13 @@ func (s *ExpenseService) CreateExpense(userID uint, req CreateExpenseRequest) (*
 				}
 			}
 		}
-		return s.advanceExpenseTypeDueDate(tx, userID, expenseType, expense.Date)
+		if err := restateExpense(tx, userID, nil, &expense); err != nil {
+			return err
+		}
+		if err := s.advanceExpenseTypeDueDate(tx, userID, expenseType, expense.Date); err != nil {
+			return err
+		}
+		return syncExpenseTypeOccurrences(tx, userID, expense.ExpenseTypeID)
 	})
 	if err != nil {
 		return nil, err
@@ -108,6 +115,7 @@ func (s *ExpenseService) CreateExpense(userID uint, req CreateExpenseRequest) (*
 	if err := s.db.Preload("ExpenseType").Preload("Wallet").Preload("Payment").First(&expense, expense.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to load expense: %w", err)
 	}
+	s.publish(userID, EventExpenseCreated, expense)
 	return &expense, nil
 }
 
@@ -131,18 +139,38 @@ func (s *ExpenseService) UpdateExpense(userID, expenseID uint, req UpdateExpense
 	if err != nil {
 		return nil, err
 	}
+	previousStatementID := expense.StatementID
+	previousExpenseTypeID := expense.ExpenseTypeID
 	expense.ExpenseTypeID = req.ExpenseTypeID
 	expense.WalletID = walletID
 	expense.PaymentID = paymentID
 	expense.Amount = req.Amount
 	expense.Date = parsedDate
 	expense.Note = req.Note
-	if err := s.db.Save(expense).Error; err != nil {
-		return nil, fmt.Errorf("failed to update expense: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(expense).Error; err != nil {
+			return fmt.Errorf("failed to update expense: %w", err)
+		}
+		if err := restateExpense(tx, userID, previousStatementID, expense); err != nil {
+			return err
+		}
+		if err := releaseOccurrences(tx, userID, "expense_id", expense.ID); err != nil {
+			return err
+		}
+		if previousExpenseTypeID != expense.ExpenseTypeID {
+			if err := syncExpenseTypeOccurrences(tx, userID, previousExpenseTypeID); err != nil {
+				return err
+			}
+		}
+		return syncExpenseTypeOccurrences(tx, userID, expense.ExpenseTypeID)
+	})
+	if err != nil {
+		return nil, err
 	}
 	if err := s.db.Preload("ExpenseType").Preload("Wallet").Preload("Payment").First(expense, expense.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to reload expense: %w", err)
 	}
+	s.publish(userID, EventExpenseUpdated, expense)
 	return expense, nil
 }
 
@@ -151,12 +179,26 @@ func (s *ExpenseService) DeleteExpense(userID, expenseID uint) error {
 	if err != nil {
 		return err
 	}
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("id = ? AND user_id = ?", expenseID, userID).Delete(&Expense{}).Error; err != nil {
 			return fmt.Errorf("failed to delete expense: %w", err)
 		}
-		return s.recalcFlexibleDueDate(tx, userID, expense.ExpenseTypeID)
+		if err := restateExpense(tx, userID, expense.StatementID, nil); err != nil {
+			return err
+		}
+		if err := releaseOccurrences(tx, userID, "expense_id", expenseID); err != nil {
+			return err
+		}
+		if err := s.recalcFlexibleDueDate(tx, userID, expense.ExpenseTypeID); err != nil {
+			return err
+		}
+		return syncExpenseTypeOccurrences(tx, userID, expense.ExpenseTypeID)
 	})
+	if err != nil {
+		return err
+	}
+	s.publish(userID, EventExpenseDeleted, DeletedEvent{ID: expenseID})
+	return nil
 }
 
 func (s *ExpenseService) ListExpenses(userID uint, req ExpenseListRequest) (*ExpenseListResponse, error) {
@@ -277,7 +319,7 @@ func (s *ExpenseService) advanceExpenseTypeDueDate(tx *gorm.DB, userID uint, exp
 	if expenseType.RecurringType != RecurringTypeFlexible {
 		return nil
 	}
-	nextDueDay, err := AdvanceNextDueDayFrom(expenseDate, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay)
+	nextDueDay, err := AdvanceNextDueDayFrom(expenseDate, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
 	if err != nil {
 		return err
 	}
@@ -310,7 +352,7 @@ func (s *ExpenseService) recalcFlexibleDueDate(tx *gorm.DB, userID, expenseTypeI
 		return nil
 	}
 
-	nextDueDay, err := AdvanceNextDueDayFrom(lastExpense.Date, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay)
+	nextDueDay, err := AdvanceNextDueDayFrom(lastExpense.Date, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
 	if err != nil {
 		return err
 	}
@@ -336,3 +378,10 @@ func (s *ExpenseService) autoCreatePaymentForNormalWallet(tx *gorm.DB, userID, w
 	}
 	return &payment.ID, nil
 }
+
+func sameOptionalID(left, right *uint) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	return *left == *right
+}
@@ -32,25 +32,33 @@ const (
 )
 
 type ExpenseType struct {
-	ID              uint           `json:"id" gorm:"primaryKey;type:bigint"`
-	ParentID        *uint          `json:"parent_id" gorm:"type:bigint;index"`
-	Name            string         `json:"name" gorm:"type:varchar(255);not null"`
-	Icon            string         `json:"icon" gorm:"type:varchar(50)"`
-	Color           string         `json:"color" gorm:"type:varchar(10)"`
-	Description     string         `json:"description" gorm:"type:text"`
-	DefaultAmount   float64        `json:"default_amount" gorm:"type:numeric(12,2);not null;default:0"`
-	DefaultWalletID *uint          `json:"default_wallet_id" gorm:"type:bigint;index"`
-	RecurringType   string         `json:"recurring_type" gorm:"type:varchar(20);not null;default:'none';check:chk_expense_type_recurring_type,recurring_type IN ('none','fixed_day','flexible')"`
-	RecurringPeriod string         `json:"recurring_period" gorm:"type:varchar(20);not null;default:'none';check:chk_expense_type_recurring_period,recurring_period IN ('none','weekly','biweekly','monthly','bimonthly','quarterly','fourmonths','semiannually','annually')"`
-	RecurringDueDay int            `json:"recurring_due_day" gorm:"not null;default:0"`
-	ReminderType    string         `json:"reminder_type" gorm:"type:varchar(20);not null;default:'in_advance';check:chk_expense_type_reminder_type,reminder_type IN ('none','in_advance','on_day','automatic')"`
-	NextDueDay      *time.Time     `json:"next_due_day" gorm:"type:date;index"`
-	IOSCategory     string         `json:"ios_category" gorm:"type:varchar(100);not null;default:''"`
-	Stopped         bool           `json:"stopped" gorm:"not null;default:false"`
-	UserID          uint           `json:"user_id" gorm:"type:bigint;not null;index"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                   uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	ParentID             *uint          `json:"parent_id" gorm:"type:bigint;index"`
+	Name                 string         `json:"name" gorm:"type:varchar(255);not null"`
+	Icon                 string         `json:"icon" gorm:"type:varchar(50)"`
+	Color                string         `json:"color" gorm:"type:varchar(10)"`
+	Description          string         `json:"description" gorm:"type:text"`
+	DefaultAmount        float64        `json:"default_amount" gorm:"type:numeric(12,2);not null;default:0"`
+	DefaultWalletID      *uint          `json:"default_wallet_id" gorm:"type:bigint;index"`
+	RecurringType        string         `json:"recurring_type" gorm:"type:varchar(20);not null;default:'none';check:chk_expense_type_recurring_type,recurring_type IN ('none','fixed_day','flexible')"`
+	RecurringPeriod      string         `json:"recurring_period" gorm:"type:varchar(20);not null;default:'none';check:chk_expense_type_recurring_period,recurring_period IN ('none','weekly','biweekly','monthly','bimonthly','quarterly','fourmonths','semiannually','annually')"`
+	RecurringDueDay      int            `json:"recurring_due_day" gorm:"not null;default:0"`
+	RecurrenceRule       string         `json:"recurrence_rule" gorm:"type:text;not null;default:''"`
+	BusinessDayRule      string         `json:"business_day_rule" gorm:"type:varchar(20);not null;default:'none';check:chk_expense_type_business_day_rule,business_day_rule IN ('none','previous','next')"`
+	HolidayRegion        string         `json:"holiday_region" gorm:"type:varchar(16);not null;default:''"`
+	ReminderType         string         `json:"reminder_type" gorm:"type:varchar(20);not null;default:'in_advance';check:chk_expense_type_reminder_type,reminder_type IN ('none','in_advance','on_day','automatic')"`
+	NextDueDay           *time.Time     `json:"next_due_day" gorm:"type:date;index"`
+	IOSCategory          string         `json:"ios_category" gorm:"type:varchar(100);not null;default:''"`
+	EndDate              *time.Time     `json:"end_date" gorm:"type:date"`
+	RemainingOccurrences *int           `json:"remaining_occurrences" gorm:"check:chk_expense_type_remaining_occurrences,remaining_occurrences IS NULL OR remaining_occurrences >= 0"`
+	OccurrenceLimit      *int           `json:"-"`
+	OccurrenceLimitSetAt *time.Time     `json:"-" gorm:"type:timestamptz"`
+	EndingSoonReminder   bool           `json:"ending_soon_reminder" gorm:"not null;default:false"`
+	Stopped              bool           `json:"stopped" gorm:"not null;default:false"`
+	UserID               uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
 
 	Parent        *ExpenseType `json:"parent,omitempty" gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	DefaultWallet *Wallet      `json:"default_wallet,omitempty" gorm:"foreignKey:DefaultWalletID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
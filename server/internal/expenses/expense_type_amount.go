@@ -0,0 +1,115 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAmountChangeNotFound     = errors.New("amount change not found")
+	ErrAmountChangeNotScheduled = errors.New("only amount changes that have not taken effect can be cancelled")
+	ErrInvalidEffectiveFrom     = errors.New("effective from must be in YYYY-MM-DD format")
+)
+
+// ExpenseTypeAmount is the amount of an expense type from EffectiveFrom until
+// the next change. Rows dated after today are scheduled changes; reads report
+// the amount in effect today as the type's DefaultAmount.
+type ExpenseTypeAmount struct {
+	ID            uint      `json:"id" gorm:"primaryKey;type:bigint"`
+	ExpenseTypeID uint      `json:"expense_type_id" gorm:"type:bigint;not null;uniqueIndex:idx_expense_type_amount_effective"`
+	Amount        float64   `json:"amount" gorm:"type:numeric(12,2);not null"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"type:date;not null;uniqueIndex:idx_expense_type_amount_effective"`
+	UserID        uint      `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	ExpenseType *ExpenseType `json:"expense_type,omitempty" gorm:"foreignKey:ExpenseTypeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// AmountSchedule holds the amount history of expense types by ID, oldest
+// change first.
+type AmountSchedule map[uint][]ExpenseTypeAmount
+
+// LoadAmountSchedule loads the amount history of the given expense types.
+func LoadAmountSchedule(db *gorm.DB, userID uint, expenseTypeIDs []uint) (AmountSchedule, error) {
+	schedule := make(AmountSchedule)
+	if len(expenseTypeIDs) == 0 {
+		return schedule, nil
+	}
+	var amounts []ExpenseTypeAmount
+	if err := db.Where("user_id = ? AND expense_type_id IN ?", userID, expenseTypeIDs).Order("effective_from ASC").Find(&amounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load amount changes: %w", err)
+	}
+	for _, amount := range amounts {
+		schedule[amount.ExpenseTypeID] = append(schedule[amount.ExpenseTypeID], amount)
+	}
+	return schedule, nil
+}
+
+// AmountOn returns the amount of expenseType in effect on date: the latest
+// change effective on or before it, or DefaultAmount when there is none.
+func (s AmountSchedule) AmountOn(expenseType ExpenseType, date time.Time) float64 {
+	amount := expenseType.DefaultAmount
+	date = NormalizeDateOnly(date)
+	for _, change := range s[expenseType.ID] {
+		if change.EffectiveFrom.After(date) {
+			break
+		}
+		amount = change.Amount
+	}
+	return amount
+}
+
+// HasAmount reports whether the type has a default amount or any amount
+// change, i.e. whether AmountOn is meaningful for it.
+func (s AmountSchedule) HasAmount(expenseType ExpenseType) bool {
+	return expenseType.DefaultAmount > 0 || len(s[expenseType.ID]) > 0
+}
+
+// recordAmountChange stores amount as effective from effectiveFrom, replacing
+// a change on the same day. The first change also records the amount the type
+// had before it, effective from the type's creation, so history is complete.
+func recordAmountChange(tx *gorm.DB, expenseType ExpenseType, amount float64, effectiveFrom time.Time) error {
+	effectiveFrom = NormalizeDateOnly(effectiveFrom)
+	var count int64
+	if err := tx.Model(&ExpenseTypeAmount{}).Where("expense_type_id = ?", expenseType.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load amount changes: %w", err)
+	}
+	changes := make([]ExpenseTypeAmount, 0, 2)
+	if baseline := NormalizeDateOnly(expenseType.CreatedAt); count == 0 && baseline.Before(effectiveFrom) {
+		changes = append(changes, ExpenseTypeAmount{ExpenseTypeID: expenseType.ID, Amount: expenseType.DefaultAmount, EffectiveFrom: baseline, UserID: expenseType.UserID})
+	}
+	changes = append(changes, ExpenseTypeAmount{ExpenseTypeID: expenseType.ID, Amount: amount, EffectiveFrom: effectiveFrom, UserID: expenseType.UserID})
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "expense_type_id"}, {Name: "effective_from"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "updated_at"}),
+	}).Create(&changes).Error; err != nil {
+		return fmt.Errorf("failed to record amount change: %w", err)
+	}
+	return nil
+}
+
+// withCurrentAmounts sets DefaultAmount on each type to the amount in effect
+// on today, so scheduled changes show once they take effect without writing
+// on read. The stored amount catches up the next time the type is saved.
+func withCurrentAmounts(db *gorm.DB, userID uint, expenseTypes []ExpenseType, today time.Time) error {
+	if len(expenseTypes) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(expenseTypes))
+	for index, expenseType := range expenseTypes {
+		ids[index] = expenseType.ID
+	}
+	schedule, err := LoadAmountSchedule(db, userID, ids)
+	if err != nil {
+		return err
+	}
+	for index := range expenseTypes {
+		expenseTypes[index].DefaultAmount = schedule.AmountOn(expenseTypes[index], today)
+	}
+	return nil
+}
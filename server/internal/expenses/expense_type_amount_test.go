@@ -0,0 +1,40 @@
+package expenses
+
+import "testing"
+
+func TestAmountScheduleAmountOn(t *testing.T) {
+	expenseType := ExpenseType{ID: 5, DefaultAmount: 9.99}
+	schedule := AmountSchedule{
+		5: {
+			{ExpenseTypeID: 5, Amount: 9.99, EffectiveFrom: mustParseDate(t, "2025-01-10")},
+			{ExpenseTypeID: 5, Amount: 12.99, EffectiveFrom: mustParseDate(t, "2025-04-01")},
+			{ExpenseTypeID: 5, Amount: 14.99, EffectiveFrom: mustParseDate(t, "2025-09-01")},
+		},
+	}
+
+	tests := []struct {
+		date string
+		want float64
+	}{
+		{date: "2024-12-31", want: 9.99},
+		{date: "2025-03-31", want: 9.99},
+		{date: "2025-04-01", want: 12.99},
+		{date: "2025-08-31", want: 12.99},
+		{date: "2026-01-01", want: 14.99},
+	}
+	for _, test := range tests {
+		t.Run(test.date, func(t *testing.T) {
+			if got := schedule.AmountOn(expenseType, mustParseDate(t, test.date)); got != test.want {
+				t.Fatalf("expected %.2f, got %.2f", test.want, got)
+			}
+		})
+	}
+
+	other := ExpenseType{ID: 6, DefaultAmount: 30}
+	if got := schedule.AmountOn(other, mustParseDate(t, "2025-06-01")); got != 30 {
+		t.Fatalf("expected default amount 30, got %.2f", got)
+	}
+	if schedule.HasAmount(ExpenseType{ID: 7}) {
+		t.Fatal("expected a type without amount or changes to have no amount")
+	}
+}
@@ -0,0 +1,323 @@
+package expenses
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpenseTypeExportVersion is the format version written by ExportExpenseTypes.
+const ExpenseTypeExportVersion = 1
+
+// maxBatchExpenseTypes bounds a single batch, preset or import.
+const maxBatchExpenseTypes = 500
+
+const defaultPresetLanguage = "en"
+
+var (
+	ErrEmptyExpenseTypeBatch     = errors.New("at least one expense type is required")
+	ErrExpenseTypeBatchTooLarge  = errors.New("too many expense types in one batch")
+	ErrDuplicateBatchKey         = errors.New("batch keys must be unique")
+	ErrUnknownBatchParentKey     = errors.New("batch parent key does not match an item in the batch")
+	ErrExpenseTypePresetNotFound = errors.New("expense type preset not found")
+	ErrUnsupportedExportVersion  = errors.New("unsupported expense type export version")
+)
+
+//go:embed presets/*.json
+var presetFiles embed.FS
+
+// BatchExpenseTypeItem is one expense type in a batch. Key and ParentKey link
+// items inside the same batch, so a parent and its children can be created
+// together; ParentID still points at an existing type.
+type BatchExpenseTypeItem struct {
+	Key       string `json:"key"`
+	ParentKey string `json:"parent_key,omitempty"`
+	CreateExpenseTypeRequest
+}
+
+type BatchCreateExpenseTypesRequest struct {
+	ExpenseTypes []BatchExpenseTypeItem `json:"expense_types"`
+	SkipExisting bool                   `json:"skip_existing"`
+}
+
+// BatchCreateExpenseTypesResponse lists the created types and the names that
+// were skipped because a type with the same name already exists.
+type BatchCreateExpenseTypesResponse struct {
+	Created []ExpenseType `json:"created"`
+	Skipped []string      `json:"skipped"`
+}
+
+type ExpenseTypeExport struct {
+	Version      int                    `json:"version"`
+	ExportedAt   time.Time              `json:"exported_at"`
+	ExpenseTypes []BatchExpenseTypeItem `json:"expense_types"`
+}
+
+type ApplyExpenseTypePresetRequest struct {
+	Language string `json:"language"`
+}
+
+// ExpenseTypePreset is a localized, ready-made set of expense types.
+type ExpenseTypePreset struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Language     string                 `json:"language"`
+	ExpenseTypes []BatchExpenseTypeItem `json:"expense_types"`
+}
+
+type presetFile struct {
+	ID           string            `json:"id"`
+	Names        map[string]string `json:"names"`
+	ExpenseTypes []struct {
+		Key             string            `json:"key"`
+		ParentKey       string            `json:"parent_key"`
+		Icon            string            `json:"icon"`
+		Color           string            `json:"color"`
+		RecurringType   string            `json:"recurring_type"`
+		RecurringPeriod string            `json:"recurring_period"`
+		RecurringDueDay int               `json:"recurring_due_day"`
+		ReminderType    string            `json:"reminder_type"`
+		Names           map[string]string `json:"names"`
+	} `json:"expense_types"`
+}
+
+// BatchCreateExpenseTypes creates all items in one transaction, so either the
+// whole batch is stored or nothing is. Items may come in any order; parents
+// are created before the children that reference them by key.
+func (s *ExpenseTypeService) BatchCreateExpenseTypes(userID uint, req BatchCreateExpenseTypesRequest) (*BatchCreateExpenseTypesResponse, error) {
+	if len(req.ExpenseTypes) == 0 {
+		return nil, ErrEmptyExpenseTypeBatch
+	}
+	if len(req.ExpenseTypes) > maxBatchExpenseTypes {
+		return nil, ErrExpenseTypeBatchTooLarge
+	}
+	keys := make(map[string]bool, len(req.ExpenseTypes))
+	for _, item := range req.ExpenseTypes {
+		key := strings.TrimSpace(item.Key)
+		if key == "" {
+			continue
+		}
+		if keys[key] {
+			return nil, ErrDuplicateBatchKey
+		}
+		keys[key] = true
+	}
+	for _, item := range req.ExpenseTypes {
+		if parentKey := strings.TrimSpace(item.ParentKey); parentKey != "" && !keys[parentKey] {
+			return nil, ErrUnknownBatchParentKey
+		}
+	}
+
+	response := &BatchCreateExpenseTypesResponse{
+		Created: make([]ExpenseType, 0, len(req.ExpenseTypes)),
+		Skipped: make([]string, 0),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		txService := &ExpenseTypeService{db: tx}
+		idsByKey := make(map[string]uint, len(keys))
+		pending := req.ExpenseTypes
+		for len(pending) > 0 {
+			remaining := make([]BatchExpenseTypeItem, 0, len(pending))
+			for _, item := range pending {
+				parentKey := strings.TrimSpace(item.ParentKey)
+				if parentKey != "" {
+					parentID, ok := idsByKey[parentKey]
+					if !ok {
+						remaining = append(remaining, item)
+						continue
+					}
+					item.ParentID = &parentID
+				}
+				id, created, err := txService.createBatchItem(userID, item, req.SkipExisting)
+				if err != nil {
+					return fmt.Errorf("expense type %q: %w", strings.TrimSpace(item.Name), err)
+				}
+				if created != nil {
+					response.Created = append(response.Created, *created)
+				} else {
+					response.Skipped = append(response.Skipped, strings.TrimSpace(item.Name))
+				}
+				if key := strings.TrimSpace(item.Key); key != "" {
+					idsByKey[key] = id
+				}
+			}
+			// Nothing could be placed: the remaining items reference each
+			// other in a loop.
+			if len(remaining) == len(pending) {
+				return ErrUnknownBatchParentKey
+			}
+			pending = remaining
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// createBatchItem creates one item and returns its ID. With skipExisting an
+// item whose name is already taken is not created; the existing type's ID is
+// returned so children can still attach to it.
+func (s *ExpenseTypeService) createBatchItem(userID uint, item BatchExpenseTypeItem, skipExisting bool) (uint, *ExpenseType, error) {
+	if skipExisting {
+		var existing ExpenseType
+		err := s.db.Where("user_id = ? AND LOWER(name) = LOWER(?)", userID, strings.TrimSpace(item.Name)).First(&existing).Error
+		if err == nil {
+			return existing.ID, nil, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil, fmt.Errorf("failed to check expense type name: %w", err)
+		}
+	}
+	created, err := s.CreateExpenseType(userID, item.CreateExpenseTypeRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+	return created.ID, created, nil
+}
+
+// ExportExpenseTypes returns the user's whole type tree in a portable form.
+// Wallet references and due dates are account specific and left out.
+func (s *ExpenseTypeService) ExportExpenseTypes(userID uint) (*ExpenseTypeExport, error) {
+	var expenseTypes []ExpenseType
+	if err := s.db.Where("user_id = ?", userID).Order("id ASC").Find(&expenseTypes).Error; err != nil {
+		return nil, fmt.Errorf("failed to export expense types: %w", err)
+	}
+	export := &ExpenseTypeExport{
+		Version:      ExpenseTypeExportVersion,
+		ExportedAt:   time.Now().UTC(),
+		ExpenseTypes: make([]BatchExpenseTypeItem, 0, len(expenseTypes)),
+	}
+	for _, expenseType := range expenseTypes {
+		item := BatchExpenseTypeItem{
+			Key: strconv.FormatUint(uint64(expenseType.ID), 10),
+			CreateExpenseTypeRequest: CreateExpenseTypeRequest{
+				Name:            expenseType.Name,
+				Icon:            expenseType.Icon,
+				Color:           expenseType.Color,
+				Description:     expenseType.Description,
+				DefaultAmount:   expenseType.DefaultAmount,
+				RecurringType:   expenseType.RecurringType,
+				RecurringPeriod: expenseType.RecurringPeriod,
+				RecurringDueDay: expenseType.RecurringDueDay,
+				RecurrenceRule:  expenseType.RecurrenceRule,
+				BusinessDayRule: expenseType.BusinessDayRule,
+				HolidayRegion:   expenseType.HolidayRegion,
+				ReminderType:    expenseType.ReminderType,
+				IOSCategory:     expenseType.IOSCategory,
+				Stopped:         expenseType.Stopped,
+			},
+		}
+		if expenseType.ParentID != nil {
+			item.ParentKey = strconv.FormatUint(uint64(*expenseType.ParentID), 10)
+		}
+		export.ExpenseTypes = append(export.ExpenseTypes, item)
+	}
+	return export, nil
+}
+
+// ImportExpenseTypes creates the exported tree in this account. Types whose
+// name already exists are kept and reused as parents.
+func (s *ExpenseTypeService) ImportExpenseTypes(userID uint, export ExpenseTypeExport) (*BatchCreateExpenseTypesResponse, error) {
+	if export.Version != ExpenseTypeExportVersion {
+		return nil, ErrUnsupportedExportVersion
+	}
+	items := make([]BatchExpenseTypeItem, 0, len(export.ExpenseTypes))
+	for _, item := range export.ExpenseTypes {
+		item.ParentID = nil
+		item.DefaultWalletID = nil
+		item.NextDueDay = nil
+		items = append(items, item)
+	}
+	return s.BatchCreateExpenseTypes(userID, BatchCreateExpenseTypesRequest{ExpenseTypes: items, SkipExisting: true})
+}
+
+// ListExpenseTypePresets returns the built-in presets localized to language,
+// falling back to English for unknown languages.
+func (s *ExpenseTypeService) ListExpenseTypePresets(language string) ([]ExpenseTypePreset, error) {
+	files, err := loadPresetFiles()
+	if err != nil {
+		return nil, err
+	}
+	presets := make([]ExpenseTypePreset, 0, len(files))
+	for _, file := range files {
+		presets = append(presets, localizePreset(file, language))
+	}
+	return presets, nil
+}
+
+// ApplyExpenseTypePreset creates a preset's types in one transaction,
+// skipping names the user already has.
+func (s *ExpenseTypeService) ApplyExpenseTypePreset(userID uint, presetID, language string) (*BatchCreateExpenseTypesResponse, error) {
+	presets, err := s.ListExpenseTypePresets(language)
+	if err != nil {
+		return nil, err
+	}
+	for _, preset := range presets {
+		if preset.ID == presetID {
+			return s.BatchCreateExpenseTypes(userID, BatchCreateExpenseTypesRequest{ExpenseTypes: preset.ExpenseTypes, SkipExisting: true})
+		}
+	}
+	return nil, ErrExpenseTypePresetNotFound
+}
+
+func loadPresetFiles() ([]presetFile, error) {
+	entries, err := presetFiles.ReadDir("presets")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expense type presets: %w", err)
+	}
+	files := make([]presetFile, 0, len(entries))
+	for _, entry := range entries {
+		data, err := presetFiles.ReadFile("presets/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read expense type preset %s: %w", entry.Name(), err)
+		}
+		var file presetFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse expense type preset %s: %w", entry.Name(), err)
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	return files, nil
+}
+
+func localizePreset(file presetFile, language string) ExpenseTypePreset {
+	if _, ok := file.Names[language]; !ok {
+		language = defaultPresetLanguage
+	}
+	preset := ExpenseTypePreset{
+		ID:           file.ID,
+		Name:         file.Names[language],
+		Language:     language,
+		ExpenseTypes: make([]BatchExpenseTypeItem, 0, len(file.ExpenseTypes)),
+	}
+	for _, expenseType := range file.ExpenseTypes {
+		name := expenseType.Names[language]
+		if name == "" {
+			name = expenseType.Names[defaultPresetLanguage]
+		}
+		preset.ExpenseTypes = append(preset.ExpenseTypes, BatchExpenseTypeItem{
+			Key:       expenseType.Key,
+			ParentKey: expenseType.ParentKey,
+			CreateExpenseTypeRequest: CreateExpenseTypeRequest{
+				Name:            name,
+				Icon:            expenseType.Icon,
+				Color:           expenseType.Color,
+				RecurringType:   expenseType.RecurringType,
+				RecurringPeriod: expenseType.RecurringPeriod,
+				RecurringDueDay: expenseType.RecurringDueDay,
+				ReminderType:    expenseType.ReminderType,
+			},
+		})
+	}
+	return preset
+}
@@ -1,8 +1,10 @@
 package expenses
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"dannyswat/jiceot/internal/auth"
 
@@ -95,7 +97,7 @@ func (h *ExpenseTypeHandler) UpdateExpenseTypeDefaultAmount(c echo.Context) erro
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "default amount is required"})
 	}
 
-	expenseType, err := h.expenseTypeService.UpdateExpenseTypeDefaultAmount(userID, uint(expenseTypeID), *req.DefaultAmount)
+	expenseType, err := h.expenseTypeService.UpdateExpenseTypeDefaultAmount(userID, uint(expenseTypeID), *req.DefaultAmount, req.EffectiveFrom)
 	if err != nil {
 		return h.expenseTypeError(c, err, "Failed to update expense type default amount")
 	}
@@ -103,6 +105,37 @@ func (h *ExpenseTypeHandler) UpdateExpenseTypeDefaultAmount(c echo.Context) erro
 	return c.JSON(http.StatusOK, expenseType)
 }
 
+// ListExpenseTypeAmounts handles GET /api/expense-types/:id/amounts
+func (h *ExpenseTypeHandler) ListExpenseTypeAmounts(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	expenseTypeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid expense type ID"})
+	}
+	amounts, err := h.expenseTypeService.ListExpenseTypeAmounts(userID, uint(expenseTypeID))
+	if err != nil {
+		return h.expenseTypeError(c, err, "Failed to list amount changes")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"amounts": amounts, "total": len(amounts)})
+}
+
+// CancelExpenseTypeAmount handles DELETE /api/expense-types/:id/amounts/:amountId
+func (h *ExpenseTypeHandler) CancelExpenseTypeAmount(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	expenseTypeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid expense type ID"})
+	}
+	amountID, err := strconv.ParseUint(c.Param("amountId"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid amount change ID"})
+	}
+	if err := h.expenseTypeService.CancelExpenseTypeAmount(userID, uint(expenseTypeID), uint(amountID)); err != nil {
+		return h.expenseTypeError(c, err, "Failed to cancel amount change")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Amount change cancelled successfully"})
+}
+
 // DeleteExpenseType handles DELETE /api/expense-types/:id
 func (h *ExpenseTypeHandler) DeleteExpenseType(c echo.Context) error {
 	userID := auth.GetUserIDFromContext(c)
@@ -180,6 +213,24 @@ func (h *ExpenseTypeHandler) PostponeExpenseType(c echo.Context) error {
 	return c.JSON(http.StatusOK, expenseType)
 }
 
+// MoveExpenseType handles POST /api/expense-types/:id/move
+func (h *ExpenseTypeHandler) MoveExpenseType(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	expenseTypeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid expense type ID"})
+	}
+	var req MoveExpenseTypeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	expenseType, err := h.expenseTypeService.MoveExpenseType(userID, uint(expenseTypeID), req)
+	if err != nil {
+		return h.expenseTypeError(c, err, "Failed to move expense type")
+	}
+	return c.JSON(http.StatusOK, expenseType)
+}
+
 func (h *ExpenseTypeHandler) ToggleExpenseType(c echo.Context) error {
 	userID := auth.GetUserIDFromContext(c)
 	expenseTypeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -193,13 +244,111 @@ func (h *ExpenseTypeHandler) ToggleExpenseType(c echo.Context) error {
 	return c.JSON(http.StatusOK, expenseType)
 }
 
+// BatchCreateExpenseTypes handles POST /api/expense-types/batch
+func (h *ExpenseTypeHandler) BatchCreateExpenseTypes(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req BatchCreateExpenseTypesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	response, err := h.expenseTypeService.BatchCreateExpenseTypes(userID, req)
+	if err != nil {
+		return h.batchError(c, err, "Failed to create expense types")
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListExpenseTypePresets handles GET /api/expense-types/presets
+func (h *ExpenseTypeHandler) ListExpenseTypePresets(c echo.Context) error {
+	presets, err := h.expenseTypeService.ListExpenseTypePresets(c.QueryParam("language"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load expense type presets"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"presets": presets, "total": len(presets)})
+}
+
+// ListHolidayRegions handles GET /api/holiday-regions
+func (h *ExpenseTypeHandler) ListHolidayRegions(c echo.Context) error {
+	regions, err := ListHolidayRegions()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load holiday regions"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"regions": regions, "total": len(regions)})
+}
+
+// PreviewExpenseTypeSchedule handles POST /api/expense-types/schedule-preview
+func (h *ExpenseTypeHandler) PreviewExpenseTypeSchedule(c echo.Context) error {
+	var req ExpenseTypeSchedulePreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	preview, err := PreviewExpenseTypeSchedule(req, time.Now().UTC())
+	if err != nil {
+		return h.expenseTypeError(c, err, "Failed to preview schedule")
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// ApplyExpenseTypePreset handles POST /api/expense-types/presets/:id/apply
+func (h *ExpenseTypeHandler) ApplyExpenseTypePreset(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req ApplyExpenseTypePresetRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	response, err := h.expenseTypeService.ApplyExpenseTypePreset(userID, c.Param("id"), req.Language)
+	if err != nil {
+		return h.batchError(c, err, "Failed to apply expense type preset")
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ExportExpenseTypes handles GET /api/expense-types/export
+func (h *ExpenseTypeHandler) ExportExpenseTypes(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	export, err := h.expenseTypeService.ExportExpenseTypes(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to export expense types"})
+	}
+	return c.JSON(http.StatusOK, export)
+}
+
+// ImportExpenseTypes handles POST /api/expense-types/import
+func (h *ExpenseTypeHandler) ImportExpenseTypes(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var export ExpenseTypeExport
+	if err := c.Bind(&export); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	response, err := h.expenseTypeService.ImportExpenseTypes(userID, export)
+	if err != nil {
+		return h.batchError(c, err, "Failed to import expense types")
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// batchError maps errors from batch operations, which wrap the failing
+// item's error with its name.
+func (h *ExpenseTypeHandler) batchError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrExpenseTypePresetNotFound), errors.Is(err, ErrExpenseTypeNotFound), errors.Is(err, ErrWalletNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrExpenseTypeNameExists):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
+
 func (h *ExpenseTypeHandler) expenseTypeError(c echo.Context, err error, fallback string) error {
 	switch err {
-	case ErrExpenseTypeNotFound, ErrWalletNotFound:
+	case ErrExpenseTypeNotFound, ErrWalletNotFound, ErrAmountChangeNotFound:
 		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
-	case ErrExpenseTypeNameExists:
+	case ErrExpenseTypeNameExists, ErrAmountChangeNotScheduled:
 		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
-	case ErrEmptyExpenseTypeName, ErrInvalidDefaultAmount, ErrInvalidExpenseParent, ErrInvalidRecurringType, ErrInvalidRecurringPeriod, ErrInvalidRecurringDueDay, ErrInvalidReminderType, ErrFlexiblePostponeOnly, ErrExpenseTypeCycleReference, ErrExpenseTypeInUse:
+	case ErrEmptyExpenseTypeName, ErrInvalidDefaultAmount, ErrInvalidRecurringType, ErrInvalidRecurringPeriod, ErrInvalidRecurringDueDay, ErrInvalidReminderType, ErrFlexiblePostponeOnly, ErrExpenseTypeCycleReference, ErrExpenseTypeInUse, ErrRecurrenceRuleNotRecurring, ErrInvalidBusinessDayRule, ErrUnknownHolidayRegion, ErrInvalidPreviewCount, ErrInvalidPreviewFrom, ErrPreviewNotRecurring, ErrInvalidPreviewNextDue, ErrInvalidEffectiveFrom, ErrInvalidEndDate, ErrInvalidRemainingOccurrences, ErrScheduleEndNotRecurring:
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	default:
 		if err != nil {
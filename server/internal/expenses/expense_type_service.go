@@ -10,60 +10,82 @@ import (
 )
 
 var (
-	ErrExpenseTypeNotFound       = errors.New("expense type not found")
-	ErrExpenseTypeNameExists     = errors.New("expense type name already exists")
-	ErrEmptyExpenseTypeName      = errors.New("expense type name cannot be empty")
-	ErrInvalidDefaultAmount      = errors.New("default amount must be greater than or equal to 0")
-	ErrInvalidExpenseParent      = errors.New("expense type parent must be a top-level expense type")
-	ErrInvalidRecurringType      = errors.New("invalid recurring type")
-	ErrInvalidRecurringPeriod    = errors.New("invalid recurring period")
-	ErrInvalidRecurringDueDay    = errors.New("recurring due day must be between 1 and 31")
-	ErrInvalidReminderType       = errors.New("invalid reminder type")
-	ErrFlexiblePostponeOnly      = errors.New("only flexible expense types can be postponed")
-	ErrExpenseTypeInUse          = errors.New("cannot delete expense type that is in use")
-	ErrExpenseTypeCycleReference = errors.New("expense type cannot reference itself as parent")
+	ErrExpenseTypeNotFound        = errors.New("expense type not found")
+	ErrExpenseTypeNameExists      = errors.New("expense type name already exists")
+	ErrEmptyExpenseTypeName       = errors.New("expense type name cannot be empty")
+	ErrInvalidDefaultAmount       = errors.New("default amount must be greater than or equal to 0")
+	ErrInvalidRecurringType       = errors.New("invalid recurring type")
+	ErrInvalidRecurringPeriod     = errors.New("invalid recurring period")
+	ErrInvalidRecurringDueDay     = errors.New("recurring due day must be between 1 and 31")
+	ErrInvalidReminderType        = errors.New("invalid reminder type")
+	ErrFlexiblePostponeOnly       = errors.New("only flexible expense types can be postponed")
+	ErrExpenseTypeInUse           = errors.New("cannot delete expense type that is in use")
+	ErrRecurrenceRuleNotRecurring = errors.New("recurrence rule requires a recurring type")
+	ErrExpenseTypeCycleReference  = errors.New("expense type cannot be moved under itself or one of its descendants")
 )
 
+// ExpenseTypePathSeparator joins expense type names into a path such as
+// "Home > Utilities > Electricity".
+const ExpenseTypePathSeparator = " > "
+
+// maxExpenseTypeDepth only guards ancestor walks against corrupted data; the
+// hierarchy itself has no practical depth limit.
+const maxExpenseTypeDepth = 64
+
 type ExpenseTypeService struct {
 	db *gorm.DB
 }
 
 type CreateExpenseTypeRequest struct {
-	ParentID        *uint   `json:"parent_id"`
-	Name            string  `json:"name"`
-	Icon            string  `json:"icon"`
-	Color           string  `json:"color"`
-	Description     string  `json:"description"`
-	DefaultAmount   float64 `json:"default_amount"`
-	DefaultWalletID *uint   `json:"default_wallet_id"`
-	RecurringType   string  `json:"recurring_type"`
-	RecurringPeriod string  `json:"recurring_period"`
-	RecurringDueDay int     `json:"recurring_due_day"`
-	ReminderType    string  `json:"reminder_type"`
-	NextDueDay      *string `json:"next_due_day"`
-	IOSCategory     string  `json:"ios_category"`
-	Stopped         bool    `json:"stopped"`
+	ParentID             *uint   `json:"parent_id"`
+	Name                 string  `json:"name"`
+	Icon                 string  `json:"icon"`
+	Color                string  `json:"color"`
+	Description          string  `json:"description"`
+	DefaultAmount        float64 `json:"default_amount"`
+	DefaultWalletID      *uint   `json:"default_wallet_id"`
+	RecurringType        string  `json:"recurring_type"`
+	RecurringPeriod      string  `json:"recurring_period"`
+	RecurringDueDay      int     `json:"recurring_due_day"`
+	RecurrenceRule       string  `json:"recurrence_rule"`
+	BusinessDayRule      string  `json:"business_day_rule"`
+	HolidayRegion        string  `json:"holiday_region"`
+	ReminderType         string  `json:"reminder_type"`
+	NextDueDay           *string `json:"next_due_day"`
+	IOSCategory          string  `json:"ios_category"`
+	EndDate              *string `json:"end_date"`
+	RemainingOccurrences *int    `json:"remaining_occurrences"`
+	EndingSoonReminder   bool    `json:"ending_soon_reminder"`
+	Stopped              bool    `json:"stopped"`
 }
 
 type UpdateExpenseTypeRequest struct {
-	ParentID        *uint   `json:"parent_id"`
-	Name            string  `json:"name"`
-	Icon            string  `json:"icon"`
-	Color           string  `json:"color"`
-	Description     string  `json:"description"`
-	DefaultAmount   float64 `json:"default_amount"`
-	DefaultWalletID *uint   `json:"default_wallet_id"`
-	RecurringType   string  `json:"recurring_type"`
-	RecurringPeriod string  `json:"recurring_period"`
-	RecurringDueDay int     `json:"recurring_due_day"`
-	ReminderType    string  `json:"reminder_type"`
-	NextDueDay      *string `json:"next_due_day"`
-	IOSCategory     string  `json:"ios_category"`
-	Stopped         bool    `json:"stopped"`
+	ParentID             *uint   `json:"parent_id"`
+	Name                 string  `json:"name"`
+	Icon                 string  `json:"icon"`
+	Color                string  `json:"color"`
+	Description          string  `json:"description"`
+	DefaultAmount        float64 `json:"default_amount"`
+	DefaultWalletID      *uint   `json:"default_wallet_id"`
+	RecurringType        string  `json:"recurring_type"`
+	RecurringPeriod      string  `json:"recurring_period"`
+	RecurringDueDay      int     `json:"recurring_due_day"`
+	RecurrenceRule       *string `json:"recurrence_rule"`
+	BusinessDayRule      *string `json:"business_day_rule"`
+	HolidayRegion        *string `json:"holiday_region"`
+	ReminderType         string  `json:"reminder_type"`
+	NextDueDay           *string `json:"next_due_day"`
+	IOSCategory          string  `json:"ios_category"`
+	EndDate              *string `json:"end_date"`
+	RemainingOccurrences *int    `json:"remaining_occurrences"`
+	EndingSoonReminder   *bool   `json:"ending_soon_reminder"`
+	ClearRemaining       bool    `json:"clear_remaining_occurrences"` // omitting RemainingOccurrences keeps the count
+	Stopped              bool    `json:"stopped"`
 }
 
 type UpdateExpenseTypeDefaultAmountRequest struct {
 	DefaultAmount *float64 `json:"default_amount"`
+	EffectiveFrom string   `json:"effective_from"`
 }
 
 type PostponeExpenseTypeRequest struct {
@@ -75,9 +97,19 @@ type ExpenseTypeListResponse struct {
 	Total        int64         `json:"total"`
 }
 
+type MoveExpenseTypeRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// ExpenseTypeTreeNode is one node of the expense type tree. Children lists the
+// direct children; Subtree holds the same children as nodes so the whole
+// hierarchy can be walked to any depth.
 type ExpenseTypeTreeNode struct {
-	ExpenseType ExpenseType   `json:"expense_type"`
-	Children    []ExpenseType `json:"children"`
+	ExpenseType ExpenseType           `json:"expense_type"`
+	Children    []ExpenseType         `json:"children"`
+	Subtree     []ExpenseTypeTreeNode `json:"subtree"`
+	Depth       int                   `json:"depth"`
+	Path        string                `json:"path"`
 }
 
 func NewExpenseTypeService(db *gorm.DB) *ExpenseTypeService {
@@ -85,10 +117,23 @@ func NewExpenseTypeService(db *gorm.DB) *ExpenseTypeService {
 }
 
 func (s *ExpenseTypeService) CreateExpenseType(userID uint, req CreateExpenseTypeRequest) (*ExpenseType, error) {
-	prepared, err := s.prepareExpenseType(userID, req.ParentID, 0, req.Name, req.Icon, req.Color, req.Description, req.DefaultAmount, req.DefaultWalletID, req.RecurringType, req.RecurringPeriod, req.RecurringDueDay, req.ReminderType, req.NextDueDay, req.IOSCategory, req.Stopped, nil)
+	prepared, err := s.prepareExpenseType(userID, req.ParentID, 0, req.Name, req.Icon, req.Color, req.Description, req.DefaultAmount, req.DefaultWalletID, req.RecurringType, req.RecurringPeriod, req.RecurringDueDay, req.RecurrenceRule, req.ReminderType, req.NextDueDay, req.IOSCategory, req.Stopped, nil)
 	if err != nil {
 		return nil, err
 	}
+	prepared.BusinessDayRule, prepared.HolidayRegion, err = normalizeBusinessDaySettings(req.BusinessDayRule, req.HolidayRegion)
+	if err != nil {
+		return nil, err
+	}
+	prepared.EndDate, prepared.RemainingOccurrences, err = parseScheduleEnd(req.EndDate, req.RemainingOccurrences, prepared.RecurringType != RecurringTypeNone)
+	if err != nil {
+		return nil, err
+	}
+	prepared.OccurrenceLimit, prepared.OccurrenceLimitSetAt = occurrenceLimit(prepared.RemainingOccurrences, nil, nil, nil, time.Now())
+	prepared.EndingSoonReminder = req.EndingSoonReminder
+	if prepared.RemainingOccurrences != nil && *prepared.RemainingOccurrences == 0 {
+		prepared.Stopped = true
+	}
 	if err := s.db.Create(prepared).Error; err != nil {
 		return nil, fmt.Errorf("failed to create expense type: %w", err)
 	}
@@ -106,7 +151,11 @@ func (s *ExpenseTypeService) GetExpenseType(userID, expenseTypeID uint) (*Expens
 		}
 		return nil, fmt.Errorf("failed to get expense type: %w", err)
 	}
-	return &expenseType, nil
+	expenseTypes := []ExpenseType{expenseType}
+	if err := withCurrentAmounts(s.db, userID, expenseTypes, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return &expenseTypes[0], nil
 }
 
 func (s *ExpenseTypeService) UpdateExpenseType(userID, expenseTypeID uint, req UpdateExpenseTypeRequest) (*ExpenseType, error) {
@@ -114,10 +163,20 @@ func (s *ExpenseTypeService) UpdateExpenseType(userID, expenseTypeID uint, req U
 	if err != nil {
 		return nil, err
 	}
-	prepared, err := s.prepareExpenseType(userID, req.ParentID, expenseTypeID, req.Name, req.Icon, req.Color, req.Description, req.DefaultAmount, req.DefaultWalletID, req.RecurringType, req.RecurringPeriod, req.RecurringDueDay, req.ReminderType, req.NextDueDay, req.IOSCategory, req.Stopped, existing)
+	recurrenceRule := resolveRecurrenceRuleUpdate(req.RecurrenceRule, existing.RecurrenceRule, normalizeRecurringType(req.RecurringType) != RecurringTypeNone)
+	prepared, err := s.prepareExpenseType(userID, req.ParentID, expenseTypeID, req.Name, req.Icon, req.Color, req.Description, req.DefaultAmount, req.DefaultWalletID, req.RecurringType, req.RecurringPeriod, req.RecurringDueDay, recurrenceRule, req.ReminderType, req.NextDueDay, req.IOSCategory, req.Stopped, existing)
+	if err != nil {
+		return nil, err
+	}
+	businessDayRule, holidayRegion, err := resolveBusinessDaySettings(req.BusinessDayRule, req.HolidayRegion, existing.BusinessDayRule, existing.HolidayRegion)
 	if err != nil {
 		return nil, err
 	}
+	endDate, remainingOccurrences, err := resolveScheduleEnd(req.EndDate, req.RemainingOccurrences, req.ClearRemaining, existing.EndDate, existing.RemainingOccurrences, prepared.RecurringType != RecurringTypeNone)
+	if err != nil {
+		return nil, err
+	}
+	previous := *existing
 	existing.ParentID = prepared.ParentID
 	existing.Name = prepared.Name
 	existing.Icon = prepared.Icon
@@ -128,12 +187,38 @@ func (s *ExpenseTypeService) UpdateExpenseType(userID, expenseTypeID uint, req U
 	existing.RecurringType = prepared.RecurringType
 	existing.RecurringPeriod = prepared.RecurringPeriod
 	existing.RecurringDueDay = prepared.RecurringDueDay
+	existing.RecurrenceRule = prepared.RecurrenceRule
+	existing.BusinessDayRule = businessDayRule
+	existing.HolidayRegion = holidayRegion
 	existing.ReminderType = prepared.ReminderType
 	existing.NextDueDay = prepared.NextDueDay
 	existing.IOSCategory = prepared.IOSCategory
-	existing.Stopped = prepared.Stopped
-	if err := s.db.Save(existing).Error; err != nil {
-		return nil, fmt.Errorf("failed to update expense type: %w", err)
+	existing.EndDate = endDate
+	existing.OccurrenceLimit, existing.OccurrenceLimitSetAt = occurrenceLimit(remainingOccurrences, existing.RemainingOccurrences, existing.OccurrenceLimit, existing.OccurrenceLimitSetAt, time.Now())
+	existing.RemainingOccurrences = remainingOccurrences
+	if req.EndingSoonReminder != nil {
+		existing.EndingSoonReminder = *req.EndingSoonReminder
+	}
+	existing.Stopped = prepared.Stopped || (remainingOccurrences != nil && *remainingOccurrences == 0)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(existing).Error; err != nil {
+			return fmt.Errorf("failed to update expense type: %w", err)
+		}
+		if existing.DefaultAmount != previous.DefaultAmount {
+			if err := recordAmountChange(tx, previous, existing.DefaultAmount, time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+		if err := clearUpcomingOccurrences(tx, userID, "expense_type_id", existing.ID); err != nil {
+			return err
+		}
+		if err := moveFlexibleOccurrence(tx, userID, *existing); err != nil {
+			return err
+		}
+		return syncExpenseTypeOccurrences(tx, userID, existing.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
 	if err := s.db.Preload("Parent").Preload("DefaultWallet").First(existing, existing.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to reload expense type: %w", err)
@@ -141,19 +226,45 @@ func (s *ExpenseTypeService) UpdateExpenseType(userID, expenseTypeID uint, req U
 	return existing, nil
 }
 
-func (s *ExpenseTypeService) UpdateExpenseTypeDefaultAmount(userID, expenseTypeID uint, defaultAmount float64) (*ExpenseType, error) {
+// UpdateExpenseTypeDefaultAmount changes the type's amount. An empty
+// effectiveFrom, or one not after today, changes it now; a later date
+// schedules the change and leaves the current amount alone.
+func (s *ExpenseTypeService) UpdateExpenseTypeDefaultAmount(userID, expenseTypeID uint, defaultAmount float64, effectiveFrom string) (*ExpenseType, error) {
 	if defaultAmount < 0 {
 		return nil, ErrInvalidDefaultAmount
 	}
+	today := NormalizeDateOnly(time.Now().UTC())
+	effective := today
+	if strings.TrimSpace(effectiveFrom) != "" {
+		parsed, err := ParseDateOnly(strings.TrimSpace(effectiveFrom))
+		if err != nil {
+			return nil, ErrInvalidEffectiveFrom
+		}
+		if parsed.After(today) {
+			effective = parsed
+		}
+	}
 
 	existing, err := s.GetExpenseType(userID, expenseTypeID)
 	if err != nil {
 		return nil, err
 	}
 
-	existing.DefaultAmount = defaultAmount
-	if err := s.db.Save(existing).Error; err != nil {
-		return nil, fmt.Errorf("failed to update expense type default amount: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := recordAmountChange(tx, *existing, defaultAmount, effective); err != nil {
+			return err
+		}
+		if effective.After(today) {
+			return nil
+		}
+		existing.DefaultAmount = defaultAmount
+		if err := tx.Save(existing).Error; err != nil {
+			return fmt.Errorf("failed to update expense type default amount: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	if err := s.db.Preload("Parent").Preload("DefaultWallet").First(existing, existing.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to reload expense type: %w", err)
@@ -162,6 +273,42 @@ func (s *ExpenseTypeService) UpdateExpenseTypeDefaultAmount(userID, expenseTypeI
 	return existing, nil
 }
 
+// ListExpenseTypeAmounts returns the amount history of a type, including
+// scheduled changes, oldest first.
+func (s *ExpenseTypeService) ListExpenseTypeAmounts(userID, expenseTypeID uint) ([]ExpenseTypeAmount, error) {
+	if _, err := s.GetExpenseType(userID, expenseTypeID); err != nil {
+		return nil, err
+	}
+	schedule, err := LoadAmountSchedule(s.db, userID, []uint{expenseTypeID})
+	if err != nil {
+		return nil, err
+	}
+	amounts := schedule[expenseTypeID]
+	if amounts == nil {
+		amounts = make([]ExpenseTypeAmount, 0)
+	}
+	return amounts, nil
+}
+
+// CancelExpenseTypeAmount removes a scheduled amount change that has not
+// taken effect yet.
+func (s *ExpenseTypeService) CancelExpenseTypeAmount(userID, expenseTypeID, amountID uint) error {
+	var amount ExpenseTypeAmount
+	if err := s.db.Where("id = ? AND expense_type_id = ? AND user_id = ?", amountID, expenseTypeID, userID).First(&amount).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAmountChangeNotFound
+		}
+		return fmt.Errorf("failed to get amount change: %w", err)
+	}
+	if !amount.EffectiveFrom.After(NormalizeDateOnly(time.Now().UTC())) {
+		return ErrAmountChangeNotScheduled
+	}
+	if err := s.db.Delete(&amount).Error; err != nil {
+		return fmt.Errorf("failed to cancel amount change: %w", err)
+	}
+	return nil
+}
+
 func (s *ExpenseTypeService) DeleteExpenseType(userID, expenseTypeID uint) error {
 	if _, err := s.GetExpenseType(userID, expenseTypeID); err != nil {
 		return err
@@ -207,6 +354,9 @@ func (s *ExpenseTypeService) ListExpenseTypes(userID uint, limit, offset int, in
 	if err := query.Preload("Parent").Preload("DefaultWallet").Order("name ASC").Limit(limit).Offset(offset).Find(&expenseTypes).Error; err != nil {
 		return nil, fmt.Errorf("failed to list expense types: %w", err)
 	}
+	if err := withCurrentAmounts(s.db, userID, expenseTypes, time.Now().UTC()); err != nil {
+		return nil, err
+	}
 	return &ExpenseTypeListResponse{ExpenseTypes: expenseTypes, Total: total}, nil
 }
 
@@ -224,15 +374,117 @@ func (s *ExpenseTypeService) GetExpenseTypeTree(userID uint, includeStopped bool
 		}
 		childrenByParent[*expenseType.ParentID] = append(childrenByParent[*expenseType.ParentID], expenseType)
 	}
-	tree := make([]ExpenseTypeTreeNode, 0, len(roots))
-	for _, root := range roots {
-		children := childrenByParent[root.ID]
+	return buildExpenseTypeTree(roots, childrenByParent, 0, ""), nil
+}
+
+func buildExpenseTypeTree(expenseTypes []ExpenseType, childrenByParent map[uint][]ExpenseType, depth int, parentPath string) []ExpenseTypeTreeNode {
+	nodes := make([]ExpenseTypeTreeNode, 0, len(expenseTypes))
+	for _, expenseType := range expenseTypes {
+		path := expenseType.Name
+		if parentPath != "" {
+			path = parentPath + ExpenseTypePathSeparator + expenseType.Name
+		}
+		children := childrenByParent[expenseType.ID]
 		if children == nil {
 			children = []ExpenseType{}
 		}
-		tree = append(tree, ExpenseTypeTreeNode{ExpenseType: root, Children: children})
+		nodes = append(nodes, ExpenseTypeTreeNode{
+			ExpenseType: expenseType,
+			Children:    children,
+			Subtree:     buildExpenseTypeTree(children, childrenByParent, depth+1, path),
+			Depth:       depth,
+			Path:        path,
+		})
+	}
+	return nodes
+}
+
+// MoveExpenseType moves an expense type and everything below it under a new
+// parent, or to the top level when parentID is nil.
+func (s *ExpenseTypeService) MoveExpenseType(userID, expenseTypeID uint, req MoveExpenseTypeRequest) (*ExpenseType, error) {
+	if _, err := s.GetExpenseType(userID, expenseTypeID); err != nil {
+		return nil, err
+	}
+	if err := s.validateExpenseTypeParent(userID, expenseTypeID, req.ParentID); err != nil {
+		return nil, err
 	}
-	return tree, nil
+	if err := s.db.Model(&ExpenseType{}).Where("id = ? AND user_id = ?", expenseTypeID, userID).Update("parent_id", req.ParentID).Error; err != nil {
+		return nil, fmt.Errorf("failed to move expense type: %w", err)
+	}
+	return s.GetExpenseType(userID, expenseTypeID)
+}
+
+// validateExpenseTypeParent checks that parentID exists and is not the
+// expense type itself or one of its descendants.
+func (s *ExpenseTypeService) validateExpenseTypeParent(userID, expenseTypeID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if expenseTypeID != 0 && *parentID == expenseTypeID {
+		return ErrExpenseTypeCycleReference
+	}
+	ancestors, err := expenseTypeAncestorIDs(s.db, userID, *parentID)
+	if err != nil {
+		return err
+	}
+	for _, ancestorID := range ancestors {
+		if expenseTypeID != 0 && ancestorID == expenseTypeID {
+			return ErrExpenseTypeCycleReference
+		}
+	}
+	return nil
+}
+
+// expenseTypeAncestorIDs returns expenseTypeID followed by its ancestors, up
+// to the root.
+func expenseTypeAncestorIDs(db *gorm.DB, userID, expenseTypeID uint) ([]uint, error) {
+	ids := make([]uint, 0, 4)
+	currentID := &expenseTypeID
+	for currentID != nil {
+		if len(ids) >= maxExpenseTypeDepth {
+			return nil, ErrExpenseTypeCycleReference
+		}
+		var current ExpenseType
+		if err := db.Select("id", "parent_id").Where("id = ? AND user_id = ?", *currentID, userID).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrExpenseTypeNotFound
+			}
+			return nil, fmt.Errorf("failed to load expense type ancestors: %w", err)
+		}
+		ids = append(ids, current.ID)
+		currentID = current.ParentID
+	}
+	return ids, nil
+}
+
+// ExpenseTypePath returns the chain of expense types from the root down to
+// expenseTypeID, using types indexed by ID.
+func ExpenseTypePath(types map[uint]ExpenseType, expenseTypeID uint) []ExpenseType {
+	path := make([]ExpenseType, 0, 4)
+	seen := make(map[uint]bool)
+	currentID := expenseTypeID
+	for {
+		expenseType, ok := types[currentID]
+		if !ok || seen[currentID] {
+			break
+		}
+		seen[currentID] = true
+		path = append([]ExpenseType{expenseType}, path...)
+		if expenseType.ParentID == nil {
+			break
+		}
+		currentID = *expenseType.ParentID
+	}
+	return path
+}
+
+// FormatExpenseTypePath joins the names of path with ExpenseTypePathSeparator.
+func FormatExpenseTypePath(path []ExpenseType) string {
+	names := make([]string, 0, len(path))
+	for _, expenseType := range path {
+		names = append(names, expenseType.Name)
+	}
+	return strings.Join(names, ExpenseTypePathSeparator)
 }
 
 func (s *ExpenseTypeService) PostponeExpenseType(userID, expenseTypeID uint, req PostponeExpenseTypeRequest) (*ExpenseType, error) {
@@ -248,8 +500,17 @@ func (s *ExpenseTypeService) PostponeExpenseType(userID, expenseTypeID uint, req
 		return nil, err
 	}
 	expenseType.NextDueDay = &nextDueDay
-	if err := s.db.Save(expenseType).Error; err != nil {
-		return nil, fmt.Errorf("failed to postpone expense type: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(expenseType).Error; err != nil {
+			return fmt.Errorf("failed to postpone expense type: %w", err)
+		}
+		if err := moveFlexibleOccurrence(tx, userID, *expenseType); err != nil {
+			return err
+		}
+		return syncExpenseTypeOccurrences(tx, userID, expenseType.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return expenseType, nil
 }
@@ -277,7 +538,7 @@ func (s *ExpenseTypeService) FindExpenseTypeByIOSCategory(userID uint, iosCatego
 	return &expenseType, nil
 }
 
-func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, expenseTypeID uint, name, icon, color, description string, defaultAmount float64, defaultWalletID *uint, recurringType, recurringPeriod string, recurringDueDay int, reminderType string, nextDueDay *string, iosCategory string, stopped bool, existing *ExpenseType) (*ExpenseType, error) {
+func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, expenseTypeID uint, name, icon, color, description string, defaultAmount float64, defaultWalletID *uint, recurringType, recurringPeriod string, recurringDueDay int, recurrenceRule, reminderType string, nextDueDay *string, iosCategory string, stopped bool, existing *ExpenseType) (*ExpenseType, error) {
 	if strings.TrimSpace(name) == "" {
 		return nil, ErrEmptyExpenseTypeName
 	}
@@ -289,20 +550,8 @@ func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, exp
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("failed to check expense type name: %w", err)
 	}
-	if parentID != nil {
-		if expenseTypeID != 0 && *parentID == expenseTypeID {
-			return nil, ErrExpenseTypeCycleReference
-		}
-		var parent ExpenseType
-		if err := s.db.Where("id = ? AND user_id = ?", *parentID, userID).First(&parent).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, ErrExpenseTypeNotFound
-			}
-			return nil, fmt.Errorf("failed to validate parent expense type: %w", err)
-		}
-		if parent.ParentID != nil {
-			return nil, ErrInvalidExpenseParent
-		}
+	if err := s.validateExpenseTypeParent(userID, expenseTypeID, parentID); err != nil {
+		return nil, err
 	}
 	if defaultWalletID != nil {
 		var wallet Wallet
@@ -316,7 +565,15 @@ func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, exp
 	recurringType = normalizeRecurringType(recurringType)
 	recurringPeriod = normalizeRecurringPeriod(recurringPeriod)
 	reminderType = normalizeReminderType(reminderType, recurringType, recurringPeriod)
-	if err := validateRecurring(recurringType, recurringPeriod, recurringDueDay, reminderType); err != nil {
+	if err := validateRecurring(recurringType, recurringPeriod, recurringDueDay, recurrenceRule, reminderType); err != nil {
+		return nil, err
+	}
+	var existingRule string
+	if existing != nil {
+		existingRule = existing.RecurrenceRule
+	}
+	recurrenceRule, err = normalizeStoredRecurrenceRule(recurrenceRule, existingRule)
+	if err != nil {
 		return nil, err
 	}
 	prepared := &ExpenseType{
@@ -330,6 +587,7 @@ func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, exp
 		RecurringType:   recurringType,
 		RecurringPeriod: recurringPeriod,
 		RecurringDueDay: recurringDueDay,
+		RecurrenceRule:  recurrenceRule,
 		ReminderType:    reminderType,
 		IOSCategory:     strings.TrimSpace(iosCategory),
 		Stopped:         stopped,
@@ -351,7 +609,7 @@ func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, exp
 		prepared.NextDueDay = &parsedNextDueDay
 		return prepared, nil
 	}
-	if existing != nil && existing.RecurringType == recurringType && existing.RecurringPeriod == recurringPeriod && existing.RecurringDueDay == recurringDueDay && existing.NextDueDay != nil {
+	if existing != nil && existing.RecurringType == recurringType && existing.RecurringPeriod == recurringPeriod && existing.RecurringDueDay == recurringDueDay && existing.RecurrenceRule == recurrenceRule && existing.NextDueDay != nil {
 		prepared.NextDueDay = existing.NextDueDay
 		return prepared, nil
 	}
@@ -361,7 +619,7 @@ func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, exp
 		prepared.NextDueDay = nil
 		return prepared, nil
 	}
-	computedNextDueDay, err := ComputeInitialNextDueDay(time.Now(), recurringType, recurringPeriod, recurringDueDay)
+	computedNextDueDay, err := ComputeInitialNextDueDay(time.Now(), recurringType, recurringPeriod, recurringDueDay, recurrenceRule)
 	if err != nil {
 		return nil, err
 	}
@@ -369,6 +627,42 @@ func (s *ExpenseTypeService) prepareExpenseType(userID uint, parentID *uint, exp
 	return prepared, nil
 }
 
+// resolveRecurrenceRuleUpdate returns the rule an update submits. A nil
+// rule keeps the stored one while the schedule can still use it; an empty
+// one clears it.
+func resolveRecurrenceRuleUpdate(value *string, existing string, supported bool) string {
+	if value != nil {
+		return *value
+	}
+	if !supported {
+		return ""
+	}
+	return existing
+}
+
+// normalizeStoredRecurrenceRule validates a rule and stores it with its
+// DTSTART. Resubmitting an unchanged RRULE without DTSTART keeps the start of
+// the stored rule, so editing other fields does not shift the series.
+func normalizeStoredRecurrenceRule(value, existing string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+	rule, err := ParseRecurrenceRule(value, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if existing != "" && !strings.Contains(strings.ToUpper(value), "DTSTART") {
+		if current, err := ParseRecurrenceRule(existing, time.Now()); err == nil {
+			unchanged := *rule
+			unchanged.DTStart = current.DTStart
+			if unchanged.String() == current.String() {
+				return current.String(), nil
+			}
+		}
+	}
+	return rule.String(), nil
+}
+
 func normalizeRecurringType(value string) string {
 	value = strings.ToLower(strings.TrimSpace(value))
 	if value == "" {
@@ -385,10 +679,24 @@ func normalizeRecurringPeriod(value string) string {
 	return value
 }
 
-func validateRecurring(recurringType, recurringPeriod string, recurringDueDay int, reminderType string) error {
+// validateRecurring checks the recurring settings. A recurrence rule needs a
+// recurring type and takes the place of the period and due day.
+func validateRecurring(recurringType, recurringPeriod string, recurringDueDay int, recurrenceRule, reminderType string) error {
 	if !isValidReminderType(reminderType) {
 		return ErrInvalidReminderType
 	}
+	if strings.TrimSpace(recurrenceRule) != "" {
+		if recurringType == RecurringTypeNone {
+			return ErrRecurrenceRuleNotRecurring
+		}
+		if recurringType != RecurringTypeFixedDay && recurringType != RecurringTypeFlexible {
+			return ErrInvalidRecurringType
+		}
+		if recurringPeriod != RecurringPeriodNone && !isValidRecurringPeriod(recurringPeriod, true) {
+			return ErrInvalidRecurringPeriod
+		}
+		return nil
+	}
 	switch recurringType {
 	case RecurringTypeNone:
 		if recurringPeriod != RecurringPeriodNone {
@@ -0,0 +1,117 @@
+package expenses
+
+import "testing"
+
+func uintPtr(value uint) *uint {
+	return &value
+}
+
+func TestExpenseTypePath(t *testing.T) {
+	types := map[uint]ExpenseType{
+		1: {ID: 1, Name: "Home"},
+		2: {ID: 2, Name: "Utilities", ParentID: uintPtr(1)},
+		3: {ID: 3, Name: "Electricity", ParentID: uintPtr(2)},
+	}
+
+	if got := FormatExpenseTypePath(ExpenseTypePath(types, 3)); got != "Home > Utilities > Electricity" {
+		t.Fatalf("path = %q, want %q", got, "Home > Utilities > Electricity")
+	}
+	if got := FormatExpenseTypePath(ExpenseTypePath(types, 1)); got != "Home" {
+		t.Fatalf("root path = %q, want %q", got, "Home")
+	}
+	if got := ExpenseTypePath(types, 99); len(got) != 0 {
+		t.Fatalf("unknown type path has %d entries, want 0", len(got))
+	}
+}
+
+func TestExpenseTypePathStopsOnCycle(t *testing.T) {
+	types := map[uint]ExpenseType{
+		1: {ID: 1, Name: "A", ParentID: uintPtr(2)},
+		2: {ID: 2, Name: "B", ParentID: uintPtr(1)},
+	}
+	if got := FormatExpenseTypePath(ExpenseTypePath(types, 1)); got != "B > A" {
+		t.Fatalf("path = %q, want %q", got, "B > A")
+	}
+}
+
+func TestBuildExpenseTypeTree(t *testing.T) {
+	home := ExpenseType{ID: 1, Name: "Home"}
+	utilities := ExpenseType{ID: 2, Name: "Utilities", ParentID: uintPtr(1)}
+	electricity := ExpenseType{ID: 3, Name: "Electricity", ParentID: uintPtr(2)}
+	childrenByParent := map[uint][]ExpenseType{
+		1: {utilities},
+		2: {electricity},
+	}
+
+	tree := buildExpenseTypeTree([]ExpenseType{home}, childrenByParent, 0, "")
+	if len(tree) != 1 || len(tree[0].Children) != 1 || len(tree[0].Subtree) != 1 {
+		t.Fatalf("unexpected tree shape: %+v", tree)
+	}
+	leaf := tree[0].Subtree[0].Subtree[0]
+	if leaf.ExpenseType.ID != 3 || leaf.Depth != 2 || leaf.Path != "Home > Utilities > Electricity" {
+		t.Fatalf("leaf = id %d depth %d path %q", leaf.ExpenseType.ID, leaf.Depth, leaf.Path)
+	}
+	if leaf.Children == nil || leaf.Subtree == nil {
+		t.Fatalf("leaf children should be empty slices, not nil")
+	}
+}
+
+func TestExpenseTypePresetsAreValid(t *testing.T) {
+	service := &ExpenseTypeService{}
+	for _, language := range []string{"en", "zh-Hant", "zh-Hans"} {
+		presets, err := service.ListExpenseTypePresets(language)
+		if err != nil {
+			t.Fatalf("ListExpenseTypePresets(%s) error: %v", language, err)
+		}
+		if len(presets) == 0 {
+			t.Fatalf("no presets for %s", language)
+		}
+		for _, preset := range presets {
+			if preset.Language != language || preset.Name == "" {
+				t.Fatalf("preset %s is not localized to %s", preset.ID, language)
+			}
+			keys := make(map[string]bool)
+			for _, item := range preset.ExpenseTypes {
+				if item.Name == "" {
+					t.Fatalf("preset %s item %s has no %s name", preset.ID, item.Key, language)
+				}
+				if item.ParentKey != "" && !keys[item.ParentKey] {
+					t.Fatalf("preset %s item %s lists parent %s after it", preset.ID, item.Key, item.ParentKey)
+				}
+				keys[item.Key] = true
+				recurringType := normalizeRecurringType(item.RecurringType)
+				recurringPeriod := normalizeRecurringPeriod(item.RecurringPeriod)
+				reminderType := normalizeReminderType(item.ReminderType, recurringType, recurringPeriod)
+				if err := validateRecurring(recurringType, recurringPeriod, item.RecurringDueDay, item.RecurrenceRule, reminderType); err != nil {
+					t.Fatalf("preset %s item %s is invalid: %v", preset.ID, item.Key, err)
+				}
+			}
+		}
+	}
+}
+
+func TestExpenseTypePresetsFallBackToEnglish(t *testing.T) {
+	presets, err := (&ExpenseTypeService{}).ListExpenseTypePresets("fr")
+	if err != nil {
+		t.Fatalf("ListExpenseTypePresets error: %v", err)
+	}
+	if presets[0].Language != "en" {
+		t.Fatalf("language = %s, want en", presets[0].Language)
+	}
+}
+
+func TestResolveRecurrenceRuleUpdate(t *testing.T) {
+	stored := "DTSTART:20250101T000000Z\nRRULE:FREQ=MONTHLY;BYDAY=-1FR"
+	if got := resolveRecurrenceRuleUpdate(nil, stored, true); got != stored {
+		t.Fatalf("expected an omitted rule to keep the stored one, got %q", got)
+	}
+	if got := resolveRecurrenceRuleUpdate(nil, stored, false); got != "" {
+		t.Fatalf("expected an omitted rule to be dropped when rules are not supported, got %q", got)
+	}
+	if got := resolveRecurrenceRuleUpdate(strPtr(""), stored, true); got != "" {
+		t.Fatalf("expected an empty rule to clear the stored one, got %q", got)
+	}
+	if got := resolveRecurrenceRuleUpdate(strPtr("FREQ=WEEKLY"), stored, true); got != "FREQ=WEEKLY" {
+		t.Fatalf("expected the submitted rule, got %q", got)
+	}
+}
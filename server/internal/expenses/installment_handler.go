@@ -0,0 +1,88 @@
+package expenses
+
+import (
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InstallmentHandler struct {
+	service *InstallmentService
+}
+
+func NewInstallmentHandler(service *InstallmentService) *InstallmentHandler {
+	return &InstallmentHandler{service: service}
+}
+
+func (h *InstallmentHandler) CreateInstallmentPlan(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req CreateInstallmentPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	plan, err := h.service.CreateInstallmentPlan(userID, req)
+	if err != nil {
+		return h.installmentError(c, err, "Failed to create installment plan")
+	}
+	return c.JSON(http.StatusCreated, plan)
+}
+
+func (h *InstallmentHandler) GetInstallmentPlan(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	planID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid installment plan ID"})
+	}
+	plan, err := h.service.GetInstallmentPlan(userID, uint(planID))
+	if err != nil {
+		return h.installmentError(c, err, "Failed to get installment plan")
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+func (h *InstallmentHandler) DeleteInstallmentPlan(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	planID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid installment plan ID"})
+	}
+	if err := h.service.DeleteInstallmentPlan(userID, uint(planID)); err != nil {
+		return h.installmentError(c, err, "Failed to delete installment plan")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Installment plan deleted successfully"})
+}
+
+func (h *InstallmentHandler) ListInstallmentPlans(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req InstallmentPlanListRequest
+	req.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	req.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	if value := c.QueryParam("wallet_id"); value != "" {
+		if parsed, err := strconv.ParseUint(value, 10, 32); err == nil {
+			id := uint(parsed)
+			req.WalletID = &id
+		}
+	}
+	response, err := h.service.ListInstallmentPlans(userID, req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list installment plans"})
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *InstallmentHandler) installmentError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case ErrInstallmentPlanNotFound, ErrWalletNotFound, ErrExpenseTypeNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case ErrInvalidInstallmentAmount, ErrInvalidInstallmentCount, ErrInvalidInstallmentDate, ErrInstallmentNoCredit, ErrInvalidFirstChargeDate:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,29 @@
+package expenses
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InstallmentPlan splits one purchase on a credit wallet into monthly charges.
+// Each charge is an Expense on the wallet, so statements, unbilled lists and
+// payments pick it up on its own date.
+type InstallmentPlan struct {
+	ID               uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	ExpenseTypeID    uint           `json:"expense_type_id" gorm:"type:bigint;not null;index"`
+	WalletID         uint           `json:"wallet_id" gorm:"type:bigint;not null;index"`
+	TotalAmount      float64        `json:"total_amount" gorm:"type:numeric(12,2);not null"`
+	InstallmentCount int            `json:"installment_count" gorm:"not null"`
+	PurchaseDate     time.Time      `json:"purchase_date" gorm:"type:date;not null;index"`
+	FirstChargeDate  time.Time      `json:"first_charge_date" gorm:"type:date;not null"`
+	Note             string         `json:"note" gorm:"type:text"`
+	UserID           uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ExpenseType ExpenseType `json:"expense_type,omitempty" gorm:"foreignKey:ExpenseTypeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Wallet      Wallet      `json:"wallet,omitempty" gorm:"foreignKey:WalletID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Charges     []Expense   `json:"charges,omitempty" gorm:"foreignKey:InstallmentPlanID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
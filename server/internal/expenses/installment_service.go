@@ -0,0 +1,306 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MinInstallmentCount = 2
+	MaxInstallmentCount = 60
+)
+
+var (
+	ErrInstallmentPlanNotFound  = errors.New("installment plan not found")
+	ErrInvalidInstallmentAmount = errors.New("installment total amount must be greater than 0")
+	ErrInvalidInstallmentCount  = errors.New("installment count must be between 2 and 60")
+	ErrInvalidInstallmentDate   = errors.New("installment purchase date is required")
+	ErrInstallmentNoCredit      = errors.New("installment plans are only supported on credit wallets")
+	ErrInvalidFirstChargeDate   = errors.New("first charge date must not be before the purchase date")
+)
+
+type InstallmentService struct {
+	db       *gorm.DB
+	expenses *ExpenseService
+	eventSource
+}
+
+type CreateInstallmentPlanRequest struct {
+	ExpenseTypeID    uint    `json:"expense_type_id"`
+	WalletID         uint    `json:"wallet_id"`
+	TotalAmount      float64 `json:"total_amount"`
+	InstallmentCount int     `json:"installment_count"`
+	PurchaseDate     string  `json:"purchase_date"`
+	FirstChargeDate  string  `json:"first_charge_date"`
+	Note             string  `json:"note"`
+}
+
+type InstallmentPlanListRequest struct {
+	WalletID *uint
+	Limit    int
+	Offset   int
+}
+
+type InstallmentPlanListResponse struct {
+	InstallmentPlans []InstallmentPlan `json:"installment_plans"`
+	Total            int64             `json:"total"`
+}
+
+func NewInstallmentService(db *gorm.DB) *InstallmentService {
+	return &InstallmentService{db: db, expenses: NewExpenseService(db)}
+}
+
+// SetEventPublisher sets where change events are published. Each installment
+// charge is published as an expense event.
+func (s *InstallmentService) SetEventPublisher(publisher EventPublisher) {
+	s.eventSource.SetEventPublisher(publisher)
+	s.expenses.SetEventPublisher(publisher)
+}
+
+// CreateInstallmentPlan records the purchase and generates one charge per
+// month on the wallet, starting on the first charge date. The charges land in
+// whichever statement cycle contains their date and are settled by payments
+// like any other card expense.
+func (s *InstallmentService) CreateInstallmentPlan(userID uint, req CreateInstallmentPlanRequest) (*InstallmentPlan, error) {
+	if req.TotalAmount <= 0 {
+		return nil, ErrInvalidInstallmentAmount
+	}
+	if req.InstallmentCount < MinInstallmentCount || req.InstallmentCount > MaxInstallmentCount {
+		return nil, ErrInvalidInstallmentCount
+	}
+	if req.PurchaseDate == "" {
+		return nil, ErrInvalidInstallmentDate
+	}
+	purchaseDate, err := ParseDateOnly(req.PurchaseDate)
+	if err != nil {
+		return nil, err
+	}
+	firstChargeDate := purchaseDate
+	if req.FirstChargeDate != "" {
+		firstChargeDate, err = ParseDateOnly(req.FirstChargeDate)
+		if err != nil {
+			return nil, err
+		}
+		if firstChargeDate.Before(purchaseDate) {
+			return nil, ErrInvalidFirstChargeDate
+		}
+	}
+
+	var expenseType ExpenseType
+	if err := s.db.Where("id = ? AND user_id = ?", req.ExpenseTypeID, userID).First(&expenseType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExpenseTypeNotFound
+		}
+		return nil, fmt.Errorf("failed to load expense type: %w", err)
+	}
+	var wallet Wallet
+	if err := s.db.Where("id = ? AND user_id = ?", req.WalletID, userID).First(&wallet).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, fmt.Errorf("failed to load wallet: %w", err)
+	}
+	if !wallet.IsCredit {
+		return nil, ErrInstallmentNoCredit
+	}
+
+	plan := InstallmentPlan{
+		ExpenseTypeID:    expenseType.ID,
+		WalletID:         wallet.ID,
+		TotalAmount:      req.TotalAmount,
+		InstallmentCount: req.InstallmentCount,
+		PurchaseDate:     purchaseDate,
+		FirstChargeDate:  firstChargeDate,
+		Note:             strings.TrimSpace(req.Note),
+		UserID:           userID,
+	}
+	amounts := SplitInstallmentAmount(req.TotalAmount, req.InstallmentCount)
+	dates := InstallmentChargeDates(firstChargeDate, req.InstallmentCount)
+
+	var charges []Expense
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&plan).Error; err != nil {
+			return fmt.Errorf("failed to create installment plan: %w", err)
+		}
+		charges = make([]Expense, 0, req.InstallmentCount)
+		for index := range amounts {
+			charges = append(charges, Expense{
+				ExpenseTypeID:     expenseType.ID,
+				WalletID:          &wallet.ID,
+				InstallmentPlanID: &plan.ID,
+				InstallmentNumber: index + 1,
+				Amount:            amounts[index],
+				Date:              dates[index],
+				Note:              installmentChargeNote(plan.Note, index+1, req.InstallmentCount),
+				UserID:            userID,
+			})
+		}
+		if err := tx.Create(&charges).Error; err != nil {
+			return fmt.Errorf("failed to create installment charges: %w", err)
+		}
+		for index := range charges {
+			if err := restateExpense(tx, userID, nil, &charges[index]); err != nil {
+				return err
+			}
+		}
+		if err := s.expenses.advanceExpenseTypeDueDate(tx, userID, &expenseType, purchaseDate); err != nil {
+			return err
+		}
+		return syncExpenseTypeOccurrences(tx, userID, expenseType.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.GetInstallmentPlan(userID, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	for index := range charges {
+		charges[index].ExpenseType = expenseType
+		charges[index].Wallet = wallet
+		s.publish(userID, EventExpenseCreated, charges[index])
+	}
+	return created, nil
+}
+
+func (s *InstallmentService) GetInstallmentPlan(userID, planID uint) (*InstallmentPlan, error) {
+	var plan InstallmentPlan
+	err := s.db.Preload("ExpenseType").Preload("Wallet").
+		Preload("Charges", func(db *gorm.DB) *gorm.DB { return db.Order("installment_number ASC") }).
+		Where("id = ? AND user_id = ?", planID, userID).First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInstallmentPlanNotFound
+		}
+		return nil, fmt.Errorf("failed to get installment plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// DeleteInstallmentPlan cancels the plan. Charges that are already paid stay
+// on the wallet as regular expenses; the remaining charges are removed, the
+// statements they were billed on are recalculated and the cycles they
+// settled are reopened.
+func (s *InstallmentService) DeleteInstallmentPlan(userID, planID uint) error {
+	plan, err := s.GetInstallmentPlan(userID, planID)
+	if err != nil {
+		return err
+	}
+	var deletedIDs []uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		unpaid := tx.Model(&Expense{}).Where("user_id = ? AND installment_plan_id = ? AND payment_id IS NULL", userID, planID)
+		if err := unpaid.Session(&gorm.Session{}).Pluck("id", &deletedIDs).Error; err != nil {
+			return fmt.Errorf("failed to load installment charges: %w", err)
+		}
+		var statementIDs []uint
+		if err := unpaid.Session(&gorm.Session{}).Where("statement_id IS NOT NULL").Distinct().Pluck("statement_id", &statementIDs).Error; err != nil {
+			return fmt.Errorf("failed to load installment statements: %w", err)
+		}
+		if err := unpaid.Session(&gorm.Session{}).Delete(&Expense{}).Error; err != nil {
+			return fmt.Errorf("failed to delete installment charges: %w", err)
+		}
+		for _, statementID := range statementIDs {
+			if err := recalcStatement(tx, userID, statementID); err != nil {
+				return err
+			}
+		}
+		for _, id := range deletedIDs {
+			if err := releaseOccurrences(tx, userID, "expense_id", id); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&Expense{}).Where("user_id = ? AND installment_plan_id = ?", userID, planID).Update("installment_plan_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unlink installment charges: %w", err)
+		}
+		if err := tx.Where("id = ? AND user_id = ?", planID, userID).Delete(&InstallmentPlan{}).Error; err != nil {
+			return fmt.Errorf("failed to delete installment plan: %w", err)
+		}
+		return syncExpenseTypeOccurrences(tx, userID, plan.ExpenseTypeID)
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range deletedIDs {
+		s.publish(userID, EventExpenseDeleted, DeletedEvent{ID: id})
+	}
+	return nil
+}
+
+func (s *InstallmentService) ListInstallmentPlans(userID uint, req InstallmentPlanListRequest) (*InstallmentPlanListResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 200 {
+		req.Limit = 200
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	query := s.db.Model(&InstallmentPlan{}).Where("user_id = ?", userID)
+	if req.WalletID != nil {
+		query = query.Where("wallet_id = ?", *req.WalletID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count installment plans: %w", err)
+	}
+
+	var plans []InstallmentPlan
+	err := query.Preload("ExpenseType").Preload("Wallet").
+		Preload("Charges", func(db *gorm.DB) *gorm.DB { return db.Order("installment_number ASC") }).
+		Order("purchase_date DESC, created_at DESC").Limit(req.Limit).Offset(req.Offset).Find(&plans).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installment plans: %w", err)
+	}
+
+	return &InstallmentPlanListResponse{InstallmentPlans: plans, Total: total}, nil
+}
+
+// SplitInstallmentAmount divides total into count equal parts in cents. The
+// rounding remainder goes to the first installment so the parts always add
+// up to the total.
+func SplitInstallmentAmount(total float64, count int) []float64 {
+	if count <= 0 {
+		return nil
+	}
+	cents := int64(math.Round(total * 100))
+	base := cents / int64(count)
+	remainder := cents - base*int64(count)
+	amounts := make([]float64, count)
+	for index := range amounts {
+		part := base
+		if index == 0 {
+			part += remainder
+		}
+		amounts[index] = float64(part) / 100
+	}
+	return amounts
+}
+
+// InstallmentChargeDates returns count monthly dates starting at first. Days
+// past the end of a shorter month clamp to its last day.
+func InstallmentChargeDates(first time.Time, count int) []time.Time {
+	first = NormalizeDateOnly(first)
+	dates := make([]time.Time, count)
+	for index := range dates {
+		month := BeginningOfMonth(first.Year(), int(first.Month())).AddDate(0, index, 0)
+		dates[index] = withClampedDay(month.Year(), month.Month(), first.Day())
+	}
+	return dates
+}
+
+func installmentChargeNote(note string, number, count int) string {
+	label := fmt.Sprintf("Installment %d/%d", number, count)
+	if note == "" {
+		return label
+	}
+	return fmt.Sprintf("%s (%s)", note, label)
+}
@@ -0,0 +1,68 @@
+package expenses
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitInstallmentAmount(t *testing.T) {
+	amounts := SplitInstallmentAmount(1000, 3)
+	want := []float64{333.34, 333.33, 333.33}
+	if len(amounts) != len(want) {
+		t.Fatalf("SplitInstallmentAmount returned %d parts, want %d", len(amounts), len(want))
+	}
+	var cents int64
+	for index := range want {
+		if amounts[index] != want[index] {
+			t.Fatalf("part %d = %.2f, want %.2f", index+1, amounts[index], want[index])
+		}
+		cents += int64(amounts[index]*100 + 0.5)
+	}
+	if cents != 100000 {
+		t.Fatalf("parts add up to %d cents, want 100000", cents)
+	}
+}
+
+func TestInstallmentChargeDates(t *testing.T) {
+	dates := InstallmentChargeDates(mustParseDate(t, "2025-01-31"), 4)
+	want := []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"}
+	for index := range want {
+		if got := dates[index].Format(DateOnlyLayout); got != want[index] {
+			t.Fatalf("charge %d = %s, want %s", index+1, got, want[index])
+		}
+	}
+}
+
+func TestInstallmentService_DeletePlanReopensSettledCycles(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	today := NormalizeDateOnly(time.Now().UTC())
+	expenseType := ExpenseType{Name: "Laptop", RecurringType: RecurringTypeFixedDay, RecurringPeriod: RecurringPeriodMonthly, RecurringDueDay: today.Day(), UserID: userID}
+	card := Wallet{Name: "Card", IsCredit: true, BillPeriod: WalletPeriodMonthly, UserID: userID}
+	require.NoError(t, db.Create(&expenseType).Error)
+	require.NoError(t, db.Create(&card).Error)
+
+	service := NewInstallmentService(db)
+	plan, err := service.CreateInstallmentPlan(userID, CreateInstallmentPlanRequest{
+		ExpenseTypeID:    expenseType.ID,
+		WalletID:         card.ID,
+		TotalAmount:      1200,
+		InstallmentCount: 3,
+		PurchaseDate:     today.Format(DateOnlyLayout),
+	})
+	require.NoError(t, err)
+	chargeIDs := make([]uint, 0, len(plan.Charges))
+	for _, charge := range plan.Charges {
+		chargeIDs = append(chargeIDs, charge.ID)
+	}
+	var settled int64
+	require.NoError(t, db.Model(&RecurrenceOccurrence{}).Where("expense_id IN ?", chargeIDs).Count(&settled).Error)
+	assert.NotZero(t, settled, "expected the first charge to settle a cycle")
+
+	require.NoError(t, service.DeleteInstallmentPlan(userID, plan.ID))
+	require.NoError(t, db.Model(&RecurrenceOccurrence{}).Where("expense_id IN ?", chargeIDs).Count(&settled).Error)
+	assert.Zero(t, settled)
+}
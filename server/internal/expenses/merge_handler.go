@@ -0,0 +1,108 @@
+package expenses
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MergeHandler struct {
+	service *MergeService
+}
+
+func NewMergeHandler(service *MergeService) *MergeHandler {
+	return &MergeHandler{service: service}
+}
+
+// PreviewWalletMerge handles GET /api/wallets/:id/merge-preview?target_id=
+func (h *MergeHandler) PreviewWalletMerge(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	sourceID, targetID, err := mergeIDsFromQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	preview, err := h.service.PreviewWalletMerge(userID, sourceID, targetID)
+	if err != nil {
+		return h.mergeError(c, err, "Failed to preview wallet merge")
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// MergeWallets handles POST /api/wallets/:id/merge
+func (h *MergeHandler) MergeWallets(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	var req MergeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	result, err := h.service.MergeWallets(userID, uint(sourceID), req.TargetID)
+	if err != nil {
+		return h.mergeError(c, err, "Failed to merge wallets")
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// PreviewExpenseTypeMerge handles GET /api/expense-types/:id/merge-preview?target_id=
+func (h *MergeHandler) PreviewExpenseTypeMerge(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	sourceID, targetID, err := mergeIDsFromQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	preview, err := h.service.PreviewExpenseTypeMerge(userID, sourceID, targetID)
+	if err != nil {
+		return h.mergeError(c, err, "Failed to preview expense type merge")
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// MergeExpenseTypes handles POST /api/expense-types/:id/merge
+func (h *MergeHandler) MergeExpenseTypes(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid expense type ID"})
+	}
+	var req MergeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	result, err := h.service.MergeExpenseTypes(userID, uint(sourceID), req.TargetID)
+	if err != nil {
+		return h.mergeError(c, err, "Failed to merge expense types")
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+func mergeIDsFromQuery(c echo.Context) (uint, uint, error) {
+	sourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid source ID")
+	}
+	targetID, err := strconv.ParseUint(c.QueryParam("target_id"), 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid target ID")
+	}
+	return uint(sourceID), uint(targetID), nil
+}
+
+func (h *MergeHandler) mergeError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case ErrWalletNotFound, ErrExpenseTypeNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case ErrMergeSelfTransfer:
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case ErrMergeSameRecord, ErrExpenseTypeCycleReference:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,279 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMergeSameRecord   = errors.New("source and target must be different")
+	ErrMergeSelfTransfer = errors.New("merging would turn transfers between these wallets into self transfers")
+)
+
+// MergeService folds a duplicate wallet or expense type into another one.
+type MergeService struct {
+	db                    *gorm.DB
+	walletReferences      []MergeReference
+	expenseTypeReferences []MergeReference
+	eventSource
+}
+
+type MergeRequest struct {
+	TargetID uint `json:"target_id"`
+}
+
+// MergePreview lists how many records of each kind point at the source and
+// would move to the target.
+type MergePreview struct {
+	SourceID uint             `json:"source_id"`
+	TargetID uint             `json:"target_id"`
+	Counts   map[string]int64 `json:"counts"`
+	Total    int64            `json:"total"`
+}
+
+// MergeReference is one column that can point at a merged record. Packages
+// that build on wallets and expense types add their own with
+// AddWalletReferences and AddExpenseTypeReferences.
+type MergeReference struct {
+	Name   string
+	Model  any
+	Column string
+}
+
+var walletMergeReferences = []MergeReference{
+	{Name: "expenses", Model: &Expense{}, Column: "wallet_id"},
+	{Name: "payments", Model: &Payment{}, Column: "wallet_id"},
+	{Name: "transfers_from", Model: &Transfer{}, Column: "from_wallet_id"},
+	{Name: "transfers_to", Model: &Transfer{}, Column: "to_wallet_id"},
+	{Name: "statements", Model: &Statement{}, Column: "wallet_id"},
+	{Name: "installment_plans", Model: &InstallmentPlan{}, Column: "wallet_id"},
+	{Name: "expense_type_defaults", Model: &ExpenseType{}, Column: "default_wallet_id"},
+}
+
+var expenseTypeMergeReferences = []MergeReference{
+	{Name: "expenses", Model: &Expense{}, Column: "expense_type_id"},
+	{Name: "installment_plans", Model: &InstallmentPlan{}, Column: "expense_type_id"},
+	{Name: "wallet_defaults", Model: &Wallet{}, Column: "default_expense_type_id"},
+	{Name: "children", Model: &ExpenseType{}, Column: "parent_id"},
+}
+
+func NewMergeService(db *gorm.DB) *MergeService {
+	return &MergeService{
+		db:                    db,
+		walletReferences:      append([]MergeReference(nil), walletMergeReferences...),
+		expenseTypeReferences: append([]MergeReference(nil), expenseTypeMergeReferences...),
+	}
+}
+
+// AddWalletReferences registers more columns to move when wallets merge.
+func (s *MergeService) AddWalletReferences(references ...MergeReference) {
+	s.walletReferences = append(s.walletReferences, references...)
+}
+
+// AddExpenseTypeReferences registers more columns to move when expense types
+// merge.
+func (s *MergeService) AddExpenseTypeReferences(references ...MergeReference) {
+	s.expenseTypeReferences = append(s.expenseTypeReferences, references...)
+}
+
+func (s *MergeService) PreviewWalletMerge(userID, sourceID, targetID uint) (*MergePreview, error) {
+	if err := s.validateWallets(userID, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	return s.preview(userID, sourceID, targetID, s.walletReferences)
+}
+
+// MergeWallets moves every reference from the source wallet to the target
+// and deletes the source in one transaction. The source's bill ledger is
+// dropped and the target's is re-synced over the moved payments.
+func (s *MergeService) MergeWallets(userID, sourceID, targetID uint) (*MergePreview, error) {
+	preview, err := s.PreviewWalletMerge(userID, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	var selfTransfers int64
+	if err := s.db.Model(&Transfer{}).
+		Where("user_id = ? AND ((from_wallet_id = ? AND to_wallet_id = ?) OR (from_wallet_id = ? AND to_wallet_id = ?))", userID, sourceID, targetID, targetID, sourceID).
+		Count(&selfTransfers).Error; err != nil {
+		return nil, fmt.Errorf("failed to check transfers: %w", err)
+	}
+	if selfTransfers > 0 {
+		return nil, ErrMergeSelfTransfer
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := moveReferences(tx, userID, sourceID, targetID, s.walletReferences); err != nil {
+			return err
+		}
+		if err := dropOccurrences(tx, userID, "wallet_id", sourceID); err != nil {
+			return err
+		}
+		if err := syncWalletOccurrences(tx, userID, targetID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND user_id = ?", sourceID, userID).Delete(&Wallet{}).Error; err != nil {
+			return fmt.Errorf("failed to delete source wallet: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publish(userID, EventWalletDeleted, DeletedEvent{ID: sourceID})
+	return preview, nil
+}
+
+func (s *MergeService) PreviewExpenseTypeMerge(userID, sourceID, targetID uint) (*MergePreview, error) {
+	if _, _, err := s.validateExpenseTypes(userID, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	preview, err := s.preview(userID, sourceID, targetID, s.expenseTypeReferences)
+	if err != nil {
+		return nil, err
+	}
+	var amountChanges int64
+	if err := movableAmountChanges(s.db, userID, sourceID, targetID).Count(&amountChanges).Error; err != nil {
+		return nil, fmt.Errorf("failed to count amount changes: %w", err)
+	}
+	preview.Counts["amount_changes"] = amountChanges
+	preview.Total += amountChanges
+	return preview, nil
+}
+
+// MergeExpenseTypes moves every reference from the source expense type to the
+// target and deletes the source in one transaction. Scheduled amount changes
+// move along unless the target has its own change on the same day. The
+// source's ledger is dropped and the target's is re-synced over the moved
+// expenses. When the target sits below the source it is first lifted to the
+// source's parent, so moving the source's children under it cannot create a
+// cycle.
+func (s *MergeService) MergeExpenseTypes(userID, sourceID, targetID uint) (*MergePreview, error) {
+	preview, err := s.PreviewExpenseTypeMerge(userID, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, _, err := s.validateExpenseTypes(userID, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	targetAncestors, err := expenseTypeAncestorIDs(s.db, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, ancestorID := range targetAncestors[1:] {
+			if ancestorID != sourceID {
+				continue
+			}
+			if err := tx.Model(&ExpenseType{}).Where("id = ? AND user_id = ?", targetID, userID).Update("parent_id", source.ParentID).Error; err != nil {
+				return fmt.Errorf("failed to reparent target expense type: %w", err)
+			}
+		}
+		if err := moveReferences(tx, userID, sourceID, targetID, s.expenseTypeReferences); err != nil {
+			return err
+		}
+		if err := movableAmountChanges(tx, userID, sourceID, targetID).Update("expense_type_id", targetID).Error; err != nil {
+			return fmt.Errorf("failed to move amount changes: %w", err)
+		}
+		if err := dropOccurrences(tx, userID, "expense_type_id", sourceID); err != nil {
+			return err
+		}
+		if err := syncExpenseTypeOccurrences(tx, userID, targetID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND user_id = ?", sourceID, userID).Delete(&ExpenseType{}).Error; err != nil {
+			return fmt.Errorf("failed to delete source expense type: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+func (s *MergeService) preview(userID, sourceID, targetID uint, references []MergeReference) (*MergePreview, error) {
+	preview := &MergePreview{
+		SourceID: sourceID,
+		TargetID: targetID,
+		Counts:   make(map[string]int64, len(references)),
+	}
+	for _, reference := range references {
+		var count int64
+		query := s.db.Model(reference.Model).Where("user_id = ? AND "+reference.Column+" = ?", userID, sourceID)
+		if reference.Column == "parent_id" {
+			query = query.Where("id <> ?", targetID)
+		}
+		if err := query.Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", reference.Name, err)
+		}
+		preview.Counts[reference.Name] = count
+		preview.Total += count
+	}
+	return preview, nil
+}
+
+func moveReferences(tx *gorm.DB, userID, sourceID, targetID uint, references []MergeReference) error {
+	for _, reference := range references {
+		if err := tx.Model(reference.Model).Where("user_id = ? AND "+reference.Column+" = ?", userID, sourceID).Update(reference.Column, targetID).Error; err != nil {
+			return fmt.Errorf("failed to move %s: %w", reference.Name, err)
+		}
+	}
+	return nil
+}
+
+// movableAmountChanges selects the source's scheduled amount changes that
+// carry over to the target. The target's own change wins when both have one
+// on the same day, and the source's past amounts stay with the source.
+func movableAmountChanges(db *gorm.DB, userID, sourceID, targetID uint) *gorm.DB {
+	today := NormalizeDateOnly(time.Now().UTC())
+	targetDates := db.Model(&ExpenseTypeAmount{}).Select("effective_from").Where("user_id = ? AND expense_type_id = ?", userID, targetID)
+	return db.Model(&ExpenseTypeAmount{}).
+		Where("user_id = ? AND expense_type_id = ? AND effective_from > ?", userID, sourceID, today).
+		Where("effective_from NOT IN (?)", targetDates)
+}
+
+// dropOccurrences deletes the ledger of a merged-away record. Its expenses or
+// payments now belong to the target, whose next sync links them to its own
+// cycles.
+func dropOccurrences(tx *gorm.DB, userID uint, column string, ownerID uint) error {
+	if err := tx.Where("user_id = ? AND "+column+" = ?", userID, ownerID).Delete(&RecurrenceOccurrence{}).Error; err != nil {
+		return fmt.Errorf("failed to delete source occurrences: %w", err)
+	}
+	return nil
+}
+
+func (s *MergeService) validateWallets(userID, sourceID, targetID uint) error {
+	if sourceID == targetID {
+		return ErrMergeSameRecord
+	}
+	var count int64
+	if err := s.db.Model(&Wallet{}).Where("user_id = ? AND id IN ?", userID, []uint{sourceID, targetID}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load wallets: %w", err)
+	}
+	if count != 2 {
+		return ErrWalletNotFound
+	}
+	return nil
+}
+
+func (s *MergeService) validateExpenseTypes(userID, sourceID, targetID uint) (*ExpenseType, *ExpenseType, error) {
+	if sourceID == targetID {
+		return nil, nil, ErrMergeSameRecord
+	}
+	var expenseTypes []ExpenseType
+	if err := s.db.Where("user_id = ? AND id IN ?", userID, []uint{sourceID, targetID}).Find(&expenseTypes).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load expense types: %w", err)
+	}
+	if len(expenseTypes) != 2 {
+		return nil, nil, ErrExpenseTypeNotFound
+	}
+	if expenseTypes[0].ID == sourceID {
+		return &expenseTypes[0], &expenseTypes[1], nil
+	}
+	return &expenseTypes[1], &expenseTypes[0], nil
+}
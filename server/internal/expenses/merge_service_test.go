@@ -0,0 +1,141 @@
+package expenses
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMergeService_MergeWallets(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	source := Wallet{Name: "Old card", IsCredit: true, BillPeriod: WalletPeriodMonthly, UserID: userID}
+	target := Wallet{Name: "Card", IsCredit: true, BillPeriod: WalletPeriodMonthly, UserID: userID}
+	require.NoError(t, db.Create(&source).Error)
+	require.NoError(t, db.Create(&target).Error)
+	expenseType := ExpenseType{Name: "Fuel", RecurringType: RecurringTypeNone, DefaultWalletID: &source.ID, UserID: userID}
+	require.NoError(t, db.Create(&expenseType).Error)
+	today := NormalizeDateOnly(time.Now().UTC())
+	for _, amount := range []float64{20, 30} {
+		require.NoError(t, db.Create(&Expense{ExpenseTypeID: expenseType.ID, WalletID: &source.ID, Amount: amount, Date: today, UserID: userID}).Error)
+	}
+	payment := Payment{WalletID: source.ID, Amount: 50, Date: today, UserID: userID}
+	require.NoError(t, db.Create(&payment).Error)
+	require.NoError(t, db.Create(&RecurrenceOccurrence{WalletID: &source.ID, DueDate: today, Status: OccurrenceStatusPaid, PaymentID: &payment.ID, UserID: userID}).Error)
+
+	service := NewMergeService(db)
+	preview, err := service.PreviewWalletMerge(userID, source.ID, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), preview.Counts["expenses"])
+	assert.Equal(t, int64(1), preview.Counts["payments"])
+	assert.Equal(t, int64(1), preview.Counts["expense_type_defaults"])
+	assert.Equal(t, int64(0), preview.Counts["statements"])
+	assert.Equal(t, int64(4), preview.Total)
+
+	merged, err := service.MergeWallets(userID, source.ID, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, preview.Total, merged.Total)
+
+	var moved int64
+	require.NoError(t, db.Model(&Expense{}).Where("wallet_id = ?", target.ID).Count(&moved).Error)
+	assert.Equal(t, int64(2), moved)
+	require.NoError(t, db.First(&payment, payment.ID).Error)
+	assert.Equal(t, target.ID, payment.WalletID)
+	require.NoError(t, db.First(&expenseType, expenseType.ID).Error)
+	assert.Equal(t, &target.ID, expenseType.DefaultWalletID)
+	assert.ErrorIs(t, db.First(&Wallet{}, source.ID).Error, gorm.ErrRecordNotFound)
+
+	var sourceOccurrences int64
+	require.NoError(t, db.Model(&RecurrenceOccurrence{}).Where("wallet_id = ?", source.ID).Count(&sourceOccurrences).Error)
+	assert.Zero(t, sourceOccurrences)
+	var settled RecurrenceOccurrence
+	require.NoError(t, db.Where("wallet_id = ? AND payment_id = ?", target.ID, payment.ID).First(&settled).Error)
+	assert.Equal(t, OccurrenceStatusPaid, settled.Status)
+}
+
+func TestMergeService_MergeWalletsRejectsSelfTransfers(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	bank := Wallet{Name: "Bank", UserID: userID}
+	cash := Wallet{Name: "Cash", IsCash: true, UserID: userID}
+	require.NoError(t, db.Create(&bank).Error)
+	require.NoError(t, db.Create(&cash).Error)
+	_, err := NewTransferService(db).CreateTransfer(userID, CreateTransferRequest{FromWalletID: bank.ID, ToWalletID: cash.ID, Amount: 100, Date: "2025-03-01"})
+	require.NoError(t, err)
+
+	_, err = NewMergeService(db).MergeWallets(userID, cash.ID, bank.ID)
+	assert.ErrorIs(t, err, ErrMergeSelfTransfer)
+}
+
+func TestMergeService_MergeExpenseTypeIntoDescendant(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	root := ExpenseType{Name: "Home", RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&root).Error)
+	source := ExpenseType{Name: "Utilities", ParentID: &root.ID, RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&source).Error)
+	child := ExpenseType{Name: "Energy", ParentID: &source.ID, RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&child).Error)
+	target := ExpenseType{Name: "Electricity", ParentID: &child.ID, RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&target).Error)
+	require.NoError(t, db.Create(&Expense{ExpenseTypeID: source.ID, Amount: 80, Date: mustParseDate(t, "2025-03-01"), UserID: userID}).Error)
+
+	service := NewMergeService(db)
+	preview, err := service.PreviewExpenseTypeMerge(userID, source.ID, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), preview.Counts["expenses"])
+	assert.Equal(t, int64(1), preview.Counts["children"])
+	assert.Equal(t, int64(0), preview.Counts["amount_changes"])
+	assert.Equal(t, int64(2), preview.Total)
+
+	_, err = service.MergeExpenseTypes(userID, source.ID, target.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, db.First(&target, target.ID).Error)
+	require.NoError(t, db.First(&child, child.ID).Error)
+	assert.Equal(t, &root.ID, target.ParentID, "the target takes the source's place in the tree")
+	assert.Equal(t, &target.ID, child.ParentID)
+	ancestors, err := expenseTypeAncestorIDs(db, userID, child.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{child.ID, target.ID, root.ID}, ancestors)
+	var moved int64
+	require.NoError(t, db.Model(&Expense{}).Where("expense_type_id = ?", target.ID).Count(&moved).Error)
+	assert.Equal(t, int64(1), moved)
+}
+
+func TestMergeService_MergeExpenseTypesCarriesAmountChanges(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	source := ExpenseType{Name: "Internet (old)", RecurringType: RecurringTypeNone, DefaultAmount: 40, UserID: userID}
+	target := ExpenseType{Name: "Internet", RecurringType: RecurringTypeNone, DefaultAmount: 45, UserID: userID}
+	require.NoError(t, db.Create(&source).Error)
+	require.NoError(t, db.Create(&target).Error)
+	today := NormalizeDateOnly(time.Now().UTC())
+	past := ExpenseTypeAmount{ExpenseTypeID: source.ID, Amount: 35, EffectiveFrom: today.AddDate(0, -2, 0), UserID: userID}
+	scheduled := ExpenseTypeAmount{ExpenseTypeID: source.ID, Amount: 50, EffectiveFrom: today.AddDate(0, 0, 10), UserID: userID}
+	conflicting := ExpenseTypeAmount{ExpenseTypeID: source.ID, Amount: 55, EffectiveFrom: today.AddDate(0, 0, 20), UserID: userID}
+	targetOwn := ExpenseTypeAmount{ExpenseTypeID: target.ID, Amount: 60, EffectiveFrom: today.AddDate(0, 0, 20), UserID: userID}
+	for _, amount := range []*ExpenseTypeAmount{&past, &scheduled, &conflicting, &targetOwn} {
+		require.NoError(t, db.Create(amount).Error)
+	}
+
+	service := NewMergeService(db)
+	preview, err := service.PreviewExpenseTypeMerge(userID, source.ID, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), preview.Counts["amount_changes"])
+
+	_, err = service.MergeExpenseTypes(userID, source.ID, target.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, db.First(&scheduled, scheduled.ID).Error)
+	assert.Equal(t, target.ID, scheduled.ExpenseTypeID)
+	require.NoError(t, db.First(&targetOwn, targetOwn.ID).Error)
+	assert.Equal(t, 60.0, targetOwn.Amount, "the target's own change wins on the same day")
+	require.NoError(t, db.First(&past, past.ID).Error)
+	assert.Equal(t, source.ID, past.ExpenseTypeID, "past amounts stay with the source")
+	require.NoError(t, db.First(&conflicting, conflicting.ID).Error)
+	assert.Equal(t, source.ID, conflicting.ExpenseTypeID)
+}
@@ -0,0 +1,43 @@
+package expenses
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OccurrenceStatusPending = "pending"
+	OccurrenceStatusPaid    = "paid"
+	OccurrenceStatusSkipped = "skipped"
+	OccurrenceStatusMissed  = "missed"
+)
+
+// RecurrenceOccurrence is one due cycle of a recurring expense type or a
+// credit wallet bill. Exactly one of ExpenseTypeID and WalletID is set; a
+// paid occurrence links the expense or payment that settled it.
+type RecurrenceOccurrence struct {
+	ID            uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	ExpenseTypeID *uint          `json:"expense_type_id" gorm:"type:bigint;index"`
+	WalletID      *uint          `json:"wallet_id" gorm:"type:bigint;index"`
+	DueDate       time.Time      `json:"due_date" gorm:"type:date;not null;index"`
+	Status        string         `json:"status" gorm:"type:varchar(20);not null;default:'pending';check:chk_occurrence_status,status IN ('pending','paid','skipped','missed')"`
+	ExpenseID     *uint          `json:"expense_id" gorm:"type:bigint;index"`
+	PaymentID     *uint          `json:"payment_id" gorm:"type:bigint;index"`
+	PaidOn        *time.Time     `json:"paid_on" gorm:"type:date"`
+	UserID        uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ExpenseType *ExpenseType `json:"expense_type,omitempty" gorm:"foreignKey:ExpenseTypeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Wallet      *Wallet      `json:"wallet,omitempty" gorm:"foreignKey:WalletID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Expense     *Expense     `json:"expense,omitempty" gorm:"foreignKey:ExpenseID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Payment     *Payment     `json:"payment,omitempty" gorm:"foreignKey:PaymentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+// PaidOnTime reports whether the occurrence was settled on or before its due
+// date.
+func (o RecurrenceOccurrence) PaidOnTime() bool {
+	return o.Status == OccurrenceStatusPaid && o.PaidOn != nil && !o.PaidOn.After(o.DueDate)
+}
@@ -0,0 +1,78 @@
+package expenses
+
+import (
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OccurrenceHandler struct {
+	service *OccurrenceService
+}
+
+func NewOccurrenceHandler(service *OccurrenceService) *OccurrenceHandler {
+	return &OccurrenceHandler{service: service}
+}
+
+func (h *OccurrenceHandler) ListExpenseTypeOccurrences(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	expenseTypeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid expense type ID"})
+	}
+	response, err := h.service.GetExpenseTypeOccurrences(userID, uint(expenseTypeID), occurrenceListRequest(c))
+	if err != nil {
+		return h.occurrenceError(c, err, "Failed to list occurrences")
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *OccurrenceHandler) ListWalletOccurrences(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	response, err := h.service.GetWalletOccurrences(userID, uint(walletID), occurrenceListRequest(c))
+	if err != nil {
+		return h.occurrenceError(c, err, "Failed to list occurrences")
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *OccurrenceHandler) SkipOccurrence(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	occurrenceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid occurrence ID"})
+	}
+	occurrence, err := h.service.SkipOccurrence(userID, uint(occurrenceID))
+	if err != nil {
+		return h.occurrenceError(c, err, "Failed to skip occurrence")
+	}
+	return c.JSON(http.StatusOK, occurrence)
+}
+
+func occurrenceListRequest(c echo.Context) OccurrenceListRequest {
+	var req OccurrenceListRequest
+	req.Status = c.QueryParam("status")
+	req.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	req.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	return req
+}
+
+func (h *OccurrenceHandler) occurrenceError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case ErrOccurrenceNotFound, ErrExpenseTypeNotFound, ErrWalletNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case ErrOccurrenceNotOpen:
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case ErrInvalidOccurrenceStatus:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,551 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Occurrences are backfilled at most this far into the past the first time a
+// schedule is synced, and one sync creates at most maxOccurrencesPerSync rows.
+const (
+	maxOccurrenceBackfillMonths = 24
+	maxOccurrencesPerSync       = 500
+)
+
+var (
+	ErrOccurrenceNotFound      = errors.New("occurrence not found")
+	ErrOccurrenceNotOpen       = errors.New("only pending or missed occurrences can be skipped")
+	ErrInvalidOccurrenceStatus = errors.New("invalid occurrence status filter")
+)
+
+// OccurrenceService keeps the ledger of due cycles for recurring expense
+// types and credit wallets. The ledger is synced lazily: each read and each
+// write that can settle a cycle brings it up to date.
+type OccurrenceService struct {
+	db *gorm.DB
+}
+
+type OccurrenceListRequest struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// OccurrenceStats summarizes a ledger. OnTimeRate is the percentage of paid
+// and missed cycles that were paid on or before the due date; it is nil
+// until at least one cycle has been paid or missed.
+type OccurrenceStats struct {
+	Pending    int64    `json:"pending"`
+	Paid       int64    `json:"paid"`
+	PaidOnTime int64    `json:"paid_on_time"`
+	Skipped    int64    `json:"skipped"`
+	Missed     int64    `json:"missed"`
+	OnTimeRate *float64 `json:"on_time_rate"`
+}
+
+type OccurrenceHistoryResponse struct {
+	Occurrences []RecurrenceOccurrence `json:"occurrences"`
+	Total       int64                  `json:"total"`
+	Stats       OccurrenceStats        `json:"stats"`
+}
+
+func NewOccurrenceService(db *gorm.DB) *OccurrenceService {
+	return &OccurrenceService{db: db}
+}
+
+// GetExpenseTypeOccurrences syncs and returns the ledger of an expense type,
+// newest first.
+func (s *OccurrenceService) GetExpenseTypeOccurrences(userID, expenseTypeID uint, req OccurrenceListRequest) (*OccurrenceHistoryResponse, error) {
+	var expenseType ExpenseType
+	if err := s.db.Where("id = ? AND user_id = ?", expenseTypeID, userID).First(&expenseType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExpenseTypeNotFound
+		}
+		return nil, fmt.Errorf("failed to get expense type: %w", err)
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return syncExpenseTypeOccurrences(tx, userID, expenseTypeID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.history(userID, "expense_type_id", expenseTypeID, req)
+}
+
+// GetWalletOccurrences syncs and returns the bill ledger of a credit wallet,
+// newest first.
+func (s *OccurrenceService) GetWalletOccurrences(userID, walletID uint, req OccurrenceListRequest) (*OccurrenceHistoryResponse, error) {
+	var wallet Wallet
+	if err := s.db.Where("id = ? AND user_id = ?", walletID, userID).First(&wallet).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return syncWalletOccurrences(tx, userID, walletID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.history(userID, "wallet_id", walletID, req)
+}
+
+// SkipOccurrence marks a cycle as intentionally not paid. Skipping the open
+// cycle of a flexible expense type moves its next due day past it.
+func (s *OccurrenceService) SkipOccurrence(userID, occurrenceID uint) (*RecurrenceOccurrence, error) {
+	var occurrence RecurrenceOccurrence
+	if err := s.db.Where("id = ? AND user_id = ?", occurrenceID, userID).First(&occurrence).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOccurrenceNotFound
+		}
+		return nil, fmt.Errorf("failed to get occurrence: %w", err)
+	}
+	if occurrence.Status != OccurrenceStatusPending && occurrence.Status != OccurrenceStatusMissed {
+		return nil, ErrOccurrenceNotOpen
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&occurrence).Update("status", OccurrenceStatusSkipped).Error; err != nil {
+			return fmt.Errorf("failed to skip occurrence: %w", err)
+		}
+		if occurrence.WalletID != nil {
+			return syncWalletOccurrences(tx, userID, *occurrence.WalletID)
+		}
+		if occurrence.ExpenseTypeID == nil {
+			return nil
+		}
+		var expenseType ExpenseType
+		if err := tx.Where("id = ? AND user_id = ?", *occurrence.ExpenseTypeID, userID).First(&expenseType).Error; err != nil {
+			return fmt.Errorf("failed to load expense type: %w", err)
+		}
+		if expenseType.RecurringType == RecurringTypeFlexible {
+			nextDueDay, err := AdvanceNextDueDayFrom(occurrence.DueDate, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&ExpenseType{}).Where("id = ? AND user_id = ?", expenseType.ID, userID).Update("next_due_day", nextDueDay).Error; err != nil {
+				return fmt.Errorf("failed to advance next due day: %w", err)
+			}
+		}
+		return syncExpenseTypeOccurrences(tx, userID, expenseType.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&occurrence, occurrence.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload occurrence: %w", err)
+	}
+	return &occurrence, nil
+}
+
+func (s *OccurrenceService) history(userID uint, column string, ownerID uint, req OccurrenceListRequest) (*OccurrenceHistoryResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 200 {
+		req.Limit = 200
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+	switch req.Status {
+	case "", OccurrenceStatusPending, OccurrenceStatusPaid, OccurrenceStatusSkipped, OccurrenceStatusMissed:
+	default:
+		return nil, ErrInvalidOccurrenceStatus
+	}
+
+	var all []RecurrenceOccurrence
+	if err := s.db.Where("user_id = ? AND "+column+" = ?", userID, ownerID).Find(&all).Error; err != nil {
+		return nil, fmt.Errorf("failed to load occurrences: %w", err)
+	}
+	response := &OccurrenceHistoryResponse{Stats: OccurrenceStatsFor(all)}
+
+	query := s.db.Model(&RecurrenceOccurrence{}).Where("user_id = ? AND "+column+" = ?", userID, ownerID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if err := query.Count(&response.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count occurrences: %w", err)
+	}
+	if err := query.Order("due_date DESC, id DESC").Limit(req.Limit).Offset(req.Offset).Find(&response.Occurrences).Error; err != nil {
+		return nil, fmt.Errorf("failed to list occurrences: %w", err)
+	}
+	return response, nil
+}
+
+// OccurrenceStatsFor counts occurrences by status and computes the on-time
+// payment rate.
+func OccurrenceStatsFor(occurrences []RecurrenceOccurrence) OccurrenceStats {
+	var stats OccurrenceStats
+	for _, occurrence := range occurrences {
+		switch occurrence.Status {
+		case OccurrenceStatusPending:
+			stats.Pending++
+		case OccurrenceStatusPaid:
+			stats.Paid++
+			if occurrence.PaidOnTime() {
+				stats.PaidOnTime++
+			}
+		case OccurrenceStatusSkipped:
+			stats.Skipped++
+		case OccurrenceStatusMissed:
+			stats.Missed++
+		}
+	}
+	if settled := stats.Paid + stats.Missed; settled > 0 {
+		rate := math.Round(float64(stats.PaidOnTime)/float64(settled)*1000) / 10
+		stats.OnTimeRate = &rate
+	}
+	return stats
+}
+
+// occurrenceSettlement is an expense or payment that can settle a cycle.
+type occurrenceSettlement struct {
+	ID   uint
+	Date time.Time
+}
+
+// occurrenceSchedule describes the cycles of one expense type or wallet.
+// first and next generate due dates; settledBefore returns the due date of
+// the cycle after the one a settlement on paidOn covers. A flexible schedule
+// has a single open cycle at flexibleDue instead of a generated series.
+type occurrenceSchedule struct {
+	column        string
+	ownerID       uint
+	isWallet      bool
+	active        bool
+	anchor        time.Time
+	flexibleDue   *time.Time
+	first         func(anchor time.Time) *time.Time
+	next          func(previous time.Time) *time.Time
+	settledBefore func(paidOn time.Time) *time.Time
+}
+
+func syncExpenseTypeOccurrences(tx *gorm.DB, userID, expenseTypeID uint) error {
+	var expenseType ExpenseType
+	if err := tx.Where("id = ? AND user_id = ?", expenseTypeID, userID).First(&expenseType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load expense type: %w", err)
+	}
+	today := NormalizeDateOnly(time.Now().UTC())
+	var settlements []occurrenceSettlement
+	if err := tx.Model(&Expense{}).Select("id, date").
+		Where("user_id = ? AND expense_type_id = ? AND date >= ?", userID, expenseTypeID, today.AddDate(0, -maxOccurrenceBackfillMonths, 0)).
+		Order("date ASC, id ASC").Scan(&settlements).Error; err != nil {
+		return fmt.Errorf("failed to load expenses: %w", err)
+	}
+
+	flexible := expenseType.RecurringType == RecurringTypeFlexible
+	schedule := occurrenceSchedule{
+		column:  "expense_type_id",
+		ownerID: expenseType.ID,
+		active:  expenseType.RecurringType != RecurringTypeNone && !expenseType.Stopped,
+		anchor:  occurrenceAnchor(expenseType.CreatedAt, settlements, today),
+		first: func(anchor time.Time) *time.Time {
+			due, err := NextExpenseTypeDueDate(expenseType, anchor, nil)
+			if err != nil {
+				return nil
+			}
+			return due
+		},
+		next: func(previous time.Time) *time.Time {
+			due, err := FollowingExpenseTypeDueDate(expenseType, previous)
+			if err != nil {
+				return nil
+			}
+			return due
+		},
+		settledBefore: func(paidOn time.Time) *time.Time {
+			if flexible {
+				due, err := AdvanceNextDueDayFrom(paidOn, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+				if err != nil {
+					return nil
+				}
+				return expenseType.adjustDueDate(due)
+			}
+			due, err := NextExpenseTypeDueDate(expenseType, paidOn, &paidOn)
+			if err != nil {
+				return nil
+			}
+			return due
+		},
+	}
+	if flexible {
+		schedule.flexibleDue = expenseType.adjustDueDate(expenseType.NextDueDay)
+	}
+	if err := syncOccurrences(tx, userID, schedule, settlements, today); err != nil {
+		return err
+	}
+	return applyExpenseTypeScheduleEnd(tx, userID, expenseType)
+}
+
+func syncWalletOccurrences(tx *gorm.DB, userID, walletID uint) error {
+	var wallet Wallet
+	if err := tx.Where("id = ? AND user_id = ?", walletID, userID).First(&wallet).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load wallet: %w", err)
+	}
+	today := NormalizeDateOnly(time.Now().UTC())
+	var settlements []occurrenceSettlement
+	if err := tx.Model(&Payment{}).Select("id, date").
+		Where("user_id = ? AND wallet_id = ? AND date >= ?", userID, walletID, today.AddDate(0, -maxOccurrenceBackfillMonths, 0)).
+		Order("date ASC, id ASC").Scan(&settlements).Error; err != nil {
+		return fmt.Errorf("failed to load payments: %w", err)
+	}
+
+	schedule := occurrenceSchedule{
+		column:   "wallet_id",
+		ownerID:  wallet.ID,
+		isWallet: true,
+		active:   wallet.IsCredit && wallet.BillPeriod != WalletPeriodNone && !wallet.Stopped,
+		anchor:   occurrenceAnchor(wallet.CreatedAt, settlements, today),
+		first: func(anchor time.Time) *time.Time {
+			return walletDueWithinEnd(wallet, NextWalletDueDate(wallet, anchor, nil))
+		},
+		next: func(previous time.Time) *time.Time {
+			return walletDueWithinEnd(wallet, FollowingWalletDueDate(wallet, previous))
+		},
+		settledBefore: func(paidOn time.Time) *time.Time {
+			return walletDueWithinEnd(wallet, nextWalletDueDateAfterPayment(wallet, paidOn, paidOn))
+		},
+	}
+	if err := syncOccurrences(tx, userID, schedule, settlements, today); err != nil {
+		return err
+	}
+	return applyWalletScheduleEnd(tx, userID, wallet)
+}
+
+func walletDueWithinEnd(wallet Wallet, due time.Time) *time.Time {
+	if wallet.EndsBefore(due) {
+		return nil
+	}
+	return &due
+}
+
+// occurrenceAnchor is where a new ledger starts: the earlier of the record's
+// creation and its first settlement, but no earlier than the backfill limit.
+func occurrenceAnchor(createdAt time.Time, settlements []occurrenceSettlement, today time.Time) time.Time {
+	anchor := NormalizeDateOnly(createdAt)
+	if createdAt.IsZero() || anchor.After(today) {
+		anchor = today
+	}
+	if len(settlements) > 0 && settlements[0].Date.Before(anchor) {
+		anchor = NormalizeDateOnly(settlements[0].Date)
+	}
+	if limit := today.AddDate(0, -maxOccurrenceBackfillMonths, 0); anchor.Before(limit) {
+		anchor = limit
+	}
+	return anchor
+}
+
+// syncOccurrences brings a ledger up to date: it creates cycles up to the
+// first one after today, links unlinked settlements to the cycles they pay,
+// and marks cycles missed once a later cycle has come due unpaid.
+func syncOccurrences(tx *gorm.DB, userID uint, schedule occurrenceSchedule, settlements []occurrenceSettlement, today time.Time) error {
+	var occurrences []RecurrenceOccurrence
+	if err := tx.Where("user_id = ? AND "+schedule.column+" = ?", userID, schedule.ownerID).Order("due_date ASC, id ASC").Find(&occurrences).Error; err != nil {
+		return fmt.Errorf("failed to load occurrences: %w", err)
+	}
+
+	occurrences, err := generateOccurrences(tx, userID, schedule, occurrences, today)
+	if err != nil {
+		return err
+	}
+
+	linked := make(map[uint]bool, len(occurrences))
+	for _, occurrence := range occurrences {
+		if id := occurrence.settlementID(schedule.isWallet); id != nil {
+			linked[*id] = true
+		}
+	}
+	for _, settlement := range settlements {
+		if linked[settlement.ID] {
+			continue
+		}
+		paidOn := NormalizeDateOnly(settlement.Date)
+		index := settledOccurrence(occurrences, schedule.settledBefore(settlement.Date), paidOn)
+		if index < 0 {
+			continue
+		}
+		occurrence := &occurrences[index]
+		occurrence.Status = OccurrenceStatusPaid
+		occurrence.PaidOn = &paidOn
+		settlementID := settlement.ID
+		if schedule.isWallet {
+			occurrence.PaymentID = &settlementID
+		} else {
+			occurrence.ExpenseID = &settlementID
+		}
+		if err := tx.Save(occurrence).Error; err != nil {
+			return fmt.Errorf("failed to mark occurrence paid: %w", err)
+		}
+		linked[settlement.ID] = true
+	}
+
+	occurrences, err = generateOccurrences(tx, userID, schedule, occurrences, today)
+	if err != nil {
+		return err
+	}
+
+	if schedule.flexibleDue != nil {
+		return nil
+	}
+	var latestDue *time.Time
+	for index := range occurrences {
+		if !occurrences[index].DueDate.After(today) {
+			latestDue = &occurrences[index].DueDate
+		}
+	}
+	if latestDue == nil {
+		return nil
+	}
+	for index := range occurrences {
+		occurrence := &occurrences[index]
+		if occurrence.Status != OccurrenceStatusPending || !occurrence.DueDate.Before(*latestDue) {
+			continue
+		}
+		occurrence.Status = OccurrenceStatusMissed
+		if err := tx.Model(occurrence).Update("status", OccurrenceStatusMissed).Error; err != nil {
+			return fmt.Errorf("failed to mark occurrence missed: %w", err)
+		}
+	}
+	return nil
+}
+
+// settledOccurrence returns the index of the cycle a settlement on paidOn
+// pays, or -1 if none is open. The latest pending cycle due before end, the
+// next cycle the settlement leads to, is the one it pays and earlier open
+// cycles stay unpaid. A late settlement pays a missed cycle instead when that
+// is the latest cycle to have come due before it.
+func settledOccurrence(occurrences []RecurrenceOccurrence, end *time.Time, paidOn time.Time) int {
+	pending, overdue := -1, -1
+	for candidate, occurrence := range occurrences {
+		if end != nil && !occurrence.DueDate.Before(*end) {
+			continue
+		}
+		if occurrence.DueDate.Before(paidOn) && (occurrence.Status == OccurrenceStatusPending || occurrence.Status == OccurrenceStatusMissed) {
+			overdue = candidate
+		}
+		if occurrence.Status == OccurrenceStatusPending {
+			pending = candidate
+		}
+	}
+	if overdue >= 0 && occurrences[overdue].Status == OccurrenceStatusMissed {
+		return overdue
+	}
+	return pending
+}
+
+func generateOccurrences(tx *gorm.DB, userID uint, schedule occurrenceSchedule, occurrences []RecurrenceOccurrence, today time.Time) ([]RecurrenceOccurrence, error) {
+	if !schedule.active {
+		return occurrences, nil
+	}
+	create := func(due time.Time) error {
+		occurrence := RecurrenceOccurrence{DueDate: NormalizeDateOnly(due), Status: OccurrenceStatusPending, UserID: userID}
+		ownerID := schedule.ownerID
+		if schedule.isWallet {
+			occurrence.WalletID = &ownerID
+		} else {
+			occurrence.ExpenseTypeID = &ownerID
+		}
+		if err := tx.Create(&occurrence).Error; err != nil {
+			return fmt.Errorf("failed to create occurrence: %w", err)
+		}
+		occurrences = append(occurrences, occurrence)
+		return nil
+	}
+
+	if schedule.flexibleDue != nil {
+		for _, occurrence := range occurrences {
+			if occurrence.Status == OccurrenceStatusPending || occurrence.DueDate.Equal(*schedule.flexibleDue) {
+				return occurrences, nil
+			}
+		}
+		if err := create(*schedule.flexibleDue); err != nil {
+			return nil, err
+		}
+		sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].DueDate.Before(occurrences[j].DueDate) })
+		return occurrences, nil
+	}
+
+	var due *time.Time
+	if len(occurrences) > 0 {
+		last := occurrences[len(occurrences)-1].DueDate
+		if last.After(today) {
+			return occurrences, nil
+		}
+		due = schedule.next(last)
+	} else {
+		due = schedule.first(schedule.anchor)
+	}
+	for created := 0; due != nil && created < maxOccurrencesPerSync; created++ {
+		if len(occurrences) > 0 && !due.After(occurrences[len(occurrences)-1].DueDate) {
+			break
+		}
+		if err := create(*due); err != nil {
+			return nil, err
+		}
+		if due.After(today) {
+			break
+		}
+		due = schedule.next(*due)
+	}
+	return occurrences, nil
+}
+
+func (o RecurrenceOccurrence) settlementID(isWallet bool) *uint {
+	if isWallet {
+		return o.PaymentID
+	}
+	return o.ExpenseID
+}
+
+// releaseOccurrences reopens the cycles settled by a deleted or edited
+// expense or payment so the next sync can match them again.
+func releaseOccurrences(tx *gorm.DB, userID uint, column string, settlementID uint) error {
+	if err := tx.Model(&RecurrenceOccurrence{}).
+		Where("user_id = ? AND "+column+" = ?", userID, settlementID).
+		Updates(map[string]any{"status": OccurrenceStatusPending, column: nil, "paid_on": nil}).Error; err != nil {
+		return fmt.Errorf("failed to reopen occurrences: %w", err)
+	}
+	return nil
+}
+
+// clearUpcomingOccurrences drops open cycles after today so they are
+// regenerated from a changed schedule.
+func clearUpcomingOccurrences(tx *gorm.DB, userID uint, column string, ownerID uint) error {
+	today := NormalizeDateOnly(time.Now().UTC())
+	if err := tx.Where("user_id = ? AND "+column+" = ? AND status = ? AND due_date > ?", userID, ownerID, OccurrenceStatusPending, today).
+		Delete(&RecurrenceOccurrence{}).Error; err != nil {
+		return fmt.Errorf("failed to clear upcoming occurrences: %w", err)
+	}
+	return nil
+}
+
+// moveFlexibleOccurrence moves the open cycle of a flexible expense type to
+// its current next due day, e.g. after it is postponed.
+func moveFlexibleOccurrence(tx *gorm.DB, userID uint, expenseType ExpenseType) error {
+	if expenseType.RecurringType != RecurringTypeFlexible {
+		return nil
+	}
+	due := expenseType.adjustDueDate(expenseType.NextDueDay)
+	if due == nil {
+		return nil
+	}
+	if err := tx.Model(&RecurrenceOccurrence{}).
+		Where("user_id = ? AND expense_type_id = ? AND status = ?", userID, expenseType.ID, OccurrenceStatusPending).
+		Update("due_date", *due).Error; err != nil {
+		return fmt.Errorf("failed to move pending occurrence: %w", err)
+	}
+	return nil
+}
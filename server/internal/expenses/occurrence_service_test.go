@@ -0,0 +1,144 @@
+package expenses
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOccurrenceStatsFor(t *testing.T) {
+	due := mustParseDate(t, "2025-03-15")
+	early := mustParseDate(t, "2025-03-14")
+	late := mustParseDate(t, "2025-03-20")
+
+	tests := []struct {
+		name        string
+		occurrences []RecurrenceOccurrence
+		want        OccurrenceStats
+		wantRate    *float64
+	}{
+		{
+			name: "no settled cycles",
+			occurrences: []RecurrenceOccurrence{
+				{DueDate: due, Status: OccurrenceStatusPending},
+				{DueDate: due, Status: OccurrenceStatusSkipped},
+			},
+			want: OccurrenceStats{Pending: 1, Skipped: 1},
+		},
+		{
+			name: "on time, late and missed",
+			occurrences: []RecurrenceOccurrence{
+				{DueDate: due, Status: OccurrenceStatusPaid, PaidOn: &early},
+				{DueDate: due, Status: OccurrenceStatusPaid, PaidOn: &due},
+				{DueDate: due, Status: OccurrenceStatusPaid, PaidOn: &late},
+				{DueDate: due, Status: OccurrenceStatusMissed},
+				{DueDate: due, Status: OccurrenceStatusSkipped},
+				{DueDate: due, Status: OccurrenceStatusMissed},
+			},
+			want:     OccurrenceStats{Paid: 3, PaidOnTime: 2, Skipped: 1, Missed: 2},
+			wantRate: floatPtr(40),
+		},
+		{
+			name: "rounds to one decimal",
+			occurrences: []RecurrenceOccurrence{
+				{DueDate: due, Status: OccurrenceStatusPaid, PaidOn: &early},
+				{DueDate: due, Status: OccurrenceStatusPaid, PaidOn: &late},
+				{DueDate: due, Status: OccurrenceStatusMissed},
+			},
+			want:     OccurrenceStats{Paid: 2, PaidOnTime: 1, Missed: 1},
+			wantRate: floatPtr(33.3),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := OccurrenceStatsFor(test.occurrences)
+			rate := got.OnTimeRate
+			got.OnTimeRate = nil
+			if got != test.want {
+				t.Fatalf("expected %+v, got %+v", test.want, got)
+			}
+			if (rate == nil) != (test.wantRate == nil) || (rate != nil && *rate != *test.wantRate) {
+				t.Fatalf("expected rate %v, got %v", test.wantRate, rate)
+			}
+		})
+	}
+}
+
+func TestOccurrenceAnchor(t *testing.T) {
+	today := mustParseDate(t, "2025-06-10")
+
+	tests := []struct {
+		name        string
+		createdAt   time.Time
+		settlements []occurrenceSettlement
+		want        string
+	}{
+		{
+			name:      "created date",
+			createdAt: time.Date(2025, 2, 3, 14, 30, 0, 0, time.UTC),
+			want:      "2025-02-03",
+		},
+		{
+			name:        "earlier settlement",
+			createdAt:   mustParseDate(t, "2025-02-03"),
+			settlements: []occurrenceSettlement{{ID: 1, Date: mustParseDate(t, "2024-11-20")}},
+			want:        "2024-11-20",
+		},
+		{
+			name:      "backfill limit",
+			createdAt: mustParseDate(t, "2020-01-01"),
+			want:      "2023-06-10",
+		},
+		{
+			name: "missing created date",
+			want: "2025-06-10",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := occurrenceAnchor(test.createdAt, test.settlements, today)
+			if got.Format(DateOnlyLayout) != test.want {
+				t.Fatalf("expected %s, got %s", test.want, got.Format(DateOnlyLayout))
+			}
+		})
+	}
+}
+
+func TestSettledOccurrence(t *testing.T) {
+	ledger := func(januaryStatus string) []RecurrenceOccurrence {
+		return []RecurrenceOccurrence{
+			{DueDate: mustParseDate(t, "2025-01-28"), Status: januaryStatus},
+			{DueDate: mustParseDate(t, "2025-02-28"), Status: OccurrenceStatusPending},
+		}
+	}
+	end := mustParseDate(t, "2025-03-28")
+
+	tests := []struct {
+		name          string
+		januaryStatus string
+		paidOn        string
+		want          int
+	}{
+		{name: "upcoming cycle", januaryStatus: OccurrenceStatusPaid, paidOn: "2025-02-10", want: 1},
+		{name: "late payment of a missed cycle", januaryStatus: OccurrenceStatusMissed, paidOn: "2025-02-10", want: 0},
+		{name: "missed cycle not yet due", januaryStatus: OccurrenceStatusMissed, paidOn: "2025-01-20", want: 1},
+		{name: "skipped cycle", januaryStatus: OccurrenceStatusSkipped, paidOn: "2025-02-10", want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := settledOccurrence(ledger(test.januaryStatus), &end, mustParseDate(t, test.paidOn)); got != test.want {
+				t.Fatalf("expected cycle %d, got %d", test.want, got)
+			}
+		})
+	}
+
+	paid := []RecurrenceOccurrence{{DueDate: mustParseDate(t, "2025-01-28"), Status: OccurrenceStatusPaid}}
+	if got := settledOccurrence(paid, &end, mustParseDate(t, "2025-02-10")); got != -1 {
+		t.Fatalf("expected no open cycle, got %d", got)
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
@@ -135,11 +135,51 @@ func (h *PaymentHandler) GetMonthlyTotal(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{"from": from.Format(DateOnlyLayout), "to": to.Format(DateOnlyLayout), "total": total})
 }
 
+func (h *PaymentHandler) GetPaymentSuggestions(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid payment ID"})
+	}
+	var req PaymentSuggestionRequest
+	req.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	if value := c.QueryParam("tolerance"); value != "" {
+		req.Tolerance, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tolerance"})
+		}
+	}
+	response, err := h.service.SuggestPaymentExpenses(userID, uint(paymentID), req)
+	if err != nil {
+		return h.paymentError(c, err, "Failed to suggest expenses")
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *PaymentHandler) AcceptPaymentSuggestion(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid payment ID"})
+	}
+	var req AcceptPaymentSuggestionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	payment, err := h.service.AcceptPaymentSuggestion(userID, uint(paymentID), req)
+	if err != nil {
+		return h.paymentError(c, err, "Failed to accept suggestion")
+	}
+	return c.JSON(http.StatusOK, payment)
+}
+
 func (h *PaymentHandler) paymentError(c echo.Context, err error, fallback string) error {
 	switch err {
 	case ErrPaymentNotFound, ErrWalletNotFound, ErrExpenseNotFound:
 		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
-	case ErrInvalidPaymentAmount, ErrInvalidPaymentDate:
+	case ErrExpenseAlreadyLinked, ErrPaymentIsTransfer:
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case ErrInvalidPaymentAmount, ErrInvalidPaymentDate, ErrInvalidSuggestionTolerance, ErrSuggestionExpenses:
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	default:
 		if err != nil {
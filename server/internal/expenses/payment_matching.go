@@ -0,0 +1,252 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Limits for the subset-sum search behind payment suggestions. The search
+// only looks at the candidates closest to the payment date and gives up after
+// visiting maxSuggestionNodes combinations, so a busy wallet still answers fast.
+const (
+	maxSuggestionCandidates = 40
+	maxSuggestionExpenses   = 20
+	maxSuggestionNodes      = 200000
+	defaultSuggestionLimit  = 5
+	maxSuggestionLimit      = 20
+)
+
+var (
+	ErrInvalidSuggestionTolerance = errors.New("tolerance must not be negative")
+	ErrSuggestionExpenses         = errors.New("expense ids are required to accept a suggestion")
+	ErrExpenseAlreadyLinked       = errors.New("some expenses are already linked to a payment")
+)
+
+type PaymentSuggestionRequest struct {
+	Tolerance float64
+	Limit     int
+}
+
+// PaymentSuggestion is one combination of unlinked expenses whose total is
+// within tolerance of the payment amount. AverageDayDistance is the mean
+// number of days between the expenses and the payment date.
+type PaymentSuggestion struct {
+	ExpenseIDs         []uint    `json:"expense_ids"`
+	Expenses           []Expense `json:"expenses"`
+	Total              float64   `json:"total"`
+	Difference         float64   `json:"difference"`
+	AverageDayDistance float64   `json:"average_day_distance"`
+}
+
+type PaymentSuggestionResponse struct {
+	PaymentID   uint                `json:"payment_id"`
+	Amount      float64             `json:"amount"`
+	WindowStart string              `json:"window_start"`
+	WindowEnd   string              `json:"window_end"`
+	Suggestions []PaymentSuggestion `json:"suggestions"`
+}
+
+type AcceptPaymentSuggestionRequest struct {
+	ExpenseIDs []uint `json:"expense_ids"`
+}
+
+// SuggestPaymentExpenses proposes combinations of unlinked expenses on the
+// payment's wallet that add up to the payment amount. Only expenses inside
+// the billing window ending on the payment date are considered.
+func (s *PaymentService) SuggestPaymentExpenses(userID, paymentID uint, req PaymentSuggestionRequest) (*PaymentSuggestionResponse, error) {
+	if req.Tolerance < 0 {
+		return nil, ErrInvalidSuggestionTolerance
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultSuggestionLimit
+	}
+	if req.Limit > maxSuggestionLimit {
+		req.Limit = maxSuggestionLimit
+	}
+
+	var payment Payment
+	if err := s.db.Preload("Wallet").Where("id = ? AND user_id = ?", paymentID, userID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	windowStart, windowEnd := paymentBillingWindow(payment.Wallet, payment.Date)
+	var candidates []Expense
+	if err := s.db.Preload("ExpenseType").
+		Where("user_id = ? AND wallet_id = ? AND payment_id IS NULL AND amount > 0 AND date >= ? AND date <= ?", userID, payment.WalletID, windowStart, windowEnd).
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load candidate expenses: %w", err)
+	}
+
+	return &PaymentSuggestionResponse{
+		PaymentID:   payment.ID,
+		Amount:      payment.Amount,
+		WindowStart: windowStart.Format(DateOnlyLayout),
+		WindowEnd:   windowEnd.Format(DateOnlyLayout),
+		Suggestions: FindExpenseCombinations(candidates, payment.Amount, req.Tolerance, payment.Date, req.Limit),
+	}, nil
+}
+
+// AcceptPaymentSuggestion links the given expenses to the payment, keeping
+// any expenses that are already linked.
+func (s *PaymentService) AcceptPaymentSuggestion(userID, paymentID uint, req AcceptPaymentSuggestionRequest) (*Payment, error) {
+	if len(req.ExpenseIDs) == 0 {
+		return nil, ErrSuggestionExpenses
+	}
+	var payment Payment
+	if err := s.db.Where("id = ? AND user_id = ?", paymentID, userID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	var linked int64
+	if err := s.db.Model(&Expense{}).Where("user_id = ? AND id IN ? AND payment_id IS NOT NULL", userID, req.ExpenseIDs).Count(&linked).Error; err != nil {
+		return nil, fmt.Errorf("failed to check expenses: %w", err)
+	}
+	if linked > 0 {
+		return nil, ErrExpenseAlreadyLinked
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.replacePaymentExpenses(tx, userID, payment.ID, payment.WalletID, uniqueIDs(req.ExpenseIDs))
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.GetPayment(userID, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(userID, EventPaymentUpdated, updated)
+	return updated, nil
+}
+
+// paymentBillingWindow returns the range of expense dates a payment can
+// settle: one bill period plus one month of slack before the payment date.
+func paymentBillingWindow(wallet Wallet, paymentDate time.Time) (time.Time, time.Time) {
+	end := NormalizeDateOnly(paymentDate)
+	months := PeriodMonths(wallet.BillPeriod)
+	if months == 0 {
+		months = 1
+	}
+	return end.AddDate(0, -(months + 1), 0), end
+}
+
+// FindExpenseCombinations runs a bounded subset-sum search in cents over the
+// candidates and returns up to limit combinations within tolerance of amount.
+// Results are ranked by how close the total is, then by how close the
+// expenses are to reference.
+func FindExpenseCombinations(candidates []Expense, amount, tolerance float64, reference time.Time, limit int) []PaymentSuggestion {
+	suggestions := make([]PaymentSuggestion, 0)
+	target := toCents(amount)
+	slack := toCents(tolerance)
+	if target <= 0 || len(candidates) == 0 || limit <= 0 {
+		return suggestions
+	}
+	reference = NormalizeDateOnly(reference)
+
+	pool := make([]Expense, 0, len(candidates))
+	for _, expense := range candidates {
+		if toCents(expense.Amount) > 0 && toCents(expense.Amount) <= target+slack {
+			pool = append(pool, expense)
+		}
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		return dayDistance(pool[i].Date, reference) < dayDistance(pool[j].Date, reference)
+	})
+	if len(pool) > maxSuggestionCandidates {
+		pool = pool[:maxSuggestionCandidates]
+	}
+	// Larger amounts first prune the search sooner.
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].Amount > pool[j].Amount
+	})
+
+	cents := make([]int64, len(pool))
+	remaining := make([]int64, len(pool)+1)
+	for index := len(pool) - 1; index >= 0; index-- {
+		cents[index] = toCents(pool[index].Amount)
+		remaining[index] = remaining[index+1] + cents[index]
+	}
+
+	var matches [][]int
+	nodes := 0
+	picked := make([]int, 0, maxSuggestionExpenses)
+	var search func(start int, sum int64)
+	search = func(start int, sum int64) {
+		nodes++
+		if nodes > maxSuggestionNodes {
+			return
+		}
+		if len(picked) > 0 && sum >= target-slack && sum <= target+slack {
+			matches = append(matches, append([]int(nil), picked...))
+		}
+		if len(picked) == maxSuggestionExpenses {
+			return
+		}
+		for index := start; index < len(pool); index++ {
+			if sum+remaining[index] < target-slack {
+				return
+			}
+			if sum+cents[index] > target+slack {
+				continue
+			}
+			picked = append(picked, index)
+			search(index+1, sum+cents[index])
+			picked = picked[:len(picked)-1]
+			if nodes > maxSuggestionNodes {
+				return
+			}
+		}
+	}
+	search(0, 0)
+
+	for _, match := range matches {
+		suggestion := PaymentSuggestion{
+			ExpenseIDs: make([]uint, 0, len(match)),
+			Expenses:   make([]Expense, 0, len(match)),
+		}
+		var total int64
+		var distance int
+		for _, index := range match {
+			expense := pool[index]
+			suggestion.ExpenseIDs = append(suggestion.ExpenseIDs, expense.ID)
+			suggestion.Expenses = append(suggestion.Expenses, expense)
+			total += cents[index]
+			distance += dayDistance(expense.Date, reference)
+		}
+		suggestion.Total = float64(total) / 100
+		suggestion.Difference = float64(target-total) / 100
+		suggestion.AverageDayDistance = math.Round(float64(distance)/float64(len(match))*10) / 10
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		left, right := math.Abs(suggestions[i].Difference), math.Abs(suggestions[j].Difference)
+		if left != right {
+			return left < right
+		}
+		if suggestions[i].AverageDayDistance != suggestions[j].AverageDayDistance {
+			return suggestions[i].AverageDayDistance < suggestions[j].AverageDayDistance
+		}
+		return len(suggestions[i].ExpenseIDs) < len(suggestions[j].ExpenseIDs)
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+func dayDistance(left, right time.Time) int {
+	days := int(NormalizeDateOnly(left).Sub(NormalizeDateOnly(right)).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
@@ -0,0 +1,51 @@
+package expenses
+
+import "testing"
+
+func TestFindExpenseCombinations(t *testing.T) {
+	reference := mustParseDate(t, "2025-03-25")
+	candidates := []Expense{
+		{ID: 1, Amount: 120.50, Date: mustParseDate(t, "2025-03-01")},
+		{ID: 2, Amount: 79.50, Date: mustParseDate(t, "2025-03-20")},
+		{ID: 3, Amount: 200, Date: mustParseDate(t, "2025-02-01")},
+		{ID: 4, Amount: 50, Date: mustParseDate(t, "2025-03-22")},
+		{ID: 5, Amount: 150, Date: mustParseDate(t, "2025-03-24")},
+	}
+
+	suggestions := FindExpenseCombinations(candidates, 200, 0, reference, 5)
+	if len(suggestions) != 3 {
+		t.Fatalf("got %d suggestions, want 3", len(suggestions))
+	}
+	first := suggestions[0]
+	if len(first.ExpenseIDs) != 2 || first.ExpenseIDs[0] != 5 || first.ExpenseIDs[1] != 4 {
+		t.Fatalf("closest suggestion = %v, want [5 4]", first.ExpenseIDs)
+	}
+	last := suggestions[len(suggestions)-1]
+	if len(last.ExpenseIDs) != 1 || last.ExpenseIDs[0] != 3 {
+		t.Fatalf("furthest suggestion = %v, want [3]", last.ExpenseIDs)
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.Difference != 0 || suggestion.Total != 200 {
+			t.Fatalf("suggestion %v totals %.2f, want exact match", suggestion.ExpenseIDs, suggestion.Total)
+		}
+	}
+}
+
+func TestFindExpenseCombinationsTolerance(t *testing.T) {
+	reference := mustParseDate(t, "2025-03-25")
+	candidates := []Expense{
+		{ID: 1, Amount: 99.90, Date: mustParseDate(t, "2025-03-10")},
+		{ID: 2, Amount: 100.20, Date: mustParseDate(t, "2025-03-24")},
+	}
+
+	if got := FindExpenseCombinations(candidates, 100, 0, reference, 5); len(got) != 0 {
+		t.Fatalf("got %d suggestions without tolerance, want 0", len(got))
+	}
+	got := FindExpenseCombinations(candidates, 100, 0.25, reference, 5)
+	if len(got) != 2 {
+		t.Fatalf("got %d suggestions with tolerance, want 2", len(got))
+	}
+	if got[0].ExpenseIDs[0] != 1 || got[0].Difference != 0.10 {
+		t.Fatalf("best suggestion = %v (difference %.2f), want [1] with 0.10", got[0].ExpenseIDs, got[0].Difference)
+	}
+}
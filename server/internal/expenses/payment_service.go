@@ -17,6 +17,7 @@ var (
 
 type PaymentService struct {
 	db *gorm.DB
+	eventSource
 }
 
 type CreatePaymentRequest struct {
@@ -60,6 +61,7 @@ func (s *PaymentService) CreatePayment(userID uint, req CreatePaymentRequest) (*
 	}
 
 	var payment Payment
+	var defaultExpense *Expense
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		payment = Payment{
 			WalletID: req.WalletID,
@@ -78,12 +80,13 @@ func (s *PaymentService) CreatePayment(userID uint, req CreatePaymentRequest) (*
 
 		autoCreateDefaultExpense := req.AutoCreateDefaultExpense == nil || *req.AutoCreateDefaultExpense
 		if len(req.ExpenseIDs) == 0 && autoCreateDefaultExpense {
-			if err := s.autoCreateDefaultExpense(tx, userID, wallet, payment); err != nil {
+			created, err := s.autoCreateDefaultExpense(tx, userID, wallet, payment)
+			if err != nil {
 				return err
 			}
+			defaultExpense = created
 		}
-
-		return nil
+		return syncWalletOccurrences(tx, userID, payment.WalletID)
 	})
 	if err != nil {
 		return nil, err
@@ -92,6 +95,12 @@ func (s *PaymentService) CreatePayment(userID uint, req CreatePaymentRequest) (*
 	if err := s.db.Preload("Wallet").First(&payment, payment.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to load payment: %w", err)
 	}
+	s.publish(userID, EventPaymentCreated, payment)
+	if defaultExpense != nil {
+		if err := s.db.Preload("ExpenseType").Preload("Wallet").Preload("Payment").First(defaultExpense, defaultExpense.ID).Error; err == nil {
+			s.publish(userID, EventExpenseCreated, *defaultExpense)
+		}
+	}
 	return &payment, nil
 }
 
@@ -121,7 +130,15 @@ func (s *PaymentService) UpdatePayment(userID, paymentID uint, req UpdatePayment
 	if err != nil {
 		return nil, err
 	}
+	isTransfer, err := s.isTransferPayment(userID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if isTransfer {
+		return nil, ErrPaymentIsTransfer
+	}
 
+	previousWalletID := payment.WalletID
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		payment.WalletID = req.WalletID
 		payment.Amount = req.Amount
@@ -133,7 +150,18 @@ func (s *PaymentService) UpdatePayment(userID, paymentID uint, req UpdatePayment
 		if err := tx.Model(&Expense{}).Where("user_id = ? AND payment_id = ?", userID, paymentID).Update("payment_id", nil).Error; err != nil {
 			return fmt.Errorf("failed to clear payment expenses: %w", err)
 		}
-		return s.replacePaymentExpenses(tx, userID, payment.ID, payment.WalletID, req.ExpenseIDs)
+		if err := s.replacePaymentExpenses(tx, userID, payment.ID, payment.WalletID, req.ExpenseIDs); err != nil {
+			return err
+		}
+		if err := releaseOccurrences(tx, userID, "payment_id", payment.ID); err != nil {
+			return err
+		}
+		if previousWalletID != payment.WalletID {
+			if err := syncWalletOccurrences(tx, userID, previousWalletID); err != nil {
+				return err
+			}
+		}
+		return syncWalletOccurrences(tx, userID, payment.WalletID)
 	})
 	if err != nil {
 		return nil, err
@@ -142,22 +170,43 @@ func (s *PaymentService) UpdatePayment(userID, paymentID uint, req UpdatePayment
 	if err := s.db.Preload("Wallet").First(payment, payment.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to reload payment: %w", err)
 	}
+	s.publish(userID, EventPaymentUpdated, payment)
 	return payment, nil
 }
 
 func (s *PaymentService) DeletePayment(userID, paymentID uint) error {
-	if _, err := s.GetPayment(userID, paymentID); err != nil {
+	payment, err := s.GetPayment(userID, paymentID)
+	if err != nil {
 		return err
 	}
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&Expense{}).Where("user_id = ? AND payment_id = ?", userID, paymentID).Update("payment_id", nil).Error; err != nil {
 			return fmt.Errorf("failed to unlink expenses: %w", err)
 		}
 		if err := tx.Where("id = ? AND user_id = ?", paymentID, userID).Delete(&Payment{}).Error; err != nil {
 			return fmt.Errorf("failed to delete payment: %w", err)
 		}
-		return nil
+		if err := tx.Where("payment_id = ? AND user_id = ?", paymentID, userID).Delete(&Transfer{}).Error; err != nil {
+			return fmt.Errorf("failed to delete payment transfer: %w", err)
+		}
+		if err := releaseOccurrences(tx, userID, "payment_id", paymentID); err != nil {
+			return err
+		}
+		return syncWalletOccurrences(tx, userID, payment.WalletID)
 	})
+	if err != nil {
+		return err
+	}
+	s.publish(userID, EventPaymentDeleted, DeletedEvent{ID: paymentID})
+	return nil
+}
+
+func (s *PaymentService) isTransferPayment(userID, paymentID uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&Transfer{}).Where("payment_id = ? AND user_id = ?", paymentID, userID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check payment transfer: %w", err)
+	}
+	return count > 0, nil
 }
 
 func (s *PaymentService) ListPayments(userID uint, req PaymentListRequest) (*PaymentListResponse, error) {
@@ -255,8 +304,11 @@ func (s *PaymentService) attachExpenseSummaries(userID uint, payments []Payment)
 func (s *PaymentService) GetMonthlyTotal(userID uint, from, to time.Time) (float64, error) {
 	from = NormalizeDateOnly(from)
 	to = NormalizeDateOnly(to)
+	// Transfer payments only move money between wallets, so they are left
+	// out of spending the same way reports leave them out.
+	transferPayments := s.db.Model(&Transfer{}).Select("payment_id").Where("user_id = ?", userID)
 	var total float64
-	if err := s.db.Model(&Payment{}).Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).Select("COALESCE(SUM(amount), 0)").Scan(&total).Error; err != nil {
+	if err := s.db.Model(&Payment{}).Where("user_id = ? AND date >= ? AND date <= ? AND id NOT IN (?)", userID, from, to, transferPayments).Select("COALESCE(SUM(amount), 0)").Scan(&total).Error; err != nil {
 		return 0, fmt.Errorf("failed to calculate payment total: %w", err)
 	}
 	return total, nil
@@ -312,19 +364,19 @@ func (s *PaymentService) replacePaymentExpenses(tx *gorm.DB, userID, paymentID,
 	return nil
 }
 
-func (s *PaymentService) autoCreateDefaultExpense(tx *gorm.DB, userID uint, wallet *Wallet, payment Payment) error {
+func (s *PaymentService) autoCreateDefaultExpense(tx *gorm.DB, userID uint, wallet *Wallet, payment Payment) (*Expense, error) {
 	if wallet == nil || wallet.DefaultExpenseTypeID == nil {
-		return nil
+		return nil, nil
 	}
 	if wallet.IsCash {
-		return nil
+		return nil, nil
 	}
 	var expenseType ExpenseType
 	if err := tx.Where("id = ? AND user_id = ?", *wallet.DefaultExpenseTypeID, userID).First(&expenseType).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil
+			return nil, nil
 		}
-		return fmt.Errorf("failed to load default expense type: %w", err)
+		return nil, fmt.Errorf("failed to load default expense type: %w", err)
 	}
 	expense := Expense{
 		ExpenseTypeID: expenseType.ID,
@@ -336,16 +388,19 @@ func (s *PaymentService) autoCreateDefaultExpense(tx *gorm.DB, userID uint, wall
 		UserID:        userID,
 	}
 	if err := tx.Create(&expense).Error; err != nil {
-		return fmt.Errorf("failed to auto-create payment expense: %w", err)
+		return nil, fmt.Errorf("failed to auto-create payment expense: %w", err)
 	}
 	if expenseType.RecurringType == RecurringTypeFlexible {
-		nextDueDay, err := AdvanceNextDueDayFrom(expense.Date, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay)
+		nextDueDay, err := AdvanceNextDueDayFrom(expense.Date, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if err := tx.Model(&ExpenseType{}).Where("id = ? AND user_id = ?", expenseType.ID, userID).Update("next_due_day", nextDueDay).Error; err != nil {
-			return fmt.Errorf("failed to advance expense type due date: %w", err)
+			return nil, fmt.Errorf("failed to advance expense type due date: %w", err)
 		}
 	}
-	return nil
+	if err := syncExpenseTypeOccurrences(tx, userID, expenseType.ID); err != nil {
+		return nil, err
+	}
+	return &expense, nil
 }
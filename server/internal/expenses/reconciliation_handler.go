@@ -0,0 +1,66 @@
+package expenses
+
+import (
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReconciliationHandler struct {
+	service *ReconciliationService
+}
+
+func NewReconciliationHandler(service *ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{service: service}
+}
+
+func (h *ReconciliationHandler) PreviewReconciliation(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	var req ReconciliationPreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	preview, err := h.service.PreviewReconciliation(userID, uint(walletID), req)
+	if err != nil {
+		return h.reconciliationError(c, err, "Failed to preview reconciliation")
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+func (h *ReconciliationHandler) ConfirmReconciliation(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	var req ConfirmReconciliationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	result, err := h.service.ConfirmReconciliation(userID, uint(walletID), req)
+	if err != nil {
+		return h.reconciliationError(c, err, "Failed to confirm reconciliation")
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+func (h *ReconciliationHandler) reconciliationError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case ErrWalletNotFound, ErrExpenseNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case ErrInvalidStatementTotal, ErrReconciliationNoCredit, ErrReconciliationExpense, ErrReconciliationNoSelect, ErrInvalidPaymentAmount, ErrInvalidPaymentDate:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
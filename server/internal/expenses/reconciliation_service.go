@@ -0,0 +1,245 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReconciliationMatched = "matched"
+	ReconciliationMissing = "missing"
+	ReconciliationExtra   = "extra"
+)
+
+var (
+	ErrInvalidStatementTotal  = errors.New("statement total must be greater than 0")
+	ErrReconciliationNoCredit = errors.New("reconciliation is only supported on credit wallets")
+	ErrReconciliationExpense  = errors.New("selected expenses must be unlinked expenses on this wallet")
+	ErrReconciliationNoSelect = errors.New("select the expenses on the statement or confirm the suggested ones")
+)
+
+// ReconciliationService compares a card statement with the expenses recorded
+// on the wallet and settles the statement with a payment once confirmed.
+type ReconciliationService struct {
+	db       *gorm.DB
+	wallets  *WalletService
+	payments *PaymentService
+	eventSource
+}
+
+type ReconciliationPreviewRequest struct {
+	StatementTotal float64 `json:"statement_total"`
+	ExpenseIDs     []uint  `json:"expense_ids"`
+}
+
+// ConfirmReconciliationRequest settles a statement. The expenses to link are
+// either listed in ExpenseIDs or, with UseSuggested, the preview's suggested
+// expenses.
+type ConfirmReconciliationRequest struct {
+	StatementTotal float64 `json:"statement_total"`
+	Amount         float64 `json:"amount"`
+	Date           string  `json:"date"`
+	Note           string  `json:"note"`
+	ExpenseIDs     []uint  `json:"expense_ids"`
+	UseSuggested   bool    `json:"use_suggested"`
+}
+
+// ReconciliationPreview describes how the statement total compares to the
+// selected expenses. A positive difference means the statement contains
+// charges that are not recorded; a negative one means recorded expenses are
+// not on the statement.
+type ReconciliationPreview struct {
+	StatementTotal      float64   `json:"statement_total"`
+	SelectedTotal       float64   `json:"selected_total"`
+	Difference          float64   `json:"difference"`
+	Status              string    `json:"status"`
+	ClosingDate         *string   `json:"closing_date,omitempty"`
+	SuggestedExpenseIDs []uint    `json:"suggested_expense_ids"`
+	InWindow            []Expense `json:"in_window"`
+	OutsideWindow       []Expense `json:"outside_window"`
+	PossibleMatches     []Expense `json:"possible_matches"`
+}
+
+type ReconciliationResult struct {
+	Payment *Payment               `json:"payment"`
+	Preview *ReconciliationPreview `json:"preview"`
+}
+
+func NewReconciliationService(db *gorm.DB) *ReconciliationService {
+	return &ReconciliationService{db: db, wallets: NewWalletService(db), payments: NewPaymentService(db)}
+}
+
+// SetEventPublisher sets where change events are published, including the
+// payment a confirmed reconciliation creates.
+func (s *ReconciliationService) SetEventPublisher(publisher EventPublisher) {
+	s.eventSource.SetEventPublisher(publisher)
+	s.wallets.SetEventPublisher(publisher)
+	s.payments.SetEventPublisher(publisher)
+}
+
+// PreviewReconciliation compares the statement total against the wallet's
+// unlinked expenses. Expenses dated on or before the last closing date are
+// suggested; when none are given explicitly they are also the selection.
+func (s *ReconciliationService) PreviewReconciliation(userID, walletID uint, req ReconciliationPreviewRequest) (*ReconciliationPreview, error) {
+	if req.StatementTotal <= 0 {
+		return nil, ErrInvalidStatementTotal
+	}
+	wallet, err := s.wallets.GetWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.IsCredit {
+		return nil, ErrReconciliationNoCredit
+	}
+	unbilled, err := s.wallets.GetWalletUnbilledExpenses(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ReconciliationPreview{
+		StatementTotal:      req.StatementTotal,
+		ClosingDate:         unbilled.LastClosingDate,
+		SuggestedExpenseIDs: make([]uint, 0, len(unbilled.CurrentStatement)),
+		InWindow:            unbilled.CurrentStatement,
+		OutsideWindow:       unbilled.NextStatement,
+		PossibleMatches:     make([]Expense, 0),
+	}
+	for _, expense := range unbilled.CurrentStatement {
+		preview.SuggestedExpenseIDs = append(preview.SuggestedExpenseIDs, expense.ID)
+	}
+
+	selectedIDs := req.ExpenseIDs
+	if len(selectedIDs) == 0 {
+		selectedIDs = preview.SuggestedExpenseIDs
+	}
+	available := make(map[uint]Expense, len(unbilled.Expenses))
+	for _, expense := range unbilled.Expenses {
+		available[expense.ID] = expense
+	}
+	selected := make(map[uint]bool, len(selectedIDs))
+	var selectedCents int64
+	for _, id := range selectedIDs {
+		expense, ok := available[id]
+		if !ok {
+			return nil, ErrReconciliationExpense
+		}
+		if selected[id] {
+			continue
+		}
+		selected[id] = true
+		selectedCents += toCents(expense.Amount)
+	}
+
+	differenceCents := toCents(req.StatementTotal) - selectedCents
+	preview.SelectedTotal = float64(selectedCents) / 100
+	preview.Difference = float64(differenceCents) / 100
+	switch {
+	case differenceCents > 0:
+		preview.Status = ReconciliationMissing
+	case differenceCents < 0:
+		preview.Status = ReconciliationExtra
+	default:
+		preview.Status = ReconciliationMatched
+	}
+
+	// A single expense that accounts for the whole difference is the most
+	// likely explanation: an unselected one when the statement is higher, a
+	// selected one when it is lower.
+	if differenceCents != 0 {
+		target := differenceCents
+		if target < 0 {
+			target = -target
+		}
+		for _, expense := range unbilled.Expenses {
+			if selected[expense.ID] != (differenceCents < 0) {
+				continue
+			}
+			if toCents(expense.Amount) == target {
+				preview.PossibleMatches = append(preview.PossibleMatches, expense)
+			}
+		}
+	}
+	return preview, nil
+}
+
+// ConfirmReconciliation creates the statement payment and links the selected
+// expenses to it in one transaction. The payment amount defaults to the
+// statement total. An empty selection is only accepted together with
+// UseSuggested.
+func (s *ReconciliationService) ConfirmReconciliation(userID, walletID uint, req ConfirmReconciliationRequest) (*ReconciliationResult, error) {
+	if len(req.ExpenseIDs) == 0 && !req.UseSuggested {
+		return nil, ErrReconciliationNoSelect
+	}
+	preview, err := s.PreviewReconciliation(userID, walletID, ReconciliationPreviewRequest{
+		StatementTotal: req.StatementTotal,
+		ExpenseIDs:     req.ExpenseIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	amount := req.Amount
+	if amount == 0 {
+		amount = req.StatementTotal
+	}
+	parsedDate, _, err := s.payments.validatePaymentInput(userID, walletID, amount, req.Date)
+	if err != nil {
+		return nil, err
+	}
+	expenseIDs := req.ExpenseIDs
+	if len(expenseIDs) == 0 {
+		expenseIDs = preview.SuggestedExpenseIDs
+	}
+	note := strings.TrimSpace(req.Note)
+	if note == "" && preview.ClosingDate != nil {
+		note = fmt.Sprintf("Statement closing %s", *preview.ClosingDate)
+	}
+
+	var payment Payment
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		payment = Payment{
+			WalletID: walletID,
+			Amount:   amount,
+			Date:     parsedDate,
+			Note:     note,
+			UserID:   userID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+		if err := s.payments.replacePaymentExpenses(tx, userID, payment.ID, walletID, uniqueIDs(expenseIDs)); err != nil {
+			return err
+		}
+		return syncWalletOccurrences(tx, userID, walletID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.payments.GetPayment(userID, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(userID, EventPaymentCreated, created)
+	return &ReconciliationResult{Payment: created, Preview: preview}, nil
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
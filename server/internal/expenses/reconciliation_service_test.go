@@ -0,0 +1,115 @@
+package expenses
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type reconciliationFixture struct {
+	wallet   Wallet
+	grocery  Expense
+	fuel     Expense
+	outside  Expense
+	reviewed []uint
+}
+
+// createReconciliationFixture records two expenses in the last closed cycle
+// and one after it.
+func createReconciliationFixture(t *testing.T, db *gorm.DB, userID uint) reconciliationFixture {
+	t.Helper()
+	wallet := Wallet{Name: "Card", IsCredit: true, StatementClosingDay: 10, UserID: userID}
+	expenseType := ExpenseType{Name: "Shopping", RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&wallet).Error)
+	require.NoError(t, db.Create(&expenseType).Error)
+
+	lastClosing := LastClosedStatementDate(wallet.StatementClosingDay, NormalizeDateOnly(time.Now()))
+	create := func(amount float64, date time.Time) Expense {
+		expense := Expense{ExpenseTypeID: expenseType.ID, WalletID: &wallet.ID, Amount: amount, Date: date, UserID: userID}
+		require.NoError(t, db.Create(&expense).Error)
+		return expense
+	}
+	fixture := reconciliationFixture{
+		wallet:  wallet,
+		grocery: create(100, lastClosing.AddDate(0, 0, -2)),
+		fuel:    create(50, lastClosing),
+		outside: create(30, lastClosing.AddDate(0, 0, 1)),
+	}
+	fixture.reviewed = []uint{fixture.grocery.ID, fixture.fuel.ID}
+	return fixture
+}
+
+func expenseIDs(expenses []Expense) []uint {
+	ids := make([]uint, 0, len(expenses))
+	for _, expense := range expenses {
+		ids = append(ids, expense.ID)
+	}
+	return ids
+}
+
+func TestReconciliationService_PreviewReconciliation(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	fixture := createReconciliationFixture(t, db, userID)
+	service := NewReconciliationService(db)
+
+	tests := []struct {
+		name            string
+		statementTotal  float64
+		expenseIDs      []uint
+		wantStatus      string
+		wantSelected    float64
+		wantDifference  float64
+		wantPossibleIDs []uint
+	}{
+		{name: "suggested expenses match", statementTotal: 150, wantStatus: ReconciliationMatched, wantSelected: 150, wantPossibleIDs: []uint{}},
+		{name: "statement has an unselected charge", statementTotal: 180, wantStatus: ReconciliationMissing, wantSelected: 150, wantDifference: 30, wantPossibleIDs: []uint{fixture.outside.ID}},
+		{name: "selected expense not on statement", statementTotal: 100, wantStatus: ReconciliationExtra, wantSelected: 150, wantDifference: -50, wantPossibleIDs: []uint{fixture.fuel.ID}},
+		{name: "explicit selection", statementTotal: 130, expenseIDs: []uint{fixture.grocery.ID, fixture.outside.ID}, wantStatus: ReconciliationMatched, wantSelected: 130, wantPossibleIDs: []uint{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preview, err := service.PreviewReconciliation(userID, fixture.wallet.ID, ReconciliationPreviewRequest{StatementTotal: test.statementTotal, ExpenseIDs: test.expenseIDs})
+			require.NoError(t, err)
+			assert.Equal(t, test.wantStatus, preview.Status)
+			assert.Equal(t, test.wantSelected, preview.SelectedTotal)
+			assert.Equal(t, test.wantDifference, preview.Difference)
+			assert.ElementsMatch(t, test.wantPossibleIDs, expenseIDs(preview.PossibleMatches))
+			assert.ElementsMatch(t, fixture.reviewed, preview.SuggestedExpenseIDs)
+			assert.ElementsMatch(t, fixture.reviewed, expenseIDs(preview.InWindow))
+			assert.ElementsMatch(t, []uint{fixture.outside.ID}, expenseIDs(preview.OutsideWindow))
+		})
+	}
+
+	_, err := service.PreviewReconciliation(userID, fixture.wallet.ID, ReconciliationPreviewRequest{StatementTotal: 50, ExpenseIDs: []uint{9999}})
+	assert.ErrorIs(t, err, ErrReconciliationExpense)
+}
+
+func TestReconciliationService_ConfirmReconciliation(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	fixture := createReconciliationFixture(t, db, userID)
+	service := NewReconciliationService(db)
+	today := time.Now().Format(DateOnlyLayout)
+
+	_, err := service.ConfirmReconciliation(userID, fixture.wallet.ID, ConfirmReconciliationRequest{StatementTotal: 150, Date: today})
+	assert.ErrorIs(t, err, ErrReconciliationNoSelect, "an empty selection must not silently take the suggestions")
+	var payments int64
+	require.NoError(t, db.Model(&Payment{}).Count(&payments).Error)
+	assert.Zero(t, payments)
+
+	result, err := service.ConfirmReconciliation(userID, fixture.wallet.ID, ConfirmReconciliationRequest{StatementTotal: 150, Date: today, UseSuggested: true})
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, result.Payment.Amount)
+	var linked []uint
+	require.NoError(t, db.Model(&Expense{}).Where("payment_id = ?", result.Payment.ID).Pluck("id", &linked).Error)
+	assert.ElementsMatch(t, fixture.reviewed, linked)
+
+	result, err = service.ConfirmReconciliation(userID, fixture.wallet.ID, ConfirmReconciliationRequest{StatementTotal: 30, Date: today, ExpenseIDs: []uint{fixture.outside.ID}})
+	require.NoError(t, err)
+	require.NoError(t, db.First(&fixture.outside, fixture.outside.ID).Error)
+	assert.Equal(t, &result.Payment.ID, fixture.outside.PaymentID)
+}
@@ -0,0 +1,547 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence rules follow the date-only subset of RFC 5545 RRULE that bills
+// need: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY with optional
+// ordinals, BYMONTHDAY (negative values count from the month end), BYMONTH,
+// BYSETPOS, COUNT, UNTIL and WKST. Rules are stored with their DTSTART, e.g.
+//
+//	DTSTART:20250101
+//	RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1
+const (
+	RecurrenceFreqDaily   = "DAILY"
+	RecurrenceFreqWeekly  = "WEEKLY"
+	RecurrenceFreqMonthly = "MONTHLY"
+	RecurrenceFreqYearly  = "YEARLY"
+
+	recurrenceDateLayout = "20060102"
+)
+
+// maxRecurrencePeriods bounds how many FREQ periods a search walks, so a rule
+// that can never match again (e.g. BYMONTHDAY=31;BYMONTH=2) still returns.
+const maxRecurrencePeriods = 20000
+
+var ErrInvalidRecurrenceRule = errors.New("invalid recurrence rule")
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceWeekday is one BYDAY entry. Ordinal 0 means every such weekday in
+// the period; 2 is the second and -1 the last.
+type RecurrenceWeekday struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+type RecurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []RecurrenceWeekday
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+	Count      int
+	Until      *time.Time
+	WeekStart  time.Weekday
+	DTStart    time.Time
+}
+
+// ParseRecurrenceRule parses an RRULE, with or without the "RRULE:" prefix,
+// optionally preceded by a DTSTART line. When the rule has no DTSTART,
+// defaultStart is used.
+func ParseRecurrenceRule(value string, defaultStart time.Time) (*RecurrenceRule, error) {
+	rule := &RecurrenceRule{Interval: 1, WeekStart: time.Monday, DTStart: NormalizeDateOnly(defaultStart)}
+	var ruleLine string
+	for _, line := range strings.FieldsFunc(strings.TrimSpace(value), func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		upper := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(upper, "DTSTART"):
+			index := strings.LastIndex(line, ":")
+			if index < 0 {
+				return nil, fmt.Errorf("%w: malformed DTSTART", ErrInvalidRecurrenceRule)
+			}
+			start, err := parseRecurrenceDate(line[index+1:])
+			if err != nil {
+				return nil, err
+			}
+			rule.DTStart = start
+		case strings.HasPrefix(upper, "RRULE:"):
+			ruleLine = line[len("RRULE:"):]
+		case line != "":
+			ruleLine = line
+		}
+	}
+	if ruleLine == "" {
+		return nil, fmt.Errorf("%w: missing RRULE", ErrInvalidRecurrenceRule)
+	}
+
+	for _, part := range strings.Split(ruleLine, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrenceRule, part)
+		}
+		name = strings.ToUpper(strings.TrimSpace(name))
+		raw = strings.ToUpper(strings.TrimSpace(raw))
+		var err error
+		switch name {
+		case "FREQ":
+			switch raw {
+			case RecurrenceFreqDaily, RecurrenceFreqWeekly, RecurrenceFreqMonthly, RecurrenceFreqYearly:
+				rule.Freq = raw
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRecurrenceRule, raw)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(raw)
+			if err != nil || rule.Interval < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive number", ErrInvalidRecurrenceRule)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(raw)
+			if err != nil || rule.Count < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive number", ErrInvalidRecurrenceRule)
+			}
+		case "UNTIL":
+			until, err := parseRecurrenceDate(raw)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(raw, ",") {
+				weekday, err := parseRecurrenceWeekday(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseRecurrenceInts(raw, 1, 31, true)
+		case "BYMONTH":
+			rule.ByMonth, err = parseRecurrenceInts(raw, 1, 12, false)
+		case "BYSETPOS":
+			rule.BySetPos, err = parseRecurrenceInts(raw, 1, 366, true)
+		case "WKST":
+			weekday, ok := recurrenceWeekdays[raw]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown WKST %s", ErrInvalidRecurrenceRule, raw)
+			}
+			rule.WeekStart = weekday
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRecurrenceRule, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrenceRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRecurrenceRule)
+	}
+	for _, weekday := range rule.ByDay {
+		if weekday.Ordinal != 0 && rule.Freq != RecurrenceFreqMonthly && rule.Freq != RecurrenceFreqYearly {
+			return nil, fmt.Errorf("%w: BYDAY ordinals need FREQ=MONTHLY or YEARLY", ErrInvalidRecurrenceRule)
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == RecurrenceFreqWeekly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRecurrenceRule)
+	}
+	return rule, nil
+}
+
+// NormalizeRecurrenceRule parses value and returns it in its stored form with
+// an explicit DTSTART. An empty value stays empty.
+func NormalizeRecurrenceRule(value string, defaultStart time.Time) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+	rule, err := ParseRecurrenceRule(value, defaultStart)
+	if err != nil {
+		return "", err
+	}
+	return rule.String(), nil
+}
+
+// String renders the rule as a DTSTART line followed by an RRULE line.
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, weekday := range r.ByDay {
+			day := ""
+			if weekday.Ordinal != 0 {
+				day = strconv.Itoa(weekday.Ordinal)
+			}
+			days = append(days, day+strings.ToUpper(weekday.Weekday.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format(recurrenceDateLayout))
+	}
+	return "DTSTART:" + r.DTStart.Format(recurrenceDateLayout) + "\nRRULE:" + strings.Join(parts, ";")
+}
+
+// NextOnOrAfter returns the first occurrence on or after reference, or nil
+// when the rule has no more occurrences.
+func (r *RecurrenceRule) NextOnOrAfter(reference time.Time) *time.Time {
+	reference = NormalizeDateOnly(reference)
+	var found *time.Time
+	r.each(func(occurrence time.Time) bool {
+		if occurrence.Before(reference) {
+			return true
+		}
+		found = &occurrence
+		return false
+	})
+	return found
+}
+
+// NextAfter returns the first occurrence strictly after reference.
+func (r *RecurrenceRule) NextAfter(reference time.Time) *time.Time {
+	return r.NextOnOrAfter(NormalizeDateOnly(reference).AddDate(0, 0, 1))
+}
+
+// NextAfterSettlement returns the occurrence after the one a payment made on
+// paidOn settles. The settled occurrence is the one nearest to paidOn, so a
+// bill paid a few days early or late is not reported as due again.
+func (r *RecurrenceRule) NextAfterSettlement(paidOn time.Time) *time.Time {
+	paidOn = NormalizeDateOnly(paidOn)
+	var before, after *time.Time
+	r.each(func(occurrence time.Time) bool {
+		if occurrence.Before(paidOn) {
+			before = &occurrence
+			return true
+		}
+		after = &occurrence
+		return false
+	})
+	settled := after
+	if before != nil && (after == nil || paidOn.Sub(*before) < after.Sub(paidOn)) {
+		settled = before
+	}
+	if settled == nil {
+		return nil
+	}
+	return r.NextAfter(*settled)
+}
+
+// Between returns every occurrence from start to end inclusive.
+func (r *RecurrenceRule) Between(start, end time.Time) []time.Time {
+	start = NormalizeDateOnly(start)
+	end = NormalizeDateOnly(end)
+	occurrences := make([]time.Time, 0)
+	r.each(func(occurrence time.Time) bool {
+		if occurrence.After(end) {
+			return false
+		}
+		if !occurrence.Before(start) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// each walks the occurrences in order from DTSTART until visit returns false
+// or the rule ends.
+func (r *RecurrenceRule) each(visit func(time.Time) bool) {
+	emitted := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, occurrence := range r.expandPeriod(period) {
+			if occurrence.Before(r.DTStart) {
+				continue
+			}
+			if r.Until != nil && occurrence.After(*r.Until) {
+				return
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return
+			}
+			emitted++
+			if !visit(occurrence) {
+				return
+			}
+		}
+	}
+}
+
+// expandPeriod returns the sorted occurrences of the index-th FREQ period
+// after the one containing DTSTART.
+func (r *RecurrenceRule) expandPeriod(index int) []time.Time {
+	step := index * r.Interval
+	start := r.DTStart
+	var candidates []time.Time
+	switch r.Freq {
+	case RecurrenceFreqDaily:
+		day := start.AddDate(0, 0, step)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			candidates = []time.Time{day}
+		}
+	case RecurrenceFreqWeekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := start.AddDate(0, 0, -offset+7*step)
+		for day := 0; day < 7; day++ {
+			candidate := weekStart.AddDate(0, 0, day)
+			if !r.matchesMonth(candidate) {
+				continue
+			}
+			if len(r.ByDay) == 0 && candidate.Weekday() != start.Weekday() {
+				continue
+			}
+			if len(r.ByDay) > 0 && !r.matchesWeekday(candidate) {
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+	case RecurrenceFreqMonthly:
+		month := BeginningOfMonth(start.Year(), int(start.Month())).AddDate(0, step, 0)
+		if r.matchesMonth(month) {
+			candidates = r.expandMonth(month)
+		}
+	case RecurrenceFreqYearly:
+		year := start.Year() + step
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.ByMonth {
+				candidates = append(candidates, r.expandMonth(BeginningOfMonth(year, month))...)
+			}
+		case len(r.ByDay) > 0 && len(r.ByMonthDay) == 0:
+			candidates = r.expandYearByDay(year)
+		case len(r.ByMonthDay) > 0:
+			for month := 1; month <= 12; month++ {
+				candidates = append(candidates, r.expandMonth(BeginningOfMonth(year, month))...)
+			}
+		default:
+			if day := time.Date(year, start.Month(), start.Day(), 0, 0, 0, 0, time.UTC); day.Day() == start.Day() {
+				candidates = []time.Time{day}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	candidates = dedupeDates(candidates)
+	return r.applySetPos(candidates)
+}
+
+// expandMonth returns the days of the month selected by BYMONTHDAY and BYDAY,
+// or the DTSTART day of month when neither is set.
+func (r *RecurrenceRule) expandMonth(month time.Time) []time.Time {
+	lastDay := EndOfMonth(month.Year(), int(month.Month())).Day()
+	var days []time.Time
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if r.DTStart.Day() <= lastDay {
+			days = append(days, time.Date(month.Year(), month.Month(), r.DTStart.Day(), 0, 0, 0, 0, time.UTC))
+		}
+		return days
+	}
+	for day := 1; day <= lastDay; day++ {
+		candidate := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+		if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(candidate) {
+			continue
+		}
+		if len(r.ByDay) > 0 && !r.matchesWeekdayInRange(candidate, month, EndOfMonth(month.Year(), int(month.Month()))) {
+			continue
+		}
+		days = append(days, candidate)
+	}
+	return days
+}
+
+func (r *RecurrenceRule) expandYearByDay(year int) []time.Time {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var days []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if r.matchesWeekdayInRange(day, first, last) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func (r *RecurrenceRule) applySetPos(candidates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(candidates) == 0 {
+		return candidates
+	}
+	selected := make([]time.Time, 0, len(r.BySetPos))
+	for _, position := range r.BySetPos {
+		index := position - 1
+		if position < 0 {
+			index = len(candidates) + position
+		}
+		if index >= 0 && index < len(candidates) {
+			selected = append(selected, candidates[index])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return dedupeDates(selected)
+}
+
+func (r *RecurrenceRule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, month := range r.ByMonth {
+		if int(day.Month()) == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecurrenceRule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	lastDay := EndOfMonth(day.Year(), int(day.Month())).Day()
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && lastDay+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday checks BYDAY without ordinals.
+func (r *RecurrenceRule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekdayInRange checks BYDAY where ordinals count within first..last.
+func (r *RecurrenceRule) matchesWeekdayInRange(day, first, last time.Time) bool {
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday != day.Weekday() {
+			continue
+		}
+		if weekday.Ordinal == 0 {
+			return true
+		}
+		if weekday.Ordinal > 0 && int(day.Sub(first).Hours()/24)/7+1 == weekday.Ordinal {
+			return true
+		}
+		if weekday.Ordinal < 0 && int(last.Sub(day).Hours()/24)/7+1 == -weekday.Ordinal {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRecurrenceDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > len(recurrenceDateLayout) {
+		// Date-times such as 20250101T000000Z are reduced to their date.
+		value = value[:len(recurrenceDateLayout)]
+	}
+	parsed, err := time.Parse(recurrenceDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid date %q", ErrInvalidRecurrenceRule, value)
+	}
+	return parsed, nil
+}
+
+func parseRecurrenceWeekday(value string) (RecurrenceWeekday, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return RecurrenceWeekday{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRecurrenceRule, value)
+	}
+	weekday, ok := recurrenceWeekdays[value[len(value)-2:]]
+	if !ok {
+		return RecurrenceWeekday{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRecurrenceRule, value)
+	}
+	result := RecurrenceWeekday{Weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		ordinal, err := strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal > 53 || ordinal < -53 {
+			return RecurrenceWeekday{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRecurrenceRule, value)
+		}
+		result.Ordinal = ordinal
+	}
+	return result, nil
+}
+
+func parseRecurrenceInts(value string, min, max int, allowNegative bool) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		number, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %q", ErrInvalidRecurrenceRule, item)
+		}
+		absolute := number
+		if number < 0 && allowNegative {
+			absolute = -number
+		}
+		if absolute < min || absolute > max {
+			return nil, fmt.Errorf("%w: %d is out of range", ErrInvalidRecurrenceRule, number)
+		}
+		values = append(values, number)
+	}
+	return values, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, strconv.Itoa(value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func dedupeDates(values []time.Time) []time.Time {
+	if len(values) < 2 {
+		return values
+	}
+	result := values[:1]
+	for _, value := range values[1:] {
+		if !value.Equal(result[len(result)-1]) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
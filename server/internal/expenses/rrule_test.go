@@ -0,0 +1,160 @@
+package expenses
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		from string
+		to   string
+		want []string
+	}{
+		{
+			name: "last business day of the month",
+			rule: "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			from: "2025-01-01", to: "2025-03-31",
+			want: []string{"2025-01-31", "2025-02-28", "2025-03-31"},
+		},
+		{
+			name: "second tuesday",
+			rule: "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYDAY=2TU",
+			from: "2025-01-01", to: "2025-03-31",
+			want: []string{"2025-01-14", "2025-02-11", "2025-03-11"},
+		},
+		{
+			name: "every other tuesday",
+			rule: "DTSTART:20250107\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+			from: "2025-01-01", to: "2025-02-10",
+			want: []string{"2025-01-07", "2025-01-21", "2025-02-04"},
+		},
+		{
+			name: "15th and last day",
+			rule: "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYMONTHDAY=15,-1",
+			from: "2025-02-01", to: "2025-03-20",
+			want: []string{"2025-02-15", "2025-02-28", "2025-03-15"},
+		},
+		{
+			name: "day 31 skips short months",
+			rule: "DTSTART:20250131\nRRULE:FREQ=MONTHLY",
+			from: "2025-01-01", to: "2025-05-31",
+			want: []string{"2025-01-31", "2025-03-31", "2025-05-31"},
+		},
+		{
+			name: "yearly in selected months",
+			rule: "DTSTART:20250101\nRRULE:FREQ=YEARLY;BYMONTH=4,10;BYMONTHDAY=1",
+			from: "2025-01-01", to: "2026-05-01",
+			want: []string{"2025-04-01", "2025-10-01", "2026-04-01"},
+		},
+		{
+			name: "last friday of the year",
+			rule: "DTSTART:20250101\nRRULE:FREQ=YEARLY;BYDAY=-1FR",
+			from: "2025-01-01", to: "2026-12-31",
+			want: []string{"2025-12-26", "2026-12-25"},
+		},
+		{
+			name: "count limits occurrences",
+			rule: "DTSTART:20250110\nRRULE:FREQ=MONTHLY;COUNT=2",
+			from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-01-10", "2025-02-10"},
+		},
+		{
+			name: "until is inclusive",
+			rule: "DTSTART:20250101\nRRULE:FREQ=DAILY;INTERVAL=10;UNTIL=20250121",
+			from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-01-01", "2025-01-11", "2025-01-21"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(test.rule, time.Time{})
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule error: %v", err)
+			}
+			got := rule.Between(mustParseDate(t, test.from), mustParseDate(t, test.to))
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d occurrences %v, want %v", len(got), got, test.want)
+			}
+			for index := range test.want {
+				if got[index].Format(DateOnlyLayout) != test.want[index] {
+					t.Fatalf("occurrence %d = %s, want %s", index, got[index].Format(DateOnlyLayout), test.want[index])
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	rule, err := ParseRecurrenceRule("RRULE:FREQ=MONTHLY;BYMONTHDAY=15", mustParseDate(t, "2025-01-01"))
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule error: %v", err)
+	}
+	if got := rule.NextOnOrAfter(mustParseDate(t, "2025-03-15")); got == nil || got.Format(DateOnlyLayout) != "2025-03-15" {
+		t.Fatalf("NextOnOrAfter = %v, want 2025-03-15", got)
+	}
+	if got := rule.NextAfter(mustParseDate(t, "2025-03-15")); got == nil || got.Format(DateOnlyLayout) != "2025-04-15" {
+		t.Fatalf("NextAfter = %v, want 2025-04-15", got)
+	}
+
+	ended, err := ParseRecurrenceRule("DTSTART:20250101\nRRULE:FREQ=MONTHLY;COUNT=1", time.Time{})
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule error: %v", err)
+	}
+	if got := ended.NextAfter(mustParseDate(t, "2025-01-01")); got != nil {
+		t.Fatalf("NextAfter on finished rule = %v, want nil", got)
+	}
+}
+
+func TestNormalizeRecurrenceRule(t *testing.T) {
+	got, err := NormalizeRecurrenceRule("freq=monthly;byday=mo,-1fr;interval=2", mustParseDate(t, "2025-06-01"))
+	if err != nil {
+		t.Fatalf("NormalizeRecurrenceRule error: %v", err)
+	}
+	want := "DTSTART:20250601\nRRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=MO,-1FR"
+	if got != want {
+		t.Fatalf("NormalizeRecurrenceRule = %q, want %q", got, want)
+	}
+}
+
+func TestParseRecurrenceRuleRejectsInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYDAY=2TU",
+		"FREQ=MONTHLY;COUNT=2;UNTIL=20250101",
+		"FREQ=MONTHLY;BYHOUR=9",
+	} {
+		if _, err := ParseRecurrenceRule(value, mustParseDate(t, "2025-01-01")); !errors.Is(err, ErrInvalidRecurrenceRule) {
+			t.Fatalf("ParseRecurrenceRule(%q) error = %v, want ErrInvalidRecurrenceRule", value, err)
+		}
+	}
+}
+
+func TestRecurrenceRuleNextAfterSettlement(t *testing.T) {
+	rule, err := ParseRecurrenceRule("DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYMONTHDAY=15", time.Time{})
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule returned error: %v", err)
+	}
+	tests := []struct {
+		paidOn string
+		want   string
+	}{
+		{paidOn: "2025-03-15", want: "2025-04-15"},
+		{paidOn: "2025-03-13", want: "2025-04-15"},
+		{paidOn: "2025-03-20", want: "2025-04-15"},
+		{paidOn: "2025-04-02", want: "2025-05-15"},
+	}
+	for _, test := range tests {
+		got := rule.NextAfterSettlement(mustParseDate(t, test.paidOn))
+		if got == nil || got.Format(DateOnlyLayout) != test.want {
+			t.Fatalf("NextAfterSettlement(%s) = %v, want %s", test.paidOn, got, test.want)
+		}
+	}
+}
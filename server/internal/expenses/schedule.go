@@ -2,6 +2,8 @@ package expenses
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -67,12 +69,24 @@ func AddRecurringPeriod(value time.Time, recurringPeriod string) (time.Time, err
 	}
 }
 
-func ComputeInitialNextDueDay(from time.Time, recurringType, recurringPeriod string, recurringDueDay int) (*time.Time, error) {
+// ComputeInitialNextDueDay returns the first due date on or after from. A
+// recurrence rule, when set, replaces the period and due day.
+func ComputeInitialNextDueDay(from time.Time, recurringType, recurringPeriod string, recurringDueDay int, recurrenceRule string) (*time.Time, error) {
 	from = NormalizeDateOnly(from)
 
 	switch recurringType {
 	case RecurringTypeNone, "":
 		return nil, nil
+	}
+	if strings.TrimSpace(recurrenceRule) != "" {
+		rule, err := ParseRecurrenceRule(recurrenceRule, from)
+		if err != nil {
+			return nil, err
+		}
+		return rule.NextOnOrAfter(from), nil
+	}
+
+	switch recurringType {
 	case RecurringTypeFlexible:
 		candidate := from
 		return &candidate, nil
@@ -95,12 +109,25 @@ func ComputeInitialNextDueDay(from time.Time, recurringType, recurringPeriod str
 	}
 }
 
-func AdvanceNextDueDayFrom(referenceDate time.Time, recurringType, recurringPeriod string, recurringDueDay int) (*time.Time, error) {
+// AdvanceNextDueDayFrom returns the due date that follows a payment made on
+// referenceDate. With a recurrence rule it is the occurrence after the one
+// the payment settles, or nil once the rule has ended.
+func AdvanceNextDueDayFrom(referenceDate time.Time, recurringType, recurringPeriod string, recurringDueDay int, recurrenceRule string) (*time.Time, error) {
 	referenceDate = NormalizeDateOnly(referenceDate)
 
 	switch recurringType {
 	case RecurringTypeNone, "":
 		return nil, nil
+	}
+	if strings.TrimSpace(recurrenceRule) != "" {
+		rule, err := ParseRecurrenceRule(recurrenceRule, referenceDate)
+		if err != nil {
+			return nil, err
+		}
+		return rule.NextAfterSettlement(referenceDate), nil
+	}
+
+	switch recurringType {
 	case RecurringTypeFlexible:
 		nextDate, err := AddRecurringPeriod(referenceDate, recurringPeriod)
 		if err != nil {
@@ -120,7 +147,42 @@ func AdvanceNextDueDayFrom(referenceDate time.Time, recurringType, recurringPeri
 	}
 }
 
+// NextWalletDueDate returns the wallet's next due date on or after reference,
+// moved off weekends and holidays by the wallet's business day rule.
 func NextWalletDueDate(wallet Wallet, reference time.Time, lastPayment *Payment) time.Time {
+	if lastPayment == nil || lastPayment.ID == 0 {
+		return nextWalletDueDate(wallet, reference, nil)
+	}
+	return nextWalletDueDate(wallet, reference, &lastPayment.Date)
+}
+
+// nextWalletDueDateAfterPayment returns the wallet's next due date on or
+// after reference once a bill has been paid on paidOn.
+func nextWalletDueDateAfterPayment(wallet Wallet, reference, paidOn time.Time) time.Time {
+	return nextWalletDueDate(wallet, reference, &paidOn)
+}
+
+func nextWalletDueDate(wallet Wallet, reference time.Time, paidOn *time.Time) time.Time {
+	due := nominalWalletDueDate(wallet, reference, paidOn)
+	adjusted := AdjustToBusinessDay(due, wallet.BusinessDayRule, wallet.HolidayRegion)
+	if paidOn == nil {
+		return adjusted
+	}
+	// A payment made on a moved-up due date already settles that occurrence.
+	paid := NormalizeDateOnly(*paidOn)
+	for attempt := 0; attempt < maxBusinessDayShift && !adjusted.After(paid); attempt++ {
+		due = nominalWalletDueDate(wallet, due.AddDate(0, 0, 1), paidOn)
+		adjusted = AdjustToBusinessDay(due, wallet.BusinessDayRule, wallet.HolidayRegion)
+	}
+	return adjusted
+}
+
+func nominalWalletDueDate(wallet Wallet, reference time.Time, paidOn *time.Time) time.Time {
+	if rule := walletRecurrenceRule(wallet); rule != nil {
+		if due := nextWalletRuleDueDate(rule, reference, paidOn); due != nil {
+			return *due
+		}
+	}
 	periodMonths := PeriodMonths(wallet.BillPeriod)
 	if periodMonths <= 0 {
 		periodMonths = 1
@@ -129,10 +191,10 @@ func NextWalletDueDate(wallet Wallet, reference time.Time, lastPayment *Payment)
 	if dueDay == 0 {
 		dueDay = EndOfMonth(reference.Year(), int(reference.Month())).Day()
 	}
-	if lastPayment == nil || lastPayment.ID == 0 {
+	if paidOn == nil {
 		return WalletDueDate(reference, dueDay)
 	}
-	base := lastPayment.Date.AddDate(0, periodMonths, 0)
+	base := paidOn.AddDate(0, periodMonths, 0)
 	due := WalletDueDate(base, dueDay)
 	for due.Before(reference) {
 		base = base.AddDate(0, periodMonths, 0)
@@ -141,6 +203,35 @@ func NextWalletDueDate(wallet Wallet, reference time.Time, lastPayment *Payment)
 	return due
 }
 
+// nextWalletRuleDueDate mirrors NextWalletDueDate for a wallet with a due
+// date rule. Without a payment the first occurrence in the reference month is
+// due; otherwise the first occurrence after the one the payment settled that
+// is not before reference.
+func nextWalletRuleDueDate(rule *RecurrenceRule, reference time.Time, paidOn *time.Time) *time.Time {
+	reference = NormalizeDateOnly(reference)
+	if paidOn == nil {
+		return rule.NextOnOrAfter(BeginningOfMonth(reference.Year(), int(reference.Month())))
+	}
+	due := rule.NextAfterSettlement(*paidOn)
+	if due != nil && due.Before(reference) {
+		due = rule.NextOnOrAfter(reference)
+	}
+	return due
+}
+
+// walletRecurrenceRule returns the wallet's due date rule, or nil when it has
+// none or it cannot be parsed, in which case the bill due day applies.
+func walletRecurrenceRule(wallet Wallet) *RecurrenceRule {
+	if !wallet.IsCredit || strings.TrimSpace(wallet.DueRecurrenceRule) == "" {
+		return nil
+	}
+	rule, err := ParseRecurrenceRule(wallet.DueRecurrenceRule, time.Now())
+	if err != nil {
+		return nil
+	}
+	return rule
+}
+
 func WalletDueDate(reference time.Time, dueDay int) time.Time {
 	lastDay := time.Date(reference.Year(), reference.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
 	if dueDay > lastDay {
@@ -149,26 +240,88 @@ func WalletDueDate(reference time.Time, dueDay int) time.Time {
 	return time.Date(reference.Year(), reference.Month(), dueDay, 0, 0, 0, 0, time.UTC)
 }
 
+// NextExpenseTypeDueDate returns the type's next due date, moved off weekends
+// and holidays by the type's business day rule, or nil once it falls after
+// the type's end date.
 func NextExpenseTypeDueDate(expenseType ExpenseType, reference time.Time, lastExpenseDate *time.Time) (*time.Time, error) {
+	due, err := nextExpenseTypeDueDate(expenseType, reference, lastExpenseDate)
+	if err != nil || due == nil || expenseType.EndsBefore(*due) {
+		return nil, err
+	}
+	return due, nil
+}
+
+func nextExpenseTypeDueDate(expenseType ExpenseType, reference time.Time, lastExpenseDate *time.Time) (*time.Time, error) {
 	switch expenseType.RecurringType {
 	case RecurringTypeNone, "":
 		return nil, nil
 	case RecurringTypeFlexible:
 		if expenseType.NextDueDay != nil {
-			nextDue := NormalizeDateOnly(*expenseType.NextDueDay)
-			return &nextDue, nil
+			return expenseType.adjustDueDate(expenseType.NextDueDay), nil
 		}
-		return ComputeInitialNextDueDay(reference, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay)
+		nextDue, err := ComputeInitialNextDueDay(reference, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+		if err != nil {
+			return nil, err
+		}
+		return expenseType.adjustDueDate(nextDue), nil
 	case RecurringTypeFixedDay:
-		if lastExpenseDate != nil {
-			return AdvanceNextDueDayFrom(*lastExpenseDate, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay)
+		if lastExpenseDate == nil {
+			nextDue, err := ComputeInitialNextDueDay(reference, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+			if err != nil {
+				return nil, err
+			}
+			return expenseType.adjustDueDate(nextDue), nil
+		}
+		nextDue, err := AdvanceNextDueDayFrom(*lastExpenseDate, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+		if err != nil {
+			return nil, err
+		}
+		// An expense recorded on a moved-up due date already covers it.
+		paidOn := NormalizeDateOnly(*lastExpenseDate)
+		for attempt := 0; attempt < maxBusinessDayShift && nextDue != nil && !expenseType.adjustDueDate(nextDue).After(paidOn); attempt++ {
+			nextDue, err = AdvanceNextDueDayFrom(*nextDue, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+			if err != nil {
+				return nil, err
+			}
 		}
-		return ComputeInitialNextDueDay(reference, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay)
+		return expenseType.adjustDueDate(nextDue), nil
 	default:
 		return nil, fmt.Errorf("invalid recurring type")
 	}
 }
 
+// FollowingExpenseTypeDueDate returns the due date of the cycle after the one
+// due on due, assuming it is paid on that day.
+func FollowingExpenseTypeDueDate(expenseType ExpenseType, due time.Time) (*time.Time, error) {
+	if expenseType.RecurringType != RecurringTypeFlexible {
+		return NextExpenseTypeDueDate(expenseType, due, &due)
+	}
+	// NextDueDay only holds the open cycle, so step from due directly.
+	next, err := AdvanceNextDueDayFrom(due, expenseType.RecurringType, expenseType.RecurringPeriod, expenseType.RecurringDueDay, expenseType.RecurrenceRule)
+	if err != nil {
+		return nil, err
+	}
+	next = expenseType.adjustDueDate(next)
+	if next == nil || expenseType.EndsBefore(*next) {
+		return nil, nil
+	}
+	return next, nil
+}
+
+// FollowingWalletDueDate returns the bill due date after the one due on due,
+// assuming that bill is paid on the day it falls due.
+func FollowingWalletDueDate(wallet Wallet, due time.Time) time.Time {
+	return nextWalletDueDateAfterPayment(wallet, due.AddDate(0, 0, 1), due)
+}
+
+func (et ExpenseType) adjustDueDate(date *time.Time) *time.Time {
+	if date == nil {
+		return nil
+	}
+	adjusted := AdjustToBusinessDay(*date, et.BusinessDayRule, et.HolidayRegion)
+	return &adjusted
+}
+
 func withClampedDay(year int, month time.Month, day int) time.Time {
 	if day < 1 {
 		day = 1
@@ -179,3 +332,72 @@ func withClampedDay(year int, month time.Month, day int) time.Time {
 	}
 	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
+
+// StatementClosingDate returns the closing date of the statement cycle that
+// contains reference. Closing days past the end of a month clamp to its last day.
+func StatementClosingDate(closingDay int, reference time.Time) time.Time {
+	reference = NormalizeDateOnly(reference)
+	closing := withClampedDay(reference.Year(), reference.Month(), closingDay)
+	if closing.Before(reference) {
+		nextMonth := BeginningOfMonth(reference.Year(), int(reference.Month())).AddDate(0, 1, 0)
+		closing = withClampedDay(nextMonth.Year(), nextMonth.Month(), closingDay)
+	}
+	return closing
+}
+
+// PreviousStatementClosingDate returns the closing date of the cycle before
+// the one that closes on closing.
+func PreviousStatementClosingDate(closingDay int, closing time.Time) time.Time {
+	previousMonth := BeginningOfMonth(closing.Year(), int(closing.Month())).AddDate(0, -1, 0)
+	return withClampedDay(previousMonth.Year(), previousMonth.Month(), closingDay)
+}
+
+// LastClosedStatementDate returns the most recent closing date strictly before
+// reference. A cycle closing on reference itself is still open.
+func LastClosedStatementDate(closingDay int, reference time.Time) time.Time {
+	closing := StatementClosingDate(closingDay, reference)
+	if !closing.Before(NormalizeDateOnly(reference)) {
+		closing = PreviousStatementClosingDate(closingDay, closing)
+	}
+	return closing
+}
+
+// StatementDueDate returns the first bill due date after the statement
+// closes, moved off weekends and holidays by the wallet's business day rule.
+func StatementDueDate(wallet Wallet, closing time.Time) time.Time {
+	return AdjustToBusinessDay(nominalStatementDueDate(wallet, closing), wallet.BusinessDayRule, wallet.HolidayRegion)
+}
+
+func nominalStatementDueDate(wallet Wallet, closing time.Time) time.Time {
+	if rule := walletRecurrenceRule(wallet); rule != nil {
+		if due := rule.NextAfter(closing); due != nil {
+			return *due
+		}
+	}
+	dueDay := wallet.BillDueDay
+	if dueDay == 0 {
+		dueDay = 31
+	}
+	due := WalletDueDate(closing, dueDay)
+	if !due.After(closing) {
+		nextMonth := BeginningOfMonth(closing.Year(), int(closing.Month())).AddDate(0, 1, 0)
+		due = WalletDueDate(nextMonth, dueDay)
+	}
+	return due
+}
+
+// MinimumPaymentFor applies the wallet's minimum payment rule to a statement
+// balance: the larger of the percentage and the fixed floor, capped at the balance.
+func MinimumPaymentFor(wallet Wallet, balance float64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	minimum := balance * wallet.MinimumPaymentPercent / 100
+	if minimum < wallet.MinimumPaymentAmount {
+		minimum = wallet.MinimumPaymentAmount
+	}
+	if minimum > balance {
+		minimum = balance
+	}
+	return math.Round(minimum*100) / 100
+}
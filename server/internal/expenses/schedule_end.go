@@ -0,0 +1,253 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidEndDate              = errors.New("end date must be in YYYY-MM-DD format")
+	ErrInvalidRemainingOccurrences = errors.New("remaining occurrences cannot be negative")
+	ErrScheduleEndNotRecurring     = errors.New("end date and remaining occurrences require a recurring schedule")
+)
+
+// EndsBefore reports whether the type's end date falls before due, so that
+// the cycle due then is no longer part of the schedule.
+func (et ExpenseType) EndsBefore(due time.Time) bool {
+	return et.EndDate != nil && NormalizeDateOnly(due).After(NormalizeDateOnly(*et.EndDate))
+}
+
+// EndsBefore reports whether the wallet's bill schedule ends before due.
+func (w Wallet) EndsBefore(due time.Time) bool {
+	return w.EndDate != nil && NormalizeDateOnly(due).After(NormalizeDateOnly(*w.EndDate))
+}
+
+// IsFinalExpenseTypeDueDate reports whether the cycle due on due is the last
+// one: either a single occurrence remains or the following cycle falls after
+// the end date.
+func IsFinalExpenseTypeDueDate(expenseType ExpenseType, due time.Time) bool {
+	if expenseType.RemainingOccurrences != nil && *expenseType.RemainingOccurrences <= 1 {
+		return true
+	}
+	if expenseType.EndDate == nil {
+		return false
+	}
+	following, err := FollowingExpenseTypeDueDate(expenseType, due)
+	return err == nil && following == nil
+}
+
+// IsFinalWalletDueDate reports whether the bill due on due is the wallet's last.
+func IsFinalWalletDueDate(wallet Wallet, due time.Time) bool {
+	if wallet.RemainingOccurrences != nil && *wallet.RemainingOccurrences <= 1 {
+		return true
+	}
+	return wallet.EndsBefore(FollowingWalletDueDate(wallet, due))
+}
+
+// parseScheduleEnd validates the optional end of a schedule. Limits are only
+// accepted on a schedule that recurs.
+func parseScheduleEnd(endDate *string, remaining *int, recurring bool) (*time.Time, *int, error) {
+	var parsedEndDate *time.Time
+	if endDate != nil && strings.TrimSpace(*endDate) != "" {
+		parsed, err := ParseDateOnly(strings.TrimSpace(*endDate))
+		if err != nil {
+			return nil, nil, ErrInvalidEndDate
+		}
+		parsedEndDate = &parsed
+	}
+	if remaining != nil && *remaining < 0 {
+		return nil, nil, ErrInvalidRemainingOccurrences
+	}
+	if !recurring {
+		if parsedEndDate != nil || remaining != nil {
+			return nil, nil, ErrScheduleEndNotRecurring
+		}
+		return nil, nil, nil
+	}
+	return parsedEndDate, remaining, nil
+}
+
+// resolveScheduleEnd validates the end of a schedule submitted with an
+// update. Fields the update leaves out keep their stored values while the
+// schedule still recurs; an empty end date clears the stored one and
+// clearRemaining drops the occurrence count.
+func resolveScheduleEnd(endDate *string, remaining *int, clearRemaining bool, existingEndDate *time.Time, existingRemaining *int, recurring bool) (*time.Time, *int, error) {
+	parsedEndDate, parsedRemaining, err := parseScheduleEnd(endDate, remaining, recurring)
+	if err != nil || !recurring {
+		return parsedEndDate, parsedRemaining, err
+	}
+	if endDate == nil {
+		parsedEndDate = existingEndDate
+	}
+	if remaining == nil && !clearRemaining {
+		parsedRemaining = existingRemaining
+	}
+	return parsedEndDate, parsedRemaining, nil
+}
+
+// occurrenceLimit returns the occurrence limit and when it was set for a
+// submitted remaining count. Resubmitting the current count keeps the
+// original limit, so the cycles settled since then keep counting against it.
+func occurrenceLimit(remaining, currentRemaining, currentLimit *int, currentSetAt *time.Time, now time.Time) (*int, *time.Time) {
+	if remaining == nil {
+		return nil, nil
+	}
+	if currentLimit != nil && currentSetAt != nil && currentRemaining != nil && *currentRemaining == *remaining {
+		return currentLimit, currentSetAt
+	}
+	limit := *remaining
+	return &limit, &now
+}
+
+// remainingAfter returns how many occurrences of limit are left once settled
+// cycles have been paid.
+func remainingAfter(limit, settled int) int {
+	if settled >= limit {
+		return 0
+	}
+	return limit - settled
+}
+
+// countSettledCycles counts the paid cycles in a ledger whose expense or
+// payment was recorded at or after since. Records from before a limit was
+// set never use up one of its occurrences, and extra records in a cycle that
+// is already paid are not counted again.
+func countSettledCycles(tx *gorm.DB, userID uint, column string, ownerID uint, settlementTable, settlementColumn string, since time.Time) (int, error) {
+	var count int64
+	err := tx.Model(&RecurrenceOccurrence{}).
+		Joins("JOIN "+settlementTable+" ON "+settlementTable+".id = recurrence_occurrences."+settlementColumn+" AND "+settlementTable+".deleted_at IS NULL").
+		Where("recurrence_occurrences.user_id = ? AND recurrence_occurrences."+column+" = ? AND recurrence_occurrences.status = ?", userID, ownerID, OccurrenceStatusPaid).
+		Where(settlementTable+".created_at >= ?", since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count settled cycles: %w", err)
+	}
+	return int(count), nil
+}
+
+// latestPaidDueDate returns the due date of the latest paid cycle in a
+// ledger, or nil when none has been paid.
+func latestPaidDueDate(tx *gorm.DB, userID uint, column string, ownerID uint) (*time.Time, error) {
+	var occurrence RecurrenceOccurrence
+	err := tx.Where("user_id = ? AND "+column+" = ? AND status = ?", userID, ownerID, OccurrenceStatusPaid).Order("due_date DESC").First(&occurrence).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load latest paid occurrence: %w", err)
+	}
+	return &occurrence.DueDate, nil
+}
+
+// applyExpenseTypeScheduleEnd updates the type's remaining occurrences from
+// its ledger and stops it once it is exhausted: no occurrences remain, or
+// the cycle after the latest paid one falls past the end date. A type that
+// was stopped because its count ran out resumes when a settled cycle is
+// given back.
+func applyExpenseTypeScheduleEnd(tx *gorm.DB, userID uint, expenseType ExpenseType) error {
+	if expenseType.RecurringType == RecurringTypeNone || (expenseType.EndDate == nil && expenseType.RemainingOccurrences == nil) {
+		return nil
+	}
+	updates := map[string]interface{}{}
+	exhausted := false
+	if expenseType.RemainingOccurrences != nil {
+		if expenseType.OccurrenceLimit == nil || expenseType.OccurrenceLimitSetAt == nil {
+			// Limits set before settled cycles were counted start counting now.
+			expenseType.OccurrenceLimit = expenseType.RemainingOccurrences
+			now := time.Now()
+			expenseType.OccurrenceLimitSetAt = &now
+			updates["occurrence_limit"] = *expenseType.OccurrenceLimit
+			updates["occurrence_limit_set_at"] = now
+		}
+		settled, err := countSettledCycles(tx, userID, "expense_type_id", expenseType.ID, "expenses", "expense_id", *expenseType.OccurrenceLimitSetAt)
+		if err != nil {
+			return err
+		}
+		left := remainingAfter(*expenseType.OccurrenceLimit, settled)
+		if left != *expenseType.RemainingOccurrences {
+			updates["remaining_occurrences"] = left
+		}
+		// Only the count running out stops the schedule, so it can still be
+		// resumed by hand afterwards.
+		exhausted = left == 0 && *expenseType.RemainingOccurrences > 0
+		if left > 0 && expenseType.Stopped && *expenseType.RemainingOccurrences == 0 {
+			updates["stopped"] = false
+		}
+	}
+	if !exhausted && expenseType.EndDate != nil {
+		due, err := latestPaidDueDate(tx, userID, "expense_type_id", expenseType.ID)
+		if err != nil {
+			return err
+		}
+		if due != nil {
+			next, err := FollowingExpenseTypeDueDate(expenseType, *due)
+			exhausted = err == nil && next == nil
+		}
+	}
+	if exhausted && !expenseType.Stopped {
+		updates["stopped"] = true
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := tx.Model(&ExpenseType{}).Where("id = ? AND user_id = ?", expenseType.ID, userID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update expense type occurrences: %w", err)
+	}
+	return nil
+}
+
+// applyWalletScheduleEnd updates the wallet's remaining bills from its
+// ledger and stops it once it is exhausted, like
+// applyExpenseTypeScheduleEnd.
+func applyWalletScheduleEnd(tx *gorm.DB, userID uint, wallet Wallet) error {
+	if !wallet.IsCredit || wallet.BillPeriod == WalletPeriodNone || (wallet.EndDate == nil && wallet.RemainingOccurrences == nil) {
+		return nil
+	}
+	updates := map[string]interface{}{}
+	exhausted := false
+	if wallet.RemainingOccurrences != nil {
+		if wallet.OccurrenceLimit == nil || wallet.OccurrenceLimitSetAt == nil {
+			// Limits set before settled cycles were counted start counting now.
+			wallet.OccurrenceLimit = wallet.RemainingOccurrences
+			now := time.Now()
+			wallet.OccurrenceLimitSetAt = &now
+			updates["occurrence_limit"] = *wallet.OccurrenceLimit
+			updates["occurrence_limit_set_at"] = now
+		}
+		settled, err := countSettledCycles(tx, userID, "wallet_id", wallet.ID, "payments", "payment_id", *wallet.OccurrenceLimitSetAt)
+		if err != nil {
+			return err
+		}
+		left := remainingAfter(*wallet.OccurrenceLimit, settled)
+		if left != *wallet.RemainingOccurrences {
+			updates["remaining_occurrences"] = left
+		}
+		// Only the count running out stops the schedule, so it can still be
+		// resumed by hand afterwards.
+		exhausted = left == 0 && *wallet.RemainingOccurrences > 0
+		if left > 0 && wallet.Stopped && *wallet.RemainingOccurrences == 0 {
+			updates["stopped"] = false
+		}
+	}
+	if !exhausted && wallet.EndDate != nil {
+		due, err := latestPaidDueDate(tx, userID, "wallet_id", wallet.ID)
+		if err != nil {
+			return err
+		}
+		exhausted = due != nil && wallet.EndsBefore(FollowingWalletDueDate(wallet, *due))
+	}
+	if exhausted && !wallet.Stopped {
+		updates["stopped"] = true
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := tx.Model(&Wallet{}).Where("id = ? AND user_id = ?", wallet.ID, userID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update wallet occurrences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package expenses
+
+import (
+	"testing"
+)
+
+func TestNextExpenseTypeDueDateStopsAfterEndDate(t *testing.T) {
+	endDate := mustParseDate(t, "2025-03-20")
+	expenseType := ExpenseType{
+		RecurringType:   RecurringTypeFixedDay,
+		RecurringPeriod: RecurringPeriodMonthly,
+		RecurringDueDay: 15,
+		EndDate:         &endDate,
+	}
+
+	lastPaid := mustParseDate(t, "2025-02-15")
+	due, err := NextExpenseTypeDueDate(expenseType, mustParseDate(t, "2025-03-01"), &lastPaid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if due == nil || due.Format(DateOnlyLayout) != "2025-03-15" {
+		t.Fatalf("expected 2025-03-15, got %v", due)
+	}
+
+	lastPaid = mustParseDate(t, "2025-03-15")
+	due, err = NextExpenseTypeDueDate(expenseType, mustParseDate(t, "2025-03-16"), &lastPaid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if due != nil {
+		t.Fatalf("expected no due date after the end date, got %s", due.Format(DateOnlyLayout))
+	}
+}
+
+func TestIsFinalExpenseTypeDueDate(t *testing.T) {
+	endDate := mustParseDate(t, "2025-04-30")
+	one, three := 1, 3
+	tests := []struct {
+		name      string
+		ends      bool
+		remaining *int
+		due       string
+		want      bool
+	}{
+		{name: "unlimited", due: "2025-03-15", want: false},
+		{name: "one remaining", remaining: &one, due: "2025-03-15", want: true},
+		{name: "several remaining", remaining: &three, due: "2025-03-15", want: false},
+		{name: "following cycle within end date", ends: true, due: "2025-03-15", want: false},
+		{name: "following cycle after end date", ends: true, due: "2025-04-15", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expenseType := ExpenseType{
+				RecurringType:        RecurringTypeFixedDay,
+				RecurringPeriod:      RecurringPeriodMonthly,
+				RecurringDueDay:      15,
+				RemainingOccurrences: tt.remaining,
+			}
+			if tt.ends {
+				expenseType.EndDate = &endDate
+			}
+			if got := IsFinalExpenseTypeDueDate(expenseType, mustParseDate(t, tt.due)); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsFinalWalletDueDate(t *testing.T) {
+	endDate := mustParseDate(t, "2025-05-31")
+	wallet := Wallet{IsCredit: true, BillPeriod: WalletPeriodMonthly, BillDueDay: 25, EndDate: &endDate}
+
+	if IsFinalWalletDueDate(wallet, mustParseDate(t, "2025-04-25")) {
+		t.Fatal("expected the April bill not to be final")
+	}
+	if !IsFinalWalletDueDate(wallet, mustParseDate(t, "2025-05-25")) {
+		t.Fatal("expected the May bill to be final")
+	}
+	if !wallet.EndsBefore(mustParseDate(t, "2025-06-25")) {
+		t.Fatal("expected the June bill to fall after the end date")
+	}
+}
+
+func TestParseScheduleEnd(t *testing.T) {
+	zero, negative := 0, -1
+	tests := []struct {
+		name      string
+		endDate   *string
+		remaining *int
+		recurring bool
+		wantErr   error
+	}{
+		{name: "no limits", recurring: true},
+		{name: "blank end date", endDate: strPtr(" "), recurring: false},
+		{name: "end date", endDate: strPtr("2025-12-31"), recurring: true},
+		{name: "exhausted count", remaining: &zero, recurring: true},
+		{name: "invalid end date", endDate: strPtr("31/12/2025"), recurring: true, wantErr: ErrInvalidEndDate},
+		{name: "negative count", remaining: &negative, recurring: true, wantErr: ErrInvalidRemainingOccurrences},
+		{name: "not recurring", remaining: &zero, recurring: false, wantErr: ErrScheduleEndNotRecurring},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseScheduleEnd(tt.endDate, tt.remaining, tt.recurring)
+			if err != tt.wantErr {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResolveScheduleEnd(t *testing.T) {
+	storedEnd := mustParseDate(t, "2026-06-30")
+	storedRemaining, submitted := 12, 6
+
+	endDate, remaining, err := resolveScheduleEnd(nil, nil, false, &storedEnd, &storedRemaining, true)
+	if err != nil || endDate == nil || !endDate.Equal(storedEnd) || remaining == nil || *remaining != 12 {
+		t.Fatalf("expected the stored end to be kept, got %v %v %v", endDate, remaining, err)
+	}
+	endDate, remaining, err = resolveScheduleEnd(strPtr(""), nil, true, &storedEnd, &storedRemaining, true)
+	if err != nil || endDate != nil || remaining != nil {
+		t.Fatalf("expected the end to be cleared, got %v %v %v", endDate, remaining, err)
+	}
+	_, remaining, err = resolveScheduleEnd(nil, &submitted, false, &storedEnd, &storedRemaining, true)
+	if err != nil || remaining == nil || *remaining != 6 {
+		t.Fatalf("expected the submitted count, got %v %v", remaining, err)
+	}
+	endDate, remaining, err = resolveScheduleEnd(nil, nil, false, &storedEnd, &storedRemaining, false)
+	if err != nil || endDate != nil || remaining != nil {
+		t.Fatalf("expected the end to be dropped from a schedule that no longer recurs, got %v %v %v", endDate, remaining, err)
+	}
+}
+
+func strPtr(value string) *string {
+	return &value
+}
+
+func TestOccurrenceLimit(t *testing.T) {
+	setAt := mustParseDate(t, "2025-01-10")
+	now := mustParseDate(t, "2025-03-01")
+	limit, remaining, changed := 24, 20, 12
+
+	if gotLimit, gotSetAt := occurrenceLimit(nil, &remaining, &limit, &setAt, now); gotLimit != nil || gotSetAt != nil {
+		t.Fatalf("expected no limit, got %v %v", gotLimit, gotSetAt)
+	}
+	gotLimit, gotSetAt := occurrenceLimit(&remaining, &remaining, &limit, &setAt, now)
+	if gotLimit == nil || *gotLimit != 24 || gotSetAt == nil || !gotSetAt.Equal(setAt) {
+		t.Fatalf("expected the original limit to be kept, got %v %v", gotLimit, gotSetAt)
+	}
+	gotLimit, gotSetAt = occurrenceLimit(&changed, &remaining, &limit, &setAt, now)
+	if gotLimit == nil || *gotLimit != 12 || gotSetAt == nil || !gotSetAt.Equal(now) {
+		t.Fatalf("expected a new limit from now, got %v %v", gotLimit, gotSetAt)
+	}
+	gotLimit, gotSetAt = occurrenceLimit(&changed, nil, nil, nil, now)
+	if gotLimit == nil || *gotLimit != 12 || gotSetAt == nil || !gotSetAt.Equal(now) {
+		t.Fatalf("expected a new limit from now, got %v %v", gotLimit, gotSetAt)
+	}
+}
+
+func TestRemainingAfter(t *testing.T) {
+	tests := []struct{ limit, settled, want int }{
+		{limit: 24, settled: 0, want: 24},
+		{limit: 24, settled: 23, want: 1},
+		{limit: 24, settled: 24, want: 0},
+		{limit: 3, settled: 5, want: 0},
+	}
+	for _, tt := range tests {
+		if got := remainingAfter(tt.limit, tt.settled); got != tt.want {
+			t.Errorf("remainingAfter(%d, %d) = %d, want %d", tt.limit, tt.settled, got, tt.want)
+		}
+	}
+}
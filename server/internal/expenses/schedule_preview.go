@@ -0,0 +1,328 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSchedulePreviewCount = 6
+	MaxSchedulePreviewCount     = 36
+)
+
+var (
+	ErrInvalidPreviewCount   = errors.New("count must be between 1 and 36")
+	ErrInvalidPreviewFrom    = errors.New("from must be in YYYY-MM-DD format")
+	ErrPreviewNotRecurring   = errors.New("schedule preview needs a recurring schedule")
+	ErrPreviewNoBillPeriod   = errors.New("schedule preview needs a bill period or due date rule")
+	ErrInvalidPreviewNextDue = errors.New("next due day must be in YYYY-MM-DD format")
+)
+
+// ExpenseTypeSchedulePreviewRequest holds the schedule fields of an unsaved
+// expense type. From defaults to today.
+type ExpenseTypeSchedulePreviewRequest struct {
+	RecurringType   string  `json:"recurring_type"`
+	RecurringPeriod string  `json:"recurring_period"`
+	RecurringDueDay int     `json:"recurring_due_day"`
+	RecurrenceRule  string  `json:"recurrence_rule"`
+	BusinessDayRule string  `json:"business_day_rule"`
+	HolidayRegion   string  `json:"holiday_region"`
+	NextDueDay      *string `json:"next_due_day"`
+	From            string  `json:"from"`
+	Count           int     `json:"count"`
+}
+
+// WalletSchedulePreviewRequest holds the bill schedule fields of an unsaved
+// credit wallet. From defaults to today.
+type WalletSchedulePreviewRequest struct {
+	BillPeriod        string `json:"bill_period"`
+	BillDueDay        int    `json:"bill_due_day"`
+	DueRecurrenceRule string `json:"due_recurrence_rule"`
+	BusinessDayRule   string `json:"business_day_rule"`
+	HolidayRegion     string `json:"holiday_region"`
+	From              string `json:"from"`
+	Count             int    `json:"count"`
+}
+
+// PreviewDueDate is one projected due date. NominalDate is the date before
+// the business day rule moved it; Clamped means the due day does not exist
+// in that month and the last day of the month was used instead.
+type PreviewDueDate struct {
+	Date        string `json:"date"`
+	Weekday     string `json:"weekday"`
+	NominalDate string `json:"nominal_date"`
+	Clamped     bool   `json:"clamped"`
+	Shifted     bool   `json:"shifted"`
+}
+
+type SchedulePreview struct {
+	From     string           `json:"from"`
+	DueDates []PreviewDueDate `json:"due_dates"`
+	Warnings []string         `json:"warnings"`
+}
+
+// previewSchedule describes the schedule being previewed independently of
+// whether it belongs to an expense type or a wallet.
+type previewSchedule struct {
+	dueDay          int
+	periodMonths    int
+	hasRule         bool
+	flexible        bool
+	businessDayRule string
+	holidayRegion   string
+	first           func(nominal bool) *time.Time
+	following       func(due time.Time, nominal bool) *time.Time
+}
+
+// PreviewExpenseTypeSchedule projects the due dates of an unsaved expense
+// type with the same functions the dashboard and notifier use.
+func PreviewExpenseTypeSchedule(req ExpenseTypeSchedulePreviewRequest, today time.Time) (*SchedulePreview, error) {
+	count, from, err := previewRange(req.Count, req.From, today)
+	if err != nil {
+		return nil, err
+	}
+	recurringType := normalizeRecurringType(req.RecurringType)
+	recurringPeriod := normalizeRecurringPeriod(req.RecurringPeriod)
+	if err := validateRecurring(recurringType, recurringPeriod, req.RecurringDueDay, req.RecurrenceRule, ReminderTypeNone); err != nil {
+		return nil, err
+	}
+	if recurringType == RecurringTypeNone {
+		return nil, ErrPreviewNotRecurring
+	}
+	recurrenceRule, err := normalizeStoredRecurrenceRule(req.RecurrenceRule, "")
+	if err != nil {
+		return nil, err
+	}
+	businessDayRule, holidayRegion, err := normalizeBusinessDaySettings(req.BusinessDayRule, req.HolidayRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	expenseType := ExpenseType{
+		RecurringType:   recurringType,
+		RecurringPeriod: recurringPeriod,
+		RecurringDueDay: req.RecurringDueDay,
+		RecurrenceRule:  recurrenceRule,
+		BusinessDayRule: businessDayRule,
+		HolidayRegion:   holidayRegion,
+	}
+	if req.NextDueDay != nil && strings.TrimSpace(*req.NextDueDay) != "" {
+		nextDueDay, err := ParseDateOnly(strings.TrimSpace(*req.NextDueDay))
+		if err != nil {
+			return nil, ErrInvalidPreviewNextDue
+		}
+		expenseType.NextDueDay = &nextDueDay
+	}
+	nominalType := expenseType
+	nominalType.BusinessDayRule = BusinessDayRuleNone
+	pick := func(nominal bool) ExpenseType {
+		if nominal {
+			return nominalType
+		}
+		return expenseType
+	}
+
+	schedule := previewSchedule{
+		dueDay:          req.RecurringDueDay,
+		periodMonths:    PeriodMonths(recurringPeriod),
+		hasRule:         recurrenceRule != "",
+		flexible:        recurringType == RecurringTypeFlexible,
+		businessDayRule: businessDayRule,
+		holidayRegion:   holidayRegion,
+		first: func(nominal bool) *time.Time {
+			due, err := NextExpenseTypeDueDate(pick(nominal), from, nil)
+			if err != nil {
+				return nil
+			}
+			return due
+		},
+		following: func(due time.Time, nominal bool) *time.Time {
+			next, err := FollowingExpenseTypeDueDate(pick(nominal), due)
+			if err != nil {
+				return nil
+			}
+			return next
+		},
+	}
+	if schedule.flexible {
+		schedule.dueDay = 0
+	}
+	return buildSchedulePreview(schedule, from, count), nil
+}
+
+// PreviewWalletSchedule projects the bill due dates of an unsaved credit
+// wallet with the same functions the dashboard and notifier use.
+func PreviewWalletSchedule(req WalletSchedulePreviewRequest, today time.Time) (*SchedulePreview, error) {
+	count, from, err := previewRange(req.Count, req.From, today)
+	if err != nil {
+		return nil, err
+	}
+	billPeriod := normalizeWalletPeriod(req.BillPeriod)
+	if !isValidWalletPeriod(billPeriod) {
+		return nil, ErrInvalidWalletPeriod
+	}
+	if req.BillDueDay < 0 || req.BillDueDay > 31 {
+		return nil, ErrInvalidWalletDueDay
+	}
+	dueRule, err := normalizeWalletDueRule(true, req.DueRecurrenceRule, "")
+	if err != nil {
+		return nil, err
+	}
+	if billPeriod == WalletPeriodNone && dueRule == "" {
+		return nil, ErrPreviewNoBillPeriod
+	}
+	businessDayRule, holidayRegion, err := normalizeBusinessDaySettings(req.BusinessDayRule, req.HolidayRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := Wallet{
+		IsCredit:          true,
+		BillPeriod:        billPeriod,
+		BillDueDay:        req.BillDueDay,
+		DueRecurrenceRule: dueRule,
+		BusinessDayRule:   businessDayRule,
+		HolidayRegion:     holidayRegion,
+	}
+	nominalWallet := wallet
+	nominalWallet.BusinessDayRule = BusinessDayRuleNone
+	pick := func(nominal bool) Wallet {
+		if nominal {
+			return nominalWallet
+		}
+		return wallet
+	}
+
+	periodMonths := PeriodMonths(billPeriod)
+	if periodMonths <= 0 {
+		periodMonths = 1
+	}
+	schedule := previewSchedule{
+		dueDay:          req.BillDueDay,
+		periodMonths:    periodMonths,
+		hasRule:         dueRule != "",
+		businessDayRule: businessDayRule,
+		holidayRegion:   holidayRegion,
+		first: func(nominal bool) *time.Time {
+			due := NextWalletDueDate(pick(nominal), from, nil)
+			return &due
+		},
+		following: func(due time.Time, nominal bool) *time.Time {
+			next := FollowingWalletDueDate(pick(nominal), due)
+			return &next
+		},
+	}
+	return buildSchedulePreview(schedule, from, count), nil
+}
+
+func previewRange(count int, from string, today time.Time) (int, time.Time, error) {
+	if count == 0 {
+		count = DefaultSchedulePreviewCount
+	}
+	if count < 1 || count > MaxSchedulePreviewCount {
+		return 0, time.Time{}, ErrInvalidPreviewCount
+	}
+	start := NormalizeDateOnly(today)
+	if strings.TrimSpace(from) != "" {
+		parsed, err := ParseDateOnly(strings.TrimSpace(from))
+		if err != nil {
+			return 0, time.Time{}, ErrInvalidPreviewFrom
+		}
+		start = parsed
+	}
+	return count, start, nil
+}
+
+// buildSchedulePreview walks the schedule twice, with and without the
+// business day rule, and pairs the results to report moved and clamped
+// dates alongside warnings about the configuration.
+func buildSchedulePreview(schedule previewSchedule, from time.Time, count int) *SchedulePreview {
+	adjusted := walkPreviewSchedule(schedule, count, false)
+	nominal := walkPreviewSchedule(schedule, count, true)
+	if len(nominal) < len(adjusted) {
+		adjusted = adjusted[:len(nominal)]
+	}
+
+	preview := &SchedulePreview{
+		From:     from.Format(DateOnlyLayout),
+		DueDates: make([]PreviewDueDate, 0, len(adjusted)),
+		Warnings: make([]string, 0),
+	}
+	clampedMonths := make([]string, 0)
+	nonBusinessDays := 0
+	for index, date := range adjusted {
+		nominalDate := nominal[index]
+		clamped := !schedule.hasRule && schedule.dueDay > nominalDate.Day()
+		if clamped {
+			clampedMonths = append(clampedMonths, fmt.Sprintf("%s (%d)", nominalDate.Format("Jan 2006"), nominalDate.Day()))
+		}
+		if !IsBusinessDay(date, schedule.holidayRegion) {
+			nonBusinessDays++
+		}
+		preview.DueDates = append(preview.DueDates, PreviewDueDate{
+			Date:        date.Format(DateOnlyLayout),
+			Weekday:     date.Weekday().String(),
+			NominalDate: nominalDate.Format(DateOnlyLayout),
+			Clamped:     clamped,
+			Shifted:     !date.Equal(nominalDate),
+		})
+	}
+
+	if len(clampedMonths) > 0 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("Day %d clamps to the last day of the month in %s.", schedule.dueDay, strings.Join(clampedMonths, ", ")))
+	}
+	if skipped := skippedPreviewMonths(nominal, schedule); len(skipped) > 0 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("No due date falls in %s.", strings.Join(skipped, ", ")))
+	}
+	if schedule.businessDayRule == BusinessDayRuleNone && nonBusinessDays > 0 {
+		subject := fmt.Sprintf("%d due dates fall", nonBusinessDays)
+		if nonBusinessDays == 1 {
+			subject = "1 due date falls"
+		}
+		preview.Warnings = append(preview.Warnings, subject+" on a weekend or holiday; set a business day rule to move them.")
+	}
+	if len(adjusted) < count {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("The schedule ends after %d due dates.", len(adjusted)))
+	}
+	if end, ok := holidayCalendarEnd(schedule.holidayRegion); ok && len(adjusted) > 0 && adjusted[len(adjusted)-1].After(end) {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("The %s holiday calendar only covers dates up to %s; later dates only skip weekends.", schedule.holidayRegion, end.Format(DateOnlyLayout)))
+	}
+	if schedule.flexible {
+		preview.Warnings = append(preview.Warnings, "Flexible due dates count from each payment; dates after the first assume payment on the due date.")
+	}
+	return preview
+}
+
+func walkPreviewSchedule(schedule previewSchedule, count int, nominal bool) []time.Time {
+	dates := make([]time.Time, 0, count)
+	due := schedule.first(nominal)
+	for due != nil && len(dates) < count {
+		dates = append(dates, *due)
+		next := schedule.following(*due, nominal)
+		if next == nil || !next.After(*due) {
+			break
+		}
+		due = next
+	}
+	return dates
+}
+
+// skippedPreviewMonths lists months a month-based schedule without a rule
+// should have hit but did not, such as a February skipped after a payment on
+// the 31st of January.
+func skippedPreviewMonths(dates []time.Time, schedule previewSchedule) []string {
+	skipped := make([]string, 0)
+	if schedule.hasRule || schedule.periodMonths <= 0 {
+		return skipped
+	}
+	for index := 1; index < len(dates); index++ {
+		expected := BeginningOfMonth(dates[index-1].Year(), int(dates[index-1].Month())).AddDate(0, schedule.periodMonths, 0)
+		actual := BeginningOfMonth(dates[index].Year(), int(dates[index].Month()))
+		for month := expected; month.Before(actual); month = month.AddDate(0, schedule.periodMonths, 0) {
+			skipped = append(skipped, month.Format("Jan 2006"))
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,94 @@
+package expenses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreviewExpenseTypeSchedule(t *testing.T) {
+	today := mustParseDate(t, "2025-01-20")
+
+	tests := []struct {
+		name         string
+		req          ExpenseTypeSchedulePreviewRequest
+		wantDates    []string
+		wantClamped  []bool
+		wantWarnings []string
+		wantErr      error
+	}{
+		{
+			name:         "day 31 clamps and skips months",
+			req:          ExpenseTypeSchedulePreviewRequest{RecurringType: RecurringTypeFixedDay, RecurringPeriod: RecurringPeriodMonthly, RecurringDueDay: 31, From: "2025-02-01", Count: 3},
+			wantDates:    []string{"2025-02-28", "2025-03-31", "2025-05-31"},
+			wantClamped:  []bool{true, false, false},
+			wantWarnings: []string{"Day 31 clamps to the last day of the month in Feb 2025 (28).", "No due date falls in Apr 2025.", "1 due date falls on a weekend or holiday; set a business day rule to move them."},
+		},
+		{
+			name:         "rule that ends",
+			req:          ExpenseTypeSchedulePreviewRequest{RecurringType: RecurringTypeFixedDay, RecurrenceRule: "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYMONTHDAY=10;COUNT=3", Count: 4},
+			wantDates:    []string{"2025-02-10", "2025-03-10"},
+			wantClamped:  []bool{false, false},
+			wantWarnings: []string{"The schedule ends after 2 due dates."},
+		},
+		{
+			name:    "not recurring",
+			req:     ExpenseTypeSchedulePreviewRequest{RecurringType: RecurringTypeNone},
+			wantErr: ErrPreviewNotRecurring,
+		},
+		{
+			name:    "count out of range",
+			req:     ExpenseTypeSchedulePreviewRequest{RecurringType: RecurringTypeFlexible, RecurringPeriod: RecurringPeriodMonthly, Count: MaxSchedulePreviewCount + 1},
+			wantErr: ErrInvalidPreviewCount,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preview, err := PreviewExpenseTypeSchedule(test.req, today)
+			if err != test.wantErr {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			dates := make([]string, 0, len(preview.DueDates))
+			clamped := make([]bool, 0, len(preview.DueDates))
+			for _, due := range preview.DueDates {
+				dates = append(dates, due.Date)
+				clamped = append(clamped, due.Clamped)
+			}
+			if !reflect.DeepEqual(dates, test.wantDates) {
+				t.Fatalf("expected dates %v, got %v", test.wantDates, dates)
+			}
+			if !reflect.DeepEqual(clamped, test.wantClamped) {
+				t.Fatalf("expected clamped %v, got %v", test.wantClamped, clamped)
+			}
+			if !reflect.DeepEqual(preview.Warnings, test.wantWarnings) {
+				t.Fatalf("expected warnings %q, got %q", test.wantWarnings, preview.Warnings)
+			}
+		})
+	}
+}
+
+func TestPreviewWalletScheduleBusinessDay(t *testing.T) {
+	preview, err := PreviewWalletSchedule(WalletSchedulePreviewRequest{
+		BillPeriod:      WalletPeriodMonthly,
+		BillDueDay:      15,
+		BusinessDayRule: BusinessDayRuleNext,
+		From:            "2025-03-01",
+		Count:           2,
+	}, mustParseDate(t, "2025-03-01"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PreviewDueDate{
+		{Date: "2025-03-17", Weekday: "Monday", NominalDate: "2025-03-15", Shifted: true},
+		{Date: "2025-04-15", Weekday: "Tuesday", NominalDate: "2025-04-15"},
+	}
+	if !reflect.DeepEqual(preview.DueDates, want) {
+		t.Fatalf("expected %+v, got %+v", want, preview.DueDates)
+	}
+	if len(preview.Warnings) != 0 {
+		t.Fatalf("expected no warnings, got %q", preview.Warnings)
+	}
+}
@@ -0,0 +1,168 @@
+package expenses
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatementClosingDate(t *testing.T) {
+	tests := []struct {
+		name       string
+		closingDay int
+		reference  string
+		want       string
+	}{
+		{name: "before closing day stays in month", closingDay: 20, reference: "2025-03-05", want: "2025-03-20"},
+		{name: "on closing day closes same day", closingDay: 20, reference: "2025-03-20", want: "2025-03-20"},
+		{name: "after closing day rolls to next month", closingDay: 20, reference: "2025-03-21", want: "2025-04-20"},
+		{name: "day 31 clamps in short months", closingDay: 31, reference: "2025-02-10", want: "2025-02-28"},
+		{name: "clamped month rolls without skipping", closingDay: 31, reference: "2025-01-31", want: "2025-01-31"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := StatementClosingDate(test.closingDay, mustParseDate(t, test.reference))
+			if got.Format(DateOnlyLayout) != test.want {
+				t.Fatalf("StatementClosingDate(%d, %s) = %s, want %s", test.closingDay, test.reference, got.Format(DateOnlyLayout), test.want)
+			}
+		})
+	}
+}
+
+func TestLastClosedStatementDate(t *testing.T) {
+	got := LastClosedStatementDate(20, mustParseDate(t, "2025-03-20"))
+	if got.Format(DateOnlyLayout) != "2025-02-20" {
+		t.Fatalf("cycle closing today should still be open, got %s", got.Format(DateOnlyLayout))
+	}
+	got = LastClosedStatementDate(20, mustParseDate(t, "2025-03-21"))
+	if got.Format(DateOnlyLayout) != "2025-03-20" {
+		t.Fatalf("LastClosedStatementDate = %s, want 2025-03-20", got.Format(DateOnlyLayout))
+	}
+}
+
+func TestStatementDueDate(t *testing.T) {
+	wallet := Wallet{BillDueDay: 5}
+	got := StatementDueDate(wallet, mustParseDate(t, "2025-03-20"))
+	if got.Format(DateOnlyLayout) != "2025-04-05" {
+		t.Fatalf("StatementDueDate = %s, want 2025-04-05", got.Format(DateOnlyLayout))
+	}
+	wallet.BillDueDay = 28
+	got = StatementDueDate(wallet, mustParseDate(t, "2025-03-20"))
+	if got.Format(DateOnlyLayout) != "2025-03-28" {
+		t.Fatalf("StatementDueDate = %s, want 2025-03-28", got.Format(DateOnlyLayout))
+	}
+}
+
+func TestMinimumPaymentFor(t *testing.T) {
+	wallet := Wallet{MinimumPaymentPercent: 2, MinimumPaymentAmount: 50}
+	tests := []struct {
+		balance float64
+		want    float64
+	}{
+		{balance: 0, want: 0},
+		{balance: 30, want: 30},
+		{balance: 1000, want: 50},
+		{balance: 10000, want: 200},
+	}
+	for _, test := range tests {
+		if got := MinimumPaymentFor(wallet, test.balance); got != test.want {
+			t.Fatalf("MinimumPaymentFor(%.2f) = %.2f, want %.2f", test.balance, got, test.want)
+		}
+	}
+}
+
+func mustParseDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := ParseDateOnly(value)
+	if err != nil {
+		t.Fatalf("ParseDateOnly(%q) returned error: %v", value, err)
+	}
+	return parsed
+}
+
+func TestNextExpenseTypeDueDateWithRecurrenceRule(t *testing.T) {
+	lastBusinessDay := "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"
+	tests := []struct {
+		name        string
+		expenseType ExpenseType
+		reference   string
+		lastExpense string
+		want        string
+	}{
+		{
+			name:        "first occurrence without expenses",
+			expenseType: ExpenseType{RecurringType: RecurringTypeFixedDay, RecurrenceRule: lastBusinessDay},
+			reference:   "2025-05-10",
+			want:        "2025-05-30",
+		},
+		{
+			name:        "advances past the last expense",
+			expenseType: ExpenseType{RecurringType: RecurringTypeFixedDay, RecurrenceRule: lastBusinessDay},
+			reference:   "2025-05-10",
+			lastExpense: "2025-05-30",
+			want:        "2025-06-30",
+		},
+		{
+			name:        "fifteenth and last day",
+			expenseType: ExpenseType{RecurringType: RecurringTypeFixedDay, RecurrenceRule: "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYMONTHDAY=15,-1"},
+			reference:   "2025-02-16",
+			want:        "2025-02-28",
+		},
+		{
+			name:        "period rules still apply without a rule",
+			expenseType: ExpenseType{RecurringType: RecurringTypeFixedDay, RecurringPeriod: RecurringPeriodMonthly, RecurringDueDay: 31},
+			reference:   "2025-05-10",
+			lastExpense: "2025-01-15",
+			want:        "2025-02-28",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var lastExpense *time.Time
+			if test.lastExpense != "" {
+				date := mustParseDate(t, test.lastExpense)
+				lastExpense = &date
+			}
+			got, err := NextExpenseTypeDueDate(test.expenseType, mustParseDate(t, test.reference), lastExpense)
+			if err != nil {
+				t.Fatalf("NextExpenseTypeDueDate returned error: %v", err)
+			}
+			if got == nil || got.Format(DateOnlyLayout) != test.want {
+				t.Fatalf("NextExpenseTypeDueDate = %v, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAdvanceNextDueDayFromEndedRule(t *testing.T) {
+	got, err := AdvanceNextDueDayFrom(mustParseDate(t, "2025-03-11"), RecurringTypeFixedDay, RecurringPeriodNone, 0, "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYDAY=2TU;COUNT=3")
+	if err != nil {
+		t.Fatalf("AdvanceNextDueDayFrom returned error: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("rule with COUNT=3 should have ended, got %s", got.Format(DateOnlyLayout))
+	}
+}
+
+func TestNextWalletDueDateWithRecurrenceRule(t *testing.T) {
+	wallet := Wallet{IsCredit: true, BillDueDay: 5, DueRecurrenceRule: "DTSTART:20250101\nRRULE:FREQ=MONTHLY;BYDAY=2TU"}
+	got := NextWalletDueDate(wallet, mustParseDate(t, "2025-03-20"), nil)
+	if got.Format(DateOnlyLayout) != "2025-03-11" {
+		t.Fatalf("NextWalletDueDate without payment = %s, want 2025-03-11", got.Format(DateOnlyLayout))
+	}
+	got = NextWalletDueDate(wallet, mustParseDate(t, "2025-03-20"), &Payment{ID: 1, Date: mustParseDate(t, "2025-03-11")})
+	if got.Format(DateOnlyLayout) != "2025-04-08" {
+		t.Fatalf("NextWalletDueDate after payment = %s, want 2025-04-08", got.Format(DateOnlyLayout))
+	}
+	got = StatementDueDate(wallet, mustParseDate(t, "2025-03-20"))
+	if got.Format(DateOnlyLayout) != "2025-04-08" {
+		t.Fatalf("StatementDueDate = %s, want 2025-04-08", got.Format(DateOnlyLayout))
+	}
+
+	wallet.IsCredit = false
+	got = NextWalletDueDate(wallet, mustParseDate(t, "2025-03-20"), nil)
+	if got.Format(DateOnlyLayout) != "2025-03-05" {
+		t.Fatalf("non-credit wallets ignore the rule, got %s", got.Format(DateOnlyLayout))
+	}
+}
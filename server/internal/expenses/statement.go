@@ -0,0 +1,26 @@
+package expenses
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statement is one closed billing cycle of a credit wallet. Expenses dated
+// inside the cycle window are attached to it when the cycle closes.
+type Statement struct {
+	ID               uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	WalletID         uint           `json:"wallet_id" gorm:"type:bigint;not null;index"`
+	PeriodStart      time.Time      `json:"period_start" gorm:"type:date;not null"`
+	ClosingDate      time.Time      `json:"closing_date" gorm:"type:date;not null;index"`
+	DueDate          time.Time      `json:"due_date" gorm:"type:date;not null;index"`
+	StatementBalance float64        `json:"statement_balance" gorm:"type:numeric(12,2);not null;default:0"`
+	MinimumPayment   float64        `json:"minimum_payment" gorm:"type:numeric(12,2);not null;default:0"`
+	UserID           uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Wallet   Wallet    `json:"wallet,omitempty" gorm:"foreignKey:WalletID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Expenses []Expense `json:"expenses,omitempty" gorm:"foreignKey:StatementID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
@@ -0,0 +1,55 @@
+package expenses
+
+import (
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type StatementHandler struct {
+	service *StatementService
+}
+
+func NewStatementHandler(service *StatementService) *StatementHandler {
+	return &StatementHandler{service: service}
+}
+
+func (h *StatementHandler) ListWalletStatements(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	response, err := h.service.ListStatements(userID, uint(walletID))
+	if err != nil {
+		return h.statementError(c, err, "Failed to list statements")
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *StatementHandler) GetStatement(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	statementID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid statement ID"})
+	}
+	statement, err := h.service.GetStatement(userID, uint(statementID))
+	if err != nil {
+		return h.statementError(c, err, "Failed to get statement")
+	}
+	return c.JSON(http.StatusOK, statement)
+}
+
+func (h *StatementHandler) statementError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case ErrStatementNotFound, ErrWalletNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case ErrStatementsNotEnabled:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,255 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxStatementCycles bounds how many cycles a single sync may close, so a
+// wallet with very old unassigned expenses cannot loop for long.
+const maxStatementCycles = 240
+
+var (
+	ErrStatementNotFound           = errors.New("statement not found")
+	ErrStatementsNotEnabled        = errors.New("wallet does not have a statement closing day")
+	ErrInvalidStatementClosingDay  = errors.New("statement closing day must be between 0 and 31")
+	ErrStatementClosingDayNoCredit = errors.New("statement closing day is only supported on credit wallets")
+	ErrInvalidMinimumPayment       = errors.New("minimum payment settings must be between 0 and 100 percent and not negative")
+)
+
+type StatementService struct {
+	db *gorm.DB
+}
+
+type StatementListResponse struct {
+	Statements []Statement `json:"statements"`
+	Total      int         `json:"total"`
+}
+
+func NewStatementService(db *gorm.DB) *StatementService {
+	return &StatementService{db: db}
+}
+
+// ListStatements closes any finished cycles for the wallet and returns its
+// statements, newest first.
+func (s *StatementService) ListStatements(userID, walletID uint) (*StatementListResponse, error) {
+	if err := s.SyncStatements(userID, walletID, time.Now()); err != nil {
+		return nil, err
+	}
+	var statements []Statement
+	if err := s.db.Where("user_id = ? AND wallet_id = ?", userID, walletID).Order("closing_date DESC").Find(&statements).Error; err != nil {
+		return nil, fmt.Errorf("failed to list statements: %w", err)
+	}
+	return &StatementListResponse{Statements: statements, Total: len(statements)}, nil
+}
+
+func (s *StatementService) GetStatement(userID, statementID uint) (*Statement, error) {
+	var statement Statement
+	err := s.db.Preload("Wallet").
+		Preload("Expenses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("date DESC, created_at DESC")
+		}).
+		Preload("Expenses.ExpenseType").
+		Where("id = ? AND user_id = ?", statementID, userID).
+		First(&statement).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStatementNotFound
+		}
+		return nil, fmt.Errorf("failed to get statement: %w", err)
+	}
+	return &statement, nil
+}
+
+// SyncStatements creates a statement for every cycle that closed before
+// reference and has not been generated yet. Cycles without expenses are skipped.
+func (s *StatementService) SyncStatements(userID, walletID uint, reference time.Time) error {
+	var wallet Wallet
+	if err := s.db.Where("id = ? AND user_id = ?", walletID, userID).First(&wallet).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWalletNotFound
+		}
+		return fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if !wallet.IsCredit || wallet.StatementClosingDay == 0 {
+		return ErrStatementsNotEnabled
+	}
+
+	lastClosed := LastClosedStatementDate(wallet.StatementClosingDay, reference)
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var start time.Time
+		var latest Statement
+		err := tx.Where("user_id = ? AND wallet_id = ?", userID, walletID).Order("closing_date DESC").First(&latest).Error
+		switch {
+		case err == nil:
+			start = NormalizeDateOnly(latest.ClosingDate).AddDate(0, 0, 1)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			var earliest Expense
+			err := tx.Where("user_id = ? AND wallet_id = ? AND statement_id IS NULL", userID, walletID).Order("date ASC").First(&earliest).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to find first statement expense: %w", err)
+			}
+			closing := StatementClosingDate(wallet.StatementClosingDay, earliest.Date)
+			start = PreviousStatementClosingDate(wallet.StatementClosingDay, closing).AddDate(0, 0, 1)
+		default:
+			return fmt.Errorf("failed to load latest statement: %w", err)
+		}
+
+		for cycle := 0; cycle < maxStatementCycles; cycle++ {
+			closing := StatementClosingDate(wallet.StatementClosingDay, start)
+			if closing.After(lastClosed) {
+				return nil
+			}
+			if _, err := closeCycle(tx, userID, wallet, start, closing); err != nil {
+				return err
+			}
+			start = closing.AddDate(0, 0, 1)
+		}
+		return nil
+	})
+}
+
+// closeCycle creates the statement for one cycle from the unassigned
+// expenses dated inside it. It returns nil when the cycle has no expenses.
+func closeCycle(tx *gorm.DB, userID uint, wallet Wallet, start, closing time.Time) (*Statement, error) {
+	cycleExpenses := tx.Model(&Expense{}).Where("user_id = ? AND wallet_id = ? AND statement_id IS NULL AND date >= ? AND date <= ?", userID, wallet.ID, start, closing)
+
+	var count int64
+	if err := cycleExpenses.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count statement expenses: %w", err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	var balance float64
+	if err := cycleExpenses.Session(&gorm.Session{}).Select("COALESCE(SUM(amount), 0)").Scan(&balance).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum statement expenses: %w", err)
+	}
+
+	statement := Statement{
+		WalletID:         wallet.ID,
+		PeriodStart:      start,
+		ClosingDate:      closing,
+		DueDate:          StatementDueDate(wallet, closing),
+		StatementBalance: balance,
+		MinimumPayment:   MinimumPaymentFor(wallet, balance),
+		UserID:           userID,
+	}
+	if err := tx.Create(&statement).Error; err != nil {
+		return nil, fmt.Errorf("failed to create statement: %w", err)
+	}
+	if err := cycleExpenses.Session(&gorm.Session{}).Update("statement_id", statement.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to attach statement expenses: %w", err)
+	}
+	return &statement, nil
+}
+
+// restateExpense keeps closed statements in line with an expense that was
+// created, edited or deleted. The expense is attached to the statement of
+// the closed cycle containing its date, creating that statement if the cycle
+// had none, and both its previous and its new statement are recalculated.
+// Pass a nil expense after deleting one.
+func restateExpense(tx *gorm.DB, userID uint, previousStatementID *uint, expense *Expense) error {
+	var statementID *uint
+	if expense != nil {
+		statement, err := closedStatementFor(tx, userID, *expense)
+		if err != nil {
+			return err
+		}
+		if statement != nil {
+			statementID = &statement.ID
+		}
+		if !sameOptionalID(expense.StatementID, statementID) {
+			if err := tx.Model(&Expense{}).Where("id = ? AND user_id = ?", expense.ID, userID).Update("statement_id", statementID).Error; err != nil {
+				return fmt.Errorf("failed to attach expense to statement: %w", err)
+			}
+			expense.StatementID = statementID
+		}
+	}
+	if previousStatementID != nil && !sameOptionalID(previousStatementID, statementID) {
+		if err := recalcStatement(tx, userID, *previousStatementID); err != nil {
+			return err
+		}
+	}
+	if statementID != nil {
+		return recalcStatement(tx, userID, *statementID)
+	}
+	return nil
+}
+
+// closedStatementFor returns the statement of the closed cycle that contains
+// the expense's date, or nil when the expense is not on a statement wallet or
+// its cycle has not been closed yet.
+func closedStatementFor(tx *gorm.DB, userID uint, expense Expense) (*Statement, error) {
+	if expense.WalletID == nil {
+		return nil, nil
+	}
+	var wallet Wallet
+	if err := tx.Where("id = ? AND user_id = ?", *expense.WalletID, userID).First(&wallet).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if !wallet.IsCredit || wallet.StatementClosingDay == 0 {
+		return nil, nil
+	}
+
+	date := NormalizeDateOnly(expense.Date)
+	var statement Statement
+	err := tx.Where("user_id = ? AND wallet_id = ? AND period_start <= ? AND closing_date >= ?", userID, wallet.ID, date, date).First(&statement).Error
+	if err == nil {
+		return &statement, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find statement: %w", err)
+	}
+
+	// Cycles after the latest statement are closed by SyncStatements.
+	var latest Statement
+	err = tx.Where("user_id = ? AND wallet_id = ?", userID, wallet.ID).Order("closing_date DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load latest statement: %w", err)
+	}
+	if date.After(NormalizeDateOnly(latest.ClosingDate)) {
+		return nil, nil
+	}
+	closing := StatementClosingDate(wallet.StatementClosingDay, date)
+	start := PreviousStatementClosingDate(wallet.StatementClosingDay, closing).AddDate(0, 0, 1)
+	return closeCycle(tx, userID, wallet, start, closing)
+}
+
+// recalcStatement recomputes a statement's balance and minimum payment from
+// the expenses attached to it.
+func recalcStatement(tx *gorm.DB, userID, statementID uint) error {
+	var statement Statement
+	if err := tx.Preload("Wallet").Where("id = ? AND user_id = ?", statementID, userID).First(&statement).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get statement: %w", err)
+	}
+	var balance float64
+	if err := tx.Model(&Expense{}).Where("user_id = ? AND statement_id = ?", userID, statementID).Select("COALESCE(SUM(amount), 0)").Scan(&balance).Error; err != nil {
+		return fmt.Errorf("failed to sum statement expenses: %w", err)
+	}
+	updates := map[string]interface{}{
+		"statement_balance": balance,
+		"minimum_payment":   MinimumPaymentFor(statement.Wallet, balance),
+	}
+	if err := tx.Model(&Statement{}).Where("id = ?", statementID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update statement balance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package expenses
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createStatementWallet(t *testing.T, db *gorm.DB, userID uint) (Wallet, ExpenseType) {
+	t.Helper()
+	wallet := Wallet{Name: "Card", IsCredit: true, StatementClosingDay: 15, BillDueDay: 5, MinimumPaymentPercent: 10, UserID: userID}
+	expenseType := ExpenseType{Name: "Groceries", RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&wallet).Error)
+	require.NoError(t, db.Create(&expenseType).Error)
+	return wallet, expenseType
+}
+
+func createStatementExpense(t *testing.T, db *gorm.DB, userID uint, wallet Wallet, expenseType ExpenseType, amount float64, date string) Expense {
+	t.Helper()
+	expense := Expense{ExpenseTypeID: expenseType.ID, WalletID: &wallet.ID, Amount: amount, Date: mustParseDate(t, date), UserID: userID}
+	require.NoError(t, db.Create(&expense).Error)
+	return expense
+}
+
+func loadStatements(t *testing.T, db *gorm.DB, walletID uint) []Statement {
+	t.Helper()
+	var statements []Statement
+	require.NoError(t, db.Where("wallet_id = ?", walletID).Order("closing_date ASC").Find(&statements).Error)
+	return statements
+}
+
+func TestStatementService_SyncStatementsClosingDayCutoff(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	wallet, expenseType := createStatementWallet(t, db, userID)
+	onClosingDay := createStatementExpense(t, db, userID, wallet, expenseType, 100, "2025-01-15")
+	createStatementExpense(t, db, userID, wallet, expenseType, 50, "2025-01-10")
+	afterClosingDay := createStatementExpense(t, db, userID, wallet, expenseType, 30, "2025-01-16")
+	open := createStatementExpense(t, db, userID, wallet, expenseType, 20, "2025-02-16")
+
+	service := NewStatementService(db)
+	require.NoError(t, service.SyncStatements(userID, wallet.ID, mustParseDate(t, "2025-02-20")))
+
+	statements := loadStatements(t, db, wallet.ID)
+	require.Len(t, statements, 2)
+	assert.Equal(t, "2024-12-16", statements[0].PeriodStart.Format(DateOnlyLayout))
+	assert.Equal(t, "2025-01-15", statements[0].ClosingDate.Format(DateOnlyLayout))
+	assert.Equal(t, "2025-02-05", statements[0].DueDate.Format(DateOnlyLayout))
+	assert.Equal(t, 150.0, statements[0].StatementBalance)
+	assert.Equal(t, 15.0, statements[0].MinimumPayment)
+	assert.Equal(t, "2025-02-15", statements[1].ClosingDate.Format(DateOnlyLayout))
+	assert.Equal(t, 30.0, statements[1].StatementBalance)
+
+	require.NoError(t, db.First(&onClosingDay, onClosingDay.ID).Error)
+	require.NoError(t, db.First(&afterClosingDay, afterClosingDay.ID).Error)
+	require.NoError(t, db.First(&open, open.ID).Error)
+	assert.Equal(t, &statements[0].ID, onClosingDay.StatementID)
+	assert.Equal(t, &statements[1].ID, afterClosingDay.StatementID)
+	assert.Nil(t, open.StatementID, "expenses in the open cycle stay unbilled")
+
+	require.NoError(t, service.SyncStatements(userID, wallet.ID, mustParseDate(t, "2025-02-20")))
+	assert.Len(t, loadStatements(t, db, wallet.ID), 2, "syncing again must not duplicate statements")
+}
+
+func TestStatementService_SyncStatementsSkipsEmptyCycles(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	wallet, expenseType := createStatementWallet(t, db, userID)
+	createStatementExpense(t, db, userID, wallet, expenseType, 40, "2025-01-05")
+	createStatementExpense(t, db, userID, wallet, expenseType, 60, "2025-03-05")
+
+	require.NoError(t, NewStatementService(db).SyncStatements(userID, wallet.ID, mustParseDate(t, "2025-03-20")))
+
+	statements := loadStatements(t, db, wallet.ID)
+	require.Len(t, statements, 2)
+	assert.Equal(t, "2025-01-15", statements[0].ClosingDate.Format(DateOnlyLayout))
+	assert.Equal(t, "2025-03-15", statements[1].ClosingDate.Format(DateOnlyLayout))
+}
+
+func TestStatementService_BackDatedExpenseJoinsClosedStatement(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	wallet, expenseType := createStatementWallet(t, db, userID)
+	createStatementExpense(t, db, userID, wallet, expenseType, 100, "2025-01-10")
+	createStatementExpense(t, db, userID, wallet, expenseType, 200, "2025-02-10")
+	require.NoError(t, NewStatementService(db).SyncStatements(userID, wallet.ID, mustParseDate(t, "2025-02-20")))
+
+	expenses := NewExpenseService(db)
+	backDated, err := expenses.CreateExpense(userID, CreateExpenseRequest{ExpenseTypeID: expenseType.ID, WalletID: &wallet.ID, Amount: 25, Date: "2025-01-12"})
+	require.NoError(t, err)
+	statements := loadStatements(t, db, wallet.ID)
+	require.Len(t, statements, 2)
+	assert.Equal(t, &statements[0].ID, backDated.StatementID)
+	assert.Equal(t, 125.0, statements[0].StatementBalance)
+
+	// A closed cycle that had no statement yet gets one.
+	older, err := expenses.CreateExpense(userID, CreateExpenseRequest{ExpenseTypeID: expenseType.ID, WalletID: &wallet.ID, Amount: 70, Date: "2024-12-01"})
+	require.NoError(t, err)
+	statements = loadStatements(t, db, wallet.ID)
+	require.Len(t, statements, 3)
+	assert.Equal(t, "2024-12-15", statements[0].ClosingDate.Format(DateOnlyLayout))
+	assert.Equal(t, &statements[0].ID, older.StatementID)
+	assert.Equal(t, 70.0, statements[0].StatementBalance)
+}
+
+func TestStatementService_EditedExpenseRecalculatesBalances(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	wallet, expenseType := createStatementWallet(t, db, userID)
+	expense := createStatementExpense(t, db, userID, wallet, expenseType, 100, "2025-01-10")
+	createStatementExpense(t, db, userID, wallet, expenseType, 200, "2025-02-10")
+	require.NoError(t, NewStatementService(db).SyncStatements(userID, wallet.ID, mustParseDate(t, "2025-02-20")))
+
+	expenses := NewExpenseService(db)
+	moved, err := expenses.UpdateExpense(userID, expense.ID, UpdateExpenseRequest{ExpenseTypeID: expenseType.ID, WalletID: &wallet.ID, Amount: 120, Date: "2025-02-01"})
+	require.NoError(t, err)
+	statements := loadStatements(t, db, wallet.ID)
+	require.Len(t, statements, 2)
+	assert.Equal(t, &statements[1].ID, moved.StatementID)
+	assert.Equal(t, 0.0, statements[0].StatementBalance)
+	assert.Equal(t, 320.0, statements[1].StatementBalance)
+	assert.Equal(t, 32.0, statements[1].MinimumPayment)
+
+	require.NoError(t, expenses.DeleteExpense(userID, expense.ID))
+	statements = loadStatements(t, db, wallet.ID)
+	assert.Equal(t, 200.0, statements[1].StatementBalance)
+}
@@ -0,0 +1,28 @@
+package expenses
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Transfer moves money from one wallet to another. The receiving side is
+// recorded as a Payment on the target wallet so cash matching and credit card
+// settlement keep working, while reports can tell it apart from spending.
+type Transfer struct {
+	ID           uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	FromWalletID uint           `json:"from_wallet_id" gorm:"type:bigint;not null;index"`
+	ToWalletID   uint           `json:"to_wallet_id" gorm:"type:bigint;not null;index"`
+	PaymentID    uint           `json:"payment_id" gorm:"type:bigint;not null;uniqueIndex"`
+	Amount       float64        `json:"amount" gorm:"type:numeric(12,2);not null"`
+	Date         time.Time      `json:"date" gorm:"type:date;not null;index"`
+	Note         string         `json:"note" gorm:"type:text"`
+	UserID       uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	FromWallet Wallet  `json:"from_wallet,omitempty" gorm:"foreignKey:FromWalletID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ToWallet   Wallet  `json:"to_wallet,omitempty" gorm:"foreignKey:ToWalletID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Payment    Payment `json:"payment,omitempty" gorm:"foreignKey:PaymentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -0,0 +1,98 @@
+package expenses
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TransferHandler struct {
+	service *TransferService
+}
+
+func NewTransferHandler(service *TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+func (h *TransferHandler) CreateTransfer(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req CreateTransferRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	transfer, err := h.service.CreateTransfer(userID, req)
+	if err != nil {
+		return h.transferError(c, err, "Failed to create transfer")
+	}
+	return c.JSON(http.StatusCreated, transfer)
+}
+
+func (h *TransferHandler) GetTransfer(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid transfer ID"})
+	}
+	transfer, err := h.service.GetTransfer(userID, uint(transferID))
+	if err != nil {
+		return h.transferError(c, err, "Failed to get transfer")
+	}
+	return c.JSON(http.StatusOK, transfer)
+}
+
+func (h *TransferHandler) DeleteTransfer(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid transfer ID"})
+	}
+	if err := h.service.DeleteTransfer(userID, uint(transferID)); err != nil {
+		return h.transferError(c, err, "Failed to delete transfer")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Transfer deleted successfully"})
+}
+
+func (h *TransferHandler) ListTransfers(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req TransferListRequest
+	req.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	req.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	if value := c.QueryParam("wallet_id"); value != "" {
+		if parsed, err := strconv.ParseUint(value, 10, 32); err == nil {
+			id := uint(parsed)
+			req.WalletID = &id
+		}
+	}
+	if value := c.QueryParam("from"); value != "" {
+		if parsed, err := ParseDateOnly(value); err == nil {
+			req.From = &parsed
+		}
+	}
+	if value := c.QueryParam("to"); value != "" {
+		if parsed, err := ParseDateOnly(value); err == nil {
+			req.To = &parsed
+		}
+	}
+	response, err := h.service.ListTransfers(userID, req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list transfers"})
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *TransferHandler) transferError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrTransferNotFound), errors.Is(err, ErrWalletNotFound), errors.Is(err, ErrExpenseNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrExpenseAlreadyLinked):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvalidTransferAmount), errors.Is(err, ErrInvalidTransferDate), errors.Is(err, ErrSameTransferWallet), errors.Is(err, ErrInvalidTransferTarget), errors.Is(err, ErrTransferExpensesNoCard), errors.Is(err, ErrTransferExpenseWallet):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
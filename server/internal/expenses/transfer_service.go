@@ -0,0 +1,266 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTransferNotFound       = errors.New("transfer not found")
+	ErrInvalidTransferAmount  = errors.New("transfer amount must be greater than 0")
+	ErrInvalidTransferDate    = errors.New("transfer date is required")
+	ErrSameTransferWallet     = errors.New("transfer source and target wallets must be different")
+	ErrInvalidTransferTarget  = errors.New("transfer target must be a cash or credit wallet")
+	ErrTransferExpensesNoCard = errors.New("expenses can only be attached when paying a credit wallet")
+	ErrPaymentIsTransfer      = errors.New("payment belongs to a transfer, edit the transfer instead")
+	ErrTransferExpenseWallet  = errors.New("expenses must belong to the target wallet")
+)
+
+type TransferService struct {
+	db       *gorm.DB
+	payments *PaymentService
+	eventSource
+}
+
+type CreateTransferRequest struct {
+	FromWalletID uint    `json:"from_wallet_id"`
+	ToWalletID   uint    `json:"to_wallet_id"`
+	Amount       float64 `json:"amount"`
+	Date         string  `json:"date"`
+	Note         string  `json:"note"`
+	ExpenseIDs   []uint  `json:"expense_ids"`
+}
+
+type TransferListRequest struct {
+	WalletID *uint
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type TransferListResponse struct {
+	Transfers []Transfer `json:"transfers"`
+	Total     int64      `json:"total"`
+}
+
+func NewTransferService(db *gorm.DB) *TransferService {
+	return &TransferService{db: db, payments: NewPaymentService(db)}
+}
+
+// SetEventPublisher sets where change events are published. The payment
+// behind each transfer is published as a payment event.
+func (s *TransferService) SetEventPublisher(publisher EventPublisher) {
+	s.eventSource.SetEventPublisher(publisher)
+	s.payments.SetEventPublisher(publisher)
+}
+
+// CreateTransfer records a transfer and the matching payment on the target
+// wallet in one transaction. A transfer into a cash wallet is a withdrawal
+// that later cash expenses can be matched against; a transfer into a credit
+// wallet pays off the card and may settle the given unbilled expenses.
+func (s *TransferService) CreateTransfer(userID uint, req CreateTransferRequest) (*Transfer, error) {
+	parsedDate, fromWallet, toWallet, err := s.validateTransferInput(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	note := strings.TrimSpace(req.Note)
+	paymentNote := note
+	if paymentNote == "" {
+		paymentNote = fmt.Sprintf("Transfer from %s", fromWallet.Name)
+	}
+
+	var transfer Transfer
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		payment := Payment{
+			WalletID: toWallet.ID,
+			Amount:   req.Amount,
+			Date:     parsedDate,
+			Note:     paymentNote,
+			UserID:   userID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to create transfer payment: %w", err)
+		}
+		if err := s.payments.replacePaymentExpenses(tx, userID, payment.ID, toWallet.ID, uniqueIDs(req.ExpenseIDs)); err != nil {
+			return err
+		}
+
+		transfer = Transfer{
+			FromWalletID: fromWallet.ID,
+			ToWalletID:   toWallet.ID,
+			PaymentID:    payment.ID,
+			Amount:       req.Amount,
+			Date:         parsedDate,
+			Note:         note,
+			UserID:       userID,
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return fmt.Errorf("failed to create transfer: %w", err)
+		}
+		return syncWalletOccurrences(tx, userID, toWallet.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.GetTransfer(userID, transfer.ID)
+	if err != nil {
+		return nil, err
+	}
+	payment := created.Payment
+	payment.Wallet = created.ToWallet
+	s.publish(userID, EventPaymentCreated, payment)
+	return created, nil
+}
+
+func (s *TransferService) GetTransfer(userID, transferID uint) (*Transfer, error) {
+	var transfer Transfer
+	if err := s.db.Preload("FromWallet").Preload("ToWallet").Preload("Payment").Where("id = ? AND user_id = ?", transferID, userID).First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to get transfer: %w", err)
+	}
+	return &transfer, nil
+}
+
+// DeleteTransfer removes the transfer together with its payment and releases
+// any expenses and bill cycles that were settled by it.
+func (s *TransferService) DeleteTransfer(userID, transferID uint) error {
+	transfer, err := s.GetTransfer(userID, transferID)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Expense{}).Where("user_id = ? AND payment_id = ?", userID, transfer.PaymentID).Update("payment_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unlink expenses: %w", err)
+		}
+		if err := tx.Where("id = ? AND user_id = ?", transfer.PaymentID, userID).Delete(&Payment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete transfer payment: %w", err)
+		}
+		if err := tx.Where("id = ? AND user_id = ?", transfer.ID, userID).Delete(&Transfer{}).Error; err != nil {
+			return fmt.Errorf("failed to delete transfer: %w", err)
+		}
+		if err := releaseOccurrences(tx, userID, "payment_id", transfer.PaymentID); err != nil {
+			return err
+		}
+		return syncWalletOccurrences(tx, userID, transfer.ToWalletID)
+	})
+	if err != nil {
+		return err
+	}
+	s.publish(userID, EventPaymentDeleted, DeletedEvent{ID: transfer.PaymentID})
+	return nil
+}
+
+func (s *TransferService) ListTransfers(userID uint, req TransferListRequest) (*TransferListResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 200 {
+		req.Limit = 200
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	query := s.db.Model(&Transfer{}).Where("user_id = ?", userID)
+	if req.WalletID != nil {
+		query = query.Where("from_wallet_id = ? OR to_wallet_id = ?", *req.WalletID, *req.WalletID)
+	}
+	if req.From != nil {
+		query = query.Where("date >= ?", NormalizeDateOnly(*req.From))
+	}
+	if req.To != nil {
+		query = query.Where("date <= ?", NormalizeDateOnly(*req.To))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count transfers: %w", err)
+	}
+
+	var transfers []Transfer
+	if err := query.Preload("FromWallet").Preload("ToWallet").Order("date DESC, created_at DESC").Limit(req.Limit).Offset(req.Offset).Find(&transfers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+
+	return &TransferListResponse{Transfers: transfers, Total: total}, nil
+}
+
+func (s *TransferService) validateTransferInput(userID uint, req CreateTransferRequest) (time.Time, *Wallet, *Wallet, error) {
+	if req.FromWalletID == 0 || req.ToWalletID == 0 {
+		return time.Time{}, nil, nil, ErrWalletNotFound
+	}
+	if req.FromWalletID == req.ToWalletID {
+		return time.Time{}, nil, nil, ErrSameTransferWallet
+	}
+	if req.Amount <= 0 {
+		return time.Time{}, nil, nil, ErrInvalidTransferAmount
+	}
+	if req.Date == "" {
+		return time.Time{}, nil, nil, ErrInvalidTransferDate
+	}
+	parsedDate, err := ParseDateOnly(req.Date)
+	if err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("%w: %v", ErrInvalidTransferDate, err)
+	}
+
+	var wallets []Wallet
+	if err := s.db.Where("user_id = ? AND id IN ?", userID, []uint{req.FromWalletID, req.ToWalletID}).Find(&wallets).Error; err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("failed to load wallets: %w", err)
+	}
+	var fromWallet, toWallet *Wallet
+	for index := range wallets {
+		switch wallets[index].ID {
+		case req.FromWalletID:
+			fromWallet = &wallets[index]
+		case req.ToWalletID:
+			toWallet = &wallets[index]
+		}
+	}
+	if fromWallet == nil || toWallet == nil {
+		return time.Time{}, nil, nil, ErrWalletNotFound
+	}
+	if !toWallet.IsCash && !toWallet.IsCredit {
+		return time.Time{}, nil, nil, ErrInvalidTransferTarget
+	}
+	if len(req.ExpenseIDs) > 0 && !toWallet.IsCredit {
+		return time.Time{}, nil, nil, ErrTransferExpensesNoCard
+	}
+	if err := s.validateTransferExpenses(userID, toWallet.ID, req.ExpenseIDs); err != nil {
+		return time.Time{}, nil, nil, err
+	}
+	return parsedDate, fromWallet, toWallet, nil
+}
+
+// validateTransferExpenses checks that the expenses to settle exist, are on
+// the target wallet and are not already settled by another payment.
+func (s *TransferService) validateTransferExpenses(userID, walletID uint, expenseIDs []uint) error {
+	if len(expenseIDs) == 0 {
+		return nil
+	}
+	ids := uniqueIDs(expenseIDs)
+	var expenses []Expense
+	if err := s.db.Where("user_id = ? AND id IN ?", userID, ids).Find(&expenses).Error; err != nil {
+		return fmt.Errorf("failed to load expenses: %w", err)
+	}
+	if len(expenses) != len(ids) {
+		return ErrExpenseNotFound
+	}
+	for _, expense := range expenses {
+		if expense.PaymentID != nil {
+			return ErrExpenseAlreadyLinked
+		}
+		if expense.WalletID != nil && *expense.WalletID != walletID {
+			return ErrTransferExpenseWallet
+		}
+	}
+	return nil
+}
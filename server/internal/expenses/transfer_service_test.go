@@ -0,0 +1,142 @@
+package expenses
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		databaseURL = os.Getenv("DATABASE_URL")
+	}
+	if databaseURL == "" {
+		t.Skip("set TEST_DATABASE_URL or DATABASE_URL to run database-backed tests")
+	}
+
+	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{DisableForeignKeyConstraintWhenMigrating: true})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(&Wallet{}, &ExpenseType{}, &ExpenseTypeAmount{}, &Payment{}, &Expense{}, &Transfer{}, &Statement{}, &InstallmentPlan{}, &RecurrenceOccurrence{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("TRUNCATE TABLE wallets, expense_types, expense_type_amounts, payments, expenses, transfers, statements, installment_plans, recurrence_occurrences RESTART IDENTITY CASCADE").Error)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	return db
+}
+
+type publishedEvent struct {
+	userID    uint
+	eventType string
+	data      interface{}
+}
+
+type recordingPublisher struct {
+	events []publishedEvent
+}
+
+func (p *recordingPublisher) Publish(userID uint, eventType string, data interface{}) int {
+	p.events = append(p.events, publishedEvent{userID: userID, eventType: eventType, data: data})
+	return 1
+}
+
+func TestTransferService_CreateTransferPublishesPayment(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	bank := Wallet{Name: "Bank", UserID: userID}
+	cash := Wallet{Name: "Cash", IsCash: true, UserID: userID}
+	require.NoError(t, db.Create(&bank).Error)
+	require.NoError(t, db.Create(&cash).Error)
+
+	publisher := &recordingPublisher{}
+	service := NewTransferService(db)
+	service.SetEventPublisher(publisher)
+
+	transfer, err := service.CreateTransfer(userID, CreateTransferRequest{
+		FromWalletID: bank.ID,
+		ToWalletID:   cash.ID,
+		Amount:       200,
+		Date:         "2025-03-01",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, uint(userID), event.userID)
+	assert.Equal(t, EventPaymentCreated, event.eventType)
+	payment, ok := event.data.(Payment)
+	require.True(t, ok, "expected a Payment, got %T", event.data)
+	assert.Equal(t, transfer.PaymentID, payment.ID)
+	assert.Equal(t, cash.ID, payment.WalletID)
+	assert.Equal(t, 200.0, payment.Amount)
+
+	require.NoError(t, service.DeleteTransfer(userID, transfer.ID))
+	require.Len(t, publisher.events, 2)
+	assert.Equal(t, EventPaymentDeleted, publisher.events[1].eventType)
+	assert.Equal(t, DeletedEvent{ID: transfer.PaymentID}, publisher.events[1].data)
+}
+
+func TestTransferService_TransferSettlesAndReleasesBillCycle(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	bank := Wallet{Name: "Bank", UserID: userID}
+	card := Wallet{Name: "Card", IsCredit: true, BillPeriod: WalletPeriodMonthly, UserID: userID}
+	require.NoError(t, db.Create(&bank).Error)
+	require.NoError(t, db.Create(&card).Error)
+
+	service := NewTransferService(db)
+	transfer, err := service.CreateTransfer(userID, CreateTransferRequest{
+		FromWalletID: bank.ID,
+		ToWalletID:   card.ID,
+		Amount:       500,
+		Date:         time.Now().UTC().Format(DateOnlyLayout),
+	})
+	require.NoError(t, err)
+
+	var paid RecurrenceOccurrence
+	require.NoError(t, db.Where("wallet_id = ? AND payment_id = ?", card.ID, transfer.PaymentID).First(&paid).Error)
+	assert.Equal(t, OccurrenceStatusPaid, paid.Status)
+
+	require.NoError(t, service.DeleteTransfer(userID, transfer.ID))
+	var linked int64
+	require.NoError(t, db.Model(&RecurrenceOccurrence{}).Where("payment_id = ?", transfer.PaymentID).Count(&linked).Error)
+	assert.Zero(t, linked)
+	require.NoError(t, db.First(&paid, paid.ID).Error)
+	assert.Equal(t, OccurrenceStatusPending, paid.Status)
+}
+
+func TestPaymentService_GetMonthlyTotalExcludesTransfers(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	bank := Wallet{Name: "Bank", UserID: userID}
+	cash := Wallet{Name: "Cash", IsCash: true, UserID: userID}
+	require.NoError(t, db.Create(&bank).Error)
+	require.NoError(t, db.Create(&cash).Error)
+
+	date := mustParseDate(t, "2025-03-10")
+	require.NoError(t, db.Create(&Payment{UserID: userID, WalletID: bank.ID, Amount: 80, Date: date}).Error)
+	_, err := NewTransferService(db).CreateTransfer(userID, CreateTransferRequest{
+		FromWalletID: bank.ID,
+		ToWalletID:   cash.ID,
+		Amount:       200,
+		Date:         "2025-03-12",
+	})
+	require.NoError(t, err)
+
+	total, err := NewPaymentService(db).GetMonthlyTotal(userID, mustParseDate(t, "2025-03-01"), mustParseDate(t, "2025-03-31"))
+	require.NoError(t, err)
+	assert.Equal(t, 80.0, total)
+}
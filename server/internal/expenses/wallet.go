@@ -17,21 +17,34 @@ const (
 )
 
 type Wallet struct {
-	ID                   uint           `json:"id" gorm:"primaryKey;type:bigint"`
-	Name                 string         `json:"name" gorm:"type:varchar(255);not null"`
-	Icon                 string         `json:"icon" gorm:"type:varchar(50)"`
-	Color                string         `json:"color" gorm:"type:varchar(10)"`
-	Description          string         `json:"description" gorm:"type:text"`
-	IsCredit             bool           `json:"is_credit" gorm:"not null;default:false"`
-	IsCash               bool           `json:"is_cash" gorm:"not null;default:false;check:chk_wallet_type,NOT (is_credit AND is_cash)"`
-	BillPeriod           string         `json:"bill_period" gorm:"type:varchar(20);not null;default:'none';check:chk_wallet_bill_period,bill_period IN ('none','monthly','bimonthly','quarterly','fourmonths','semiannually','annually')"`
-	BillDueDay           int            `json:"bill_due_day" gorm:"not null;default:0"`
-	Stopped              bool           `json:"stopped" gorm:"not null;default:false"`
-	DefaultExpenseTypeID *uint          `json:"default_expense_type_id" gorm:"type:bigint;index"`
-	UserID               uint           `json:"user_id" gorm:"type:bigint;not null;index"`
-	CreatedAt            time.Time      `json:"created_at"`
-	UpdatedAt            time.Time      `json:"updated_at"`
-	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                    uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	Name                  string         `json:"name" gorm:"type:varchar(255);not null"`
+	Icon                  string         `json:"icon" gorm:"type:varchar(50)"`
+	Color                 string         `json:"color" gorm:"type:varchar(10)"`
+	Description           string         `json:"description" gorm:"type:text"`
+	IsCredit              bool           `json:"is_credit" gorm:"not null;default:false"`
+	IsCash                bool           `json:"is_cash" gorm:"not null;default:false;check:chk_wallet_type,NOT (is_credit AND is_cash)"`
+	BillPeriod            string         `json:"bill_period" gorm:"type:varchar(20);not null;default:'none';check:chk_wallet_bill_period,bill_period IN ('none','monthly','bimonthly','quarterly','fourmonths','semiannually','annually')"`
+	BillDueDay            int            `json:"bill_due_day" gorm:"not null;default:0"`
+	DueRecurrenceRule     string         `json:"due_recurrence_rule" gorm:"type:text;not null;default:''"`
+	BusinessDayRule       string         `json:"business_day_rule" gorm:"type:varchar(20);not null;default:'none';check:chk_wallet_business_day_rule,business_day_rule IN ('none','previous','next')"`
+	HolidayRegion         string         `json:"holiday_region" gorm:"type:varchar(16);not null;default:''"`
+	StatementClosingDay   int            `json:"statement_closing_day" gorm:"not null;default:0"` // 0 disables statement cycles
+	MinimumPaymentPercent float64        `json:"minimum_payment_percent" gorm:"type:numeric(5,2);not null;default:0"`
+	MinimumPaymentAmount  float64        `json:"minimum_payment_amount" gorm:"type:numeric(12,2);not null;default:0"`
+	CreditLimit           float64        `json:"credit_limit" gorm:"type:numeric(12,2);not null;default:0"` // 0 means no limit is tracked
+	UtilizationAlert      float64        `json:"utilization_alert" gorm:"type:numeric(5,2);not null;default:30"`
+	EndDate               *time.Time     `json:"end_date" gorm:"type:date"`
+	RemainingOccurrences  *int           `json:"remaining_occurrences" gorm:"check:chk_wallet_remaining_occurrences,remaining_occurrences IS NULL OR remaining_occurrences >= 0"`
+	OccurrenceLimit       *int           `json:"-"`
+	OccurrenceLimitSetAt  *time.Time     `json:"-" gorm:"type:timestamptz"`
+	EndingSoonReminder    bool           `json:"ending_soon_reminder" gorm:"not null;default:false"`
+	Stopped               bool           `json:"stopped" gorm:"not null;default:false"`
+	DefaultExpenseTypeID  *uint          `json:"default_expense_type_id" gorm:"type:bigint;index"`
+	UserID                uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
 
 	DefaultExpenseType *ExpenseType `json:"default_expense_type,omitempty" gorm:"foreignKey:DefaultExpenseTypeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 }
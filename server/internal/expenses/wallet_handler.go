@@ -1,8 +1,10 @@
 package expenses
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"dannyswat/jiceot/internal/auth"
 
@@ -116,20 +118,62 @@ func (h *WalletHandler) GetUnbilledExpenses(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
 	}
-	expenses, err := h.service.GetWalletUnbilledExpenses(userID, uint(walletID))
+	response, err := h.service.GetWalletUnbilledExpenses(userID, uint(walletID))
 	if err != nil {
 		return h.walletError(c, err, "Failed to get unbilled expenses")
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{"expenses": expenses, "total": len(expenses)})
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WalletHandler) GetUtilization(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	utilization, err := h.service.GetWalletUtilization(userID, uint(walletID))
+	if err != nil {
+		return h.walletError(c, err, "Failed to get wallet utilization")
+	}
+	return c.JSON(http.StatusOK, utilization)
+}
+
+func (h *WalletHandler) GetUtilizationHistory(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid wallet ID"})
+	}
+	months, _ := strconv.Atoi(c.QueryParam("months"))
+	history, err := h.service.GetWalletUtilizationHistory(userID, uint(walletID), months)
+	if err != nil {
+		return h.walletError(c, err, "Failed to get wallet utilization history")
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
+func (h *WalletHandler) PreviewWalletSchedule(c echo.Context) error {
+	var req WalletSchedulePreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	preview, err := PreviewWalletSchedule(req, time.Now().UTC())
+	if err != nil {
+		return h.walletError(c, err, "Failed to preview schedule")
+	}
+	return c.JSON(http.StatusOK, preview)
 }
 
 func (h *WalletHandler) walletError(c echo.Context, err error, fallback string) error {
+	if errors.Is(err, ErrInvalidRecurrenceRule) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	switch err {
 	case ErrWalletNotFound:
 		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
 	case ErrWalletNameExists:
 		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
-	case ErrEmptyWalletName, ErrInvalidWalletType, ErrInvalidWalletPeriod, ErrInvalidWalletDueDay, ErrExpenseTypeNotFound:
+	case ErrEmptyWalletName, ErrInvalidWalletType, ErrInvalidWalletPeriod, ErrInvalidWalletDueDay, ErrExpenseTypeNotFound, ErrInvalidStatementClosingDay, ErrStatementClosingDayNoCredit, ErrInvalidMinimumPayment, ErrInvalidCreditLimit, ErrInvalidUtilization, ErrCreditLimitNotSet, ErrDueRuleNoCredit, ErrInvalidBusinessDayRule, ErrUnknownHolidayRegion, ErrInvalidPreviewCount, ErrInvalidPreviewFrom, ErrPreviewNoBillPeriod, ErrInvalidEndDate, ErrInvalidRemainingOccurrences, ErrScheduleEndNotRecurring:
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	default:
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
@@ -3,7 +3,9 @@ package expenses
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,35 +17,67 @@ var (
 	ErrInvalidWalletType   = errors.New("wallet cannot be both credit and cash")
 	ErrInvalidWalletPeriod = errors.New("invalid wallet bill period")
 	ErrInvalidWalletDueDay = errors.New("wallet due day must be between 0 and 31")
+	ErrInvalidCreditLimit  = errors.New("credit limit must not be negative and is only supported on credit wallets")
+	ErrInvalidUtilization  = errors.New("utilization alert must be between 0 and 100 percent")
+	ErrCreditLimitNotSet   = errors.New("wallet does not have a credit limit")
+	ErrDueRuleNoCredit     = errors.New("due date recurrence rules are only supported on credit wallets")
 )
 
+// DefaultUtilizationAlert is the utilization percentage at which the
+// dashboard warns about a credit wallet unless the wallet overrides it.
+const DefaultUtilizationAlert = 30
+
 type WalletService struct {
 	db *gorm.DB
+	eventSource
 }
 
 type CreateWalletRequest struct {
-	Name                 string `json:"name"`
-	Icon                 string `json:"icon"`
-	Color                string `json:"color"`
-	Description          string `json:"description"`
-	IsCredit             bool   `json:"is_credit"`
-	IsCash               bool   `json:"is_cash"`
-	BillPeriod           string `json:"bill_period"`
-	BillDueDay           int    `json:"bill_due_day"`
-	DefaultExpenseTypeID *uint  `json:"default_expense_type_id"`
+	Name                  string   `json:"name"`
+	Icon                  string   `json:"icon"`
+	Color                 string   `json:"color"`
+	Description           string   `json:"description"`
+	IsCredit              bool     `json:"is_credit"`
+	IsCash                bool     `json:"is_cash"`
+	BillPeriod            string   `json:"bill_period"`
+	BillDueDay            int      `json:"bill_due_day"`
+	DueRecurrenceRule     string   `json:"due_recurrence_rule"`
+	BusinessDayRule       string   `json:"business_day_rule"`
+	HolidayRegion         string   `json:"holiday_region"`
+	StatementClosingDay   int      `json:"statement_closing_day"`
+	MinimumPaymentPercent float64  `json:"minimum_payment_percent"`
+	MinimumPaymentAmount  float64  `json:"minimum_payment_amount"`
+	CreditLimit           float64  `json:"credit_limit"`
+	UtilizationAlert      *float64 `json:"utilization_alert"`
+	EndDate               *string  `json:"end_date"`
+	RemainingOccurrences  *int     `json:"remaining_occurrences"`
+	EndingSoonReminder    bool     `json:"ending_soon_reminder"`
+	DefaultExpenseTypeID  *uint    `json:"default_expense_type_id"`
 }
 
 type UpdateWalletRequest struct {
-	Name                 string `json:"name"`
-	Icon                 string `json:"icon"`
-	Color                string `json:"color"`
-	Description          string `json:"description"`
-	IsCredit             bool   `json:"is_credit"`
-	IsCash               bool   `json:"is_cash"`
-	BillPeriod           string `json:"bill_period"`
-	BillDueDay           int    `json:"bill_due_day"`
-	DefaultExpenseTypeID *uint  `json:"default_expense_type_id"`
-	Stopped              bool   `json:"stopped"`
+	Name                  string   `json:"name"`
+	Icon                  string   `json:"icon"`
+	Color                 string   `json:"color"`
+	Description           string   `json:"description"`
+	IsCredit              bool     `json:"is_credit"`
+	IsCash                bool     `json:"is_cash"`
+	BillPeriod            string   `json:"bill_period"`
+	BillDueDay            int      `json:"bill_due_day"`
+	DueRecurrenceRule     *string  `json:"due_recurrence_rule"`
+	BusinessDayRule       *string  `json:"business_day_rule"`
+	HolidayRegion         *string  `json:"holiday_region"`
+	StatementClosingDay   *int     `json:"statement_closing_day"`
+	MinimumPaymentPercent *float64 `json:"minimum_payment_percent"`
+	MinimumPaymentAmount  *float64 `json:"minimum_payment_amount"`
+	CreditLimit           *float64 `json:"credit_limit"`
+	UtilizationAlert      *float64 `json:"utilization_alert"`
+	EndDate               *string  `json:"end_date"`
+	RemainingOccurrences  *int     `json:"remaining_occurrences"`
+	EndingSoonReminder    *bool    `json:"ending_soon_reminder"`
+	ClearRemaining        bool     `json:"clear_remaining_occurrences"` // omitting RemainingOccurrences keeps the count
+	DefaultExpenseTypeID  *uint    `json:"default_expense_type_id"`
+	Stopped               bool     `json:"stopped"`
 }
 
 type WalletListResponse struct {
@@ -51,6 +85,47 @@ type WalletListResponse struct {
 	Total   int64    `json:"total"`
 }
 
+// WalletUtilization describes how much of a credit wallet's limit is used by
+// unpaid expenses, whether still unbilled or billed on a statement.
+type WalletUtilization struct {
+	WalletID         uint    `json:"wallet_id"`
+	CreditLimit      float64 `json:"credit_limit"`
+	Unbilled         float64 `json:"unbilled"`
+	BilledUnpaid     float64 `json:"billed_unpaid"`
+	Outstanding      float64 `json:"outstanding"`
+	Available        float64 `json:"available"`
+	Utilization      float64 `json:"utilization"`
+	UtilizationAlert float64 `json:"utilization_alert"`
+	OverAlert        bool    `json:"over_alert"`
+}
+
+type UtilizationPoint struct {
+	Date        string  `json:"date"`
+	Outstanding float64 `json:"outstanding"`
+	Utilization float64 `json:"utilization"`
+}
+
+type UtilizationHistoryResponse struct {
+	WalletID    uint               `json:"wallet_id"`
+	CreditLimit float64            `json:"credit_limit"`
+	Points      []UtilizationPoint `json:"points"`
+}
+
+// UnbilledExpensesResponse splits a wallet's unpaid expenses by statement
+// cycle. Expenses dated on or before the last closing date belong to the
+// current statement; later ones roll into the next statement. Wallets without
+// a closing day keep every expense on the current statement.
+type UnbilledExpensesResponse struct {
+	Expenses              []Expense `json:"expenses"`
+	Total                 int       `json:"total"`
+	CurrentStatement      []Expense `json:"current_statement"`
+	NextStatement         []Expense `json:"next_statement"`
+	CurrentStatementTotal float64   `json:"current_statement_total"`
+	NextStatementTotal    float64   `json:"next_statement_total"`
+	LastClosingDate       *string   `json:"last_closing_date,omitempty"`
+	NextClosingDate       *string   `json:"next_closing_date,omitempty"`
+}
+
 func NewWalletService(db *gorm.DB) *WalletService {
 	return &WalletService{db: db}
 }
@@ -59,9 +134,28 @@ func (s *WalletService) CreateWallet(userID uint, req CreateWalletRequest) (*Wal
 	if err := s.validateWalletInput(userID, req.Name, req.IsCredit, req.IsCash, req.BillPeriod, req.BillDueDay, req.DefaultExpenseTypeID, 0); err != nil {
 		return nil, err
 	}
+	if err := validateStatementSettings(req.IsCredit, req.StatementClosingDay, req.MinimumPaymentPercent, req.MinimumPaymentAmount); err != nil {
+		return nil, err
+	}
+	utilizationAlert, err := resolveCreditLimitSettings(req.IsCredit, req.CreditLimit, req.UtilizationAlert, DefaultUtilizationAlert)
+	if err != nil {
+		return nil, err
+	}
+	dueRule, err := normalizeWalletDueRule(req.IsCredit, req.DueRecurrenceRule, "")
+	if err != nil {
+		return nil, err
+	}
+	businessDayRule, holidayRegion, err := normalizeBusinessDaySettings(req.BusinessDayRule, req.HolidayRegion)
+	if err != nil {
+		return nil, err
+	}
+	endDate, remainingOccurrences, err := parseScheduleEnd(req.EndDate, req.RemainingOccurrences, req.IsCredit && normalizeWalletPeriod(req.BillPeriod) != WalletPeriodNone)
+	if err != nil {
+		return nil, err
+	}
 
 	var existing Wallet
-	err := s.db.Where("user_id = ? AND LOWER(name) = LOWER(?)", userID, strings.TrimSpace(req.Name)).First(&existing).Error
+	err = s.db.Where("user_id = ? AND LOWER(name) = LOWER(?)", userID, strings.TrimSpace(req.Name)).First(&existing).Error
 	if err == nil {
 		return nil, ErrWalletNameExists
 	}
@@ -70,17 +164,30 @@ func (s *WalletService) CreateWallet(userID uint, req CreateWalletRequest) (*Wal
 	}
 
 	wallet := Wallet{
-		Name:                 strings.TrimSpace(req.Name),
-		Icon:                 strings.TrimSpace(req.Icon),
-		Color:                strings.TrimSpace(req.Color),
-		Description:          strings.TrimSpace(req.Description),
-		IsCredit:             req.IsCredit,
-		IsCash:               req.IsCash,
-		BillPeriod:           normalizeWalletPeriod(req.BillPeriod),
-		BillDueDay:           req.BillDueDay,
-		DefaultExpenseTypeID: req.DefaultExpenseTypeID,
-		UserID:               userID,
-	}
+		Name:                  strings.TrimSpace(req.Name),
+		Icon:                  strings.TrimSpace(req.Icon),
+		Color:                 strings.TrimSpace(req.Color),
+		Description:           strings.TrimSpace(req.Description),
+		IsCredit:              req.IsCredit,
+		IsCash:                req.IsCash,
+		BillPeriod:            normalizeWalletPeriod(req.BillPeriod),
+		BillDueDay:            req.BillDueDay,
+		DueRecurrenceRule:     dueRule,
+		BusinessDayRule:       businessDayRule,
+		HolidayRegion:         holidayRegion,
+		StatementClosingDay:   req.StatementClosingDay,
+		MinimumPaymentPercent: req.MinimumPaymentPercent,
+		MinimumPaymentAmount:  req.MinimumPaymentAmount,
+		CreditLimit:           req.CreditLimit,
+		UtilizationAlert:      utilizationAlert,
+		EndDate:               endDate,
+		RemainingOccurrences:  remainingOccurrences,
+		EndingSoonReminder:    req.EndingSoonReminder,
+		Stopped:               remainingOccurrences != nil && *remainingOccurrences == 0,
+		DefaultExpenseTypeID:  req.DefaultExpenseTypeID,
+		UserID:                userID,
+	}
+	wallet.OccurrenceLimit, wallet.OccurrenceLimitSetAt = occurrenceLimit(remainingOccurrences, nil, nil, nil, time.Now())
 
 	if err := s.db.Create(&wallet).Error; err != nil {
 		return nil, fmt.Errorf("failed to create wallet: %w", err)
@@ -90,6 +197,7 @@ func (s *WalletService) CreateWallet(userID uint, req CreateWalletRequest) (*Wal
 		return nil, fmt.Errorf("failed to load wallet: %w", err)
 	}
 
+	s.publish(userID, EventWalletCreated, wallet)
 	return &wallet, nil
 }
 
@@ -113,6 +221,32 @@ func (s *WalletService) UpdateWallet(userID, walletID uint, req UpdateWalletRequ
 	if err != nil {
 		return nil, err
 	}
+	closingDay, minimumPercent, minimumAmount := resolveStatementSettings(req.IsCredit, req.StatementClosingDay, req.MinimumPaymentPercent, req.MinimumPaymentAmount, *wallet)
+	if err := validateStatementSettings(req.IsCredit, closingDay, minimumPercent, minimumAmount); err != nil {
+		return nil, err
+	}
+	creditLimit := wallet.CreditLimit
+	if req.CreditLimit != nil {
+		creditLimit = *req.CreditLimit
+	} else if !req.IsCredit {
+		creditLimit = 0
+	}
+	utilizationAlert, err := resolveCreditLimitSettings(req.IsCredit, creditLimit, req.UtilizationAlert, wallet.UtilizationAlert)
+	if err != nil {
+		return nil, err
+	}
+	dueRule, err := normalizeWalletDueRule(req.IsCredit, resolveRecurrenceRuleUpdate(req.DueRecurrenceRule, wallet.DueRecurrenceRule, req.IsCredit), wallet.DueRecurrenceRule)
+	if err != nil {
+		return nil, err
+	}
+	businessDayRule, holidayRegion, err := resolveBusinessDaySettings(req.BusinessDayRule, req.HolidayRegion, wallet.BusinessDayRule, wallet.HolidayRegion)
+	if err != nil {
+		return nil, err
+	}
+	endDate, remainingOccurrences, err := resolveScheduleEnd(req.EndDate, req.RemainingOccurrences, req.ClearRemaining, wallet.EndDate, wallet.RemainingOccurrences, req.IsCredit && normalizeWalletPeriod(req.BillPeriod) != WalletPeriodNone)
+	if err != nil {
+		return nil, err
+	}
 
 	var existing Wallet
 	err = s.db.Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, strings.TrimSpace(req.Name), walletID).First(&existing).Error
@@ -131,17 +265,41 @@ func (s *WalletService) UpdateWallet(userID, walletID uint, req UpdateWalletRequ
 	wallet.IsCash = req.IsCash
 	wallet.BillPeriod = normalizeWalletPeriod(req.BillPeriod)
 	wallet.BillDueDay = req.BillDueDay
+	wallet.DueRecurrenceRule = dueRule
+	wallet.BusinessDayRule = businessDayRule
+	wallet.HolidayRegion = holidayRegion
+	wallet.StatementClosingDay = closingDay
+	wallet.MinimumPaymentPercent = minimumPercent
+	wallet.MinimumPaymentAmount = minimumAmount
+	wallet.CreditLimit = creditLimit
+	wallet.UtilizationAlert = utilizationAlert
+	wallet.EndDate = endDate
+	wallet.OccurrenceLimit, wallet.OccurrenceLimitSetAt = occurrenceLimit(remainingOccurrences, wallet.RemainingOccurrences, wallet.OccurrenceLimit, wallet.OccurrenceLimitSetAt, time.Now())
+	wallet.RemainingOccurrences = remainingOccurrences
+	if req.EndingSoonReminder != nil {
+		wallet.EndingSoonReminder = *req.EndingSoonReminder
+	}
 	wallet.DefaultExpenseTypeID = req.DefaultExpenseTypeID
-	wallet.Stopped = req.Stopped
+	wallet.Stopped = req.Stopped || (remainingOccurrences != nil && *remainingOccurrences == 0)
 
-	if err := s.db.Save(wallet).Error; err != nil {
-		return nil, fmt.Errorf("failed to update wallet: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(wallet).Error; err != nil {
+			return fmt.Errorf("failed to update wallet: %w", err)
+		}
+		if err := clearUpcomingOccurrences(tx, userID, "wallet_id", wallet.ID); err != nil {
+			return err
+		}
+		return syncWalletOccurrences(tx, userID, wallet.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	if err := s.db.Preload("DefaultExpenseType").First(wallet, wallet.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to reload wallet: %w", err)
 	}
 
+	s.publish(userID, EventWalletUpdated, wallet)
 	return wallet, nil
 }
 
@@ -152,6 +310,7 @@ func (s *WalletService) DeleteWallet(userID, walletID uint) error {
 	if err := s.db.Where("id = ? AND user_id = ?", walletID, userID).Delete(&Wallet{}).Error; err != nil {
 		return fmt.Errorf("failed to delete wallet: %w", err)
 	}
+	s.publish(userID, EventWalletDeleted, DeletedEvent{ID: walletID})
 	return nil
 }
 
@@ -202,6 +361,7 @@ func (s *WalletService) ToggleWallet(userID, walletID uint) (*Wallet, error) {
 	if err := s.db.Save(wallet).Error; err != nil {
 		return nil, fmt.Errorf("failed to toggle wallet: %w", err)
 	}
+	s.publish(userID, EventWalletUpdated, wallet)
 	return wallet, nil
 }
 
@@ -216,15 +376,47 @@ func (s *WalletService) GetWalletPayments(userID, walletID uint) ([]Payment, err
 	return payments, nil
 }
 
-func (s *WalletService) GetWalletUnbilledExpenses(userID, walletID uint) ([]Expense, error) {
-	if _, err := s.GetWallet(userID, walletID); err != nil {
+func (s *WalletService) GetWalletUnbilledExpenses(userID, walletID uint) (*UnbilledExpensesResponse, error) {
+	wallet, err := s.GetWallet(userID, walletID)
+	if err != nil {
 		return nil, err
 	}
 	var expenses []Expense
 	if err := s.db.Preload("ExpenseType").Where("user_id = ? AND wallet_id = ? AND payment_id IS NULL", userID, walletID).Order("date DESC, created_at DESC").Find(&expenses).Error; err != nil {
 		return nil, fmt.Errorf("failed to get unbilled expenses: %w", err)
 	}
-	return expenses, nil
+
+	response := &UnbilledExpensesResponse{
+		Expenses:         expenses,
+		Total:            len(expenses),
+		CurrentStatement: make([]Expense, 0),
+		NextStatement:    make([]Expense, 0),
+	}
+	if !wallet.IsCredit || wallet.StatementClosingDay == 0 {
+		response.CurrentStatement = expenses
+		for _, expense := range expenses {
+			response.CurrentStatementTotal += expense.Amount
+		}
+		return response, nil
+	}
+
+	today := NormalizeDateOnly(time.Now())
+	lastClosing := LastClosedStatementDate(wallet.StatementClosingDay, today)
+	nextClosing := StatementClosingDate(wallet.StatementClosingDay, today)
+	lastClosingStr := lastClosing.Format(DateOnlyLayout)
+	nextClosingStr := nextClosing.Format(DateOnlyLayout)
+	response.LastClosingDate = &lastClosingStr
+	response.NextClosingDate = &nextClosingStr
+	for _, expense := range expenses {
+		if expense.Date.After(lastClosing) {
+			response.NextStatement = append(response.NextStatement, expense)
+			response.NextStatementTotal += expense.Amount
+			continue
+		}
+		response.CurrentStatement = append(response.CurrentStatement, expense)
+		response.CurrentStatementTotal += expense.Amount
+	}
+	return response, nil
 }
 
 func (s *WalletService) validateWalletInput(userID uint, name string, isCredit, isCash bool, billPeriod string, billDueDay int, defaultExpenseTypeID *uint, excludeWalletID uint) error {
@@ -256,6 +448,164 @@ func (s *WalletService) validateWalletInput(userID uint, name string, isCredit,
 	return nil
 }
 
+// normalizeWalletDueRule validates a credit wallet's due date rule and returns
+// it in its stored form.
+func normalizeWalletDueRule(isCredit bool, value, existing string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+	if !isCredit {
+		return "", ErrDueRuleNoCredit
+	}
+	return normalizeStoredRecurrenceRule(value, existing)
+}
+
+// GetWalletUtilization returns the current credit utilization of a wallet.
+func (s *WalletService) GetWalletUtilization(userID, walletID uint) (*WalletUtilization, error) {
+	wallet, err := s.GetWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.IsCredit || wallet.CreditLimit <= 0 {
+		return nil, ErrCreditLimitNotSet
+	}
+
+	type balanceRow struct {
+		Billed bool
+		Amount float64
+	}
+	var rows []balanceRow
+	if err := s.db.Model(&Expense{}).
+		Select("statement_id IS NOT NULL AS billed, COALESCE(SUM(amount), 0) AS amount").
+		Where("user_id = ? AND wallet_id = ? AND payment_id IS NULL", userID, walletID).
+		Group("statement_id IS NOT NULL").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate wallet utilization: %w", err)
+	}
+
+	utilization := &WalletUtilization{
+		WalletID:         wallet.ID,
+		CreditLimit:      wallet.CreditLimit,
+		UtilizationAlert: wallet.UtilizationAlert,
+	}
+	for _, row := range rows {
+		if row.Billed {
+			utilization.BilledUnpaid += row.Amount
+		} else {
+			utilization.Unbilled += row.Amount
+		}
+	}
+	utilization.Outstanding = utilization.Unbilled + utilization.BilledUnpaid
+	utilization.Available = wallet.CreditLimit - utilization.Outstanding
+	utilization.Utilization = CreditUtilization(wallet.CreditLimit, utilization.Outstanding)
+	utilization.OverAlert = utilization.Utilization >= wallet.UtilizationAlert
+	return utilization, nil
+}
+
+// GetWalletUtilizationHistory reconstructs the outstanding balance at the end
+// of each of the last months from expense and payment dates. An expense counts
+// as outstanding on a date if it was incurred by then and not yet paid.
+func (s *WalletService) GetWalletUtilizationHistory(userID, walletID uint, months int) (*UtilizationHistoryResponse, error) {
+	wallet, err := s.GetWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.IsCredit || wallet.CreditLimit <= 0 {
+		return nil, ErrCreditLimitNotSet
+	}
+	if months <= 0 {
+		months = 12
+	}
+	if months > 36 {
+		months = 36
+	}
+
+	today := NormalizeDateOnly(time.Now())
+	dates := make([]time.Time, 0, months+1)
+	for offset := months; offset >= 1; offset-- {
+		month := BeginningOfMonth(today.Year(), int(today.Month())).AddDate(0, -offset, 0)
+		dates = append(dates, EndOfMonth(month.Year(), int(month.Month())))
+	}
+	dates = append(dates, today)
+
+	points := make([]UtilizationPoint, 0, len(dates))
+	for _, date := range dates {
+		var outstanding float64
+		if err := s.db.Table("expenses").
+			Joins("LEFT JOIN payments ON payments.id = expenses.payment_id AND payments.deleted_at IS NULL").
+			Where("expenses.user_id = ? AND expenses.wallet_id = ? AND expenses.deleted_at IS NULL AND expenses.date <= ?", userID, walletID, date).
+			Where("expenses.payment_id IS NULL OR payments.id IS NULL OR payments.date > ?", date).
+			Select("COALESCE(SUM(expenses.amount), 0)").
+			Scan(&outstanding).Error; err != nil {
+			return nil, fmt.Errorf("failed to calculate utilization history: %w", err)
+		}
+		points = append(points, UtilizationPoint{
+			Date:        date.Format(DateOnlyLayout),
+			Outstanding: outstanding,
+			Utilization: CreditUtilization(wallet.CreditLimit, outstanding),
+		})
+	}
+
+	return &UtilizationHistoryResponse{WalletID: wallet.ID, CreditLimit: wallet.CreditLimit, Points: points}, nil
+}
+
+// CreditUtilization returns outstanding as a percentage of limit, rounded to
+// two decimals. A wallet without a limit reports zero.
+func CreditUtilization(limit, outstanding float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return math.Round(outstanding/limit*10000) / 100
+}
+
+func resolveCreditLimitSettings(isCredit bool, creditLimit float64, utilizationAlert *float64, fallbackAlert float64) (float64, error) {
+	if creditLimit < 0 || (creditLimit > 0 && !isCredit) {
+		return 0, ErrInvalidCreditLimit
+	}
+	alert := fallbackAlert
+	if utilizationAlert != nil {
+		alert = *utilizationAlert
+	}
+	if alert < 0 || alert > 100 {
+		return 0, ErrInvalidUtilization
+	}
+	return alert, nil
+}
+
+// resolveStatementSettings returns the statement settings for an update,
+// keeping the wallet's stored values for fields the request leaves out. A
+// wallet that is no longer a credit wallet drops its stored closing day.
+func resolveStatementSettings(isCredit bool, closingDay *int, minimumPercent, minimumAmount *float64, wallet Wallet) (int, float64, float64) {
+	resolvedClosingDay := wallet.StatementClosingDay
+	if closingDay != nil {
+		resolvedClosingDay = *closingDay
+	} else if !isCredit {
+		resolvedClosingDay = 0
+	}
+	resolvedPercent := wallet.MinimumPaymentPercent
+	if minimumPercent != nil {
+		resolvedPercent = *minimumPercent
+	}
+	resolvedAmount := wallet.MinimumPaymentAmount
+	if minimumAmount != nil {
+		resolvedAmount = *minimumAmount
+	}
+	return resolvedClosingDay, resolvedPercent, resolvedAmount
+}
+
+func validateStatementSettings(isCredit bool, closingDay int, minimumPercent, minimumAmount float64) error {
+	if closingDay < 0 || closingDay > 31 {
+		return ErrInvalidStatementClosingDay
+	}
+	if closingDay > 0 && !isCredit {
+		return ErrStatementClosingDayNoCredit
+	}
+	if minimumPercent < 0 || minimumPercent > 100 || minimumAmount < 0 {
+		return ErrInvalidMinimumPayment
+	}
+	return nil
+}
+
 func normalizeWalletPeriod(period string) string {
 	period = strings.ToLower(strings.TrimSpace(period))
 	if period == "" {
@@ -0,0 +1,97 @@
+package expenses
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveStatementSettings(t *testing.T) {
+	wallet := Wallet{IsCredit: true, StatementClosingDay: 20, MinimumPaymentPercent: 5, MinimumPaymentAmount: 50}
+	closingDay, percent, minimum := 25, 3.0, 0.0
+
+	if day, gotPercent, gotMinimum := resolveStatementSettings(true, nil, nil, nil, wallet); day != 20 || gotPercent != 5 || gotMinimum != 50 {
+		t.Fatalf("expected stored settings to be kept, got %d %v %v", day, gotPercent, gotMinimum)
+	}
+	if day, gotPercent, gotMinimum := resolveStatementSettings(true, &closingDay, &percent, &minimum, wallet); day != 25 || gotPercent != 3 || gotMinimum != 0 {
+		t.Fatalf("expected submitted settings, got %d %v %v", day, gotPercent, gotMinimum)
+	}
+	if day, _, _ := resolveStatementSettings(false, nil, nil, nil, wallet); day != 0 {
+		t.Fatalf("expected the closing day to be dropped from a non-credit wallet, got %d", day)
+	}
+}
+
+func TestWalletService_GetWalletUtilization(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	card := Wallet{Name: "Card", IsCredit: true, StatementClosingDay: 10, CreditLimit: 1000, UtilizationAlert: 30, UserID: userID}
+	debit := Wallet{Name: "Debit", UserID: userID}
+	expenseType := ExpenseType{Name: "Shopping", RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&card).Error)
+	require.NoError(t, db.Create(&debit).Error)
+	require.NoError(t, db.Create(&expenseType).Error)
+
+	today := NormalizeDateOnly(time.Now().UTC())
+	statement := Statement{WalletID: card.ID, PeriodStart: today.AddDate(0, -1, 0), ClosingDate: today.AddDate(0, 0, -1), DueDate: today, UserID: userID}
+	require.NoError(t, db.Create(&statement).Error)
+	payment := Payment{WalletID: card.ID, Amount: 50, Date: today, UserID: userID}
+	require.NoError(t, db.Create(&payment).Error)
+	for _, expense := range []Expense{
+		{Amount: 200},
+		{Amount: 100, StatementID: &statement.ID},
+		{Amount: 50, StatementID: &statement.ID, PaymentID: &payment.ID},
+	} {
+		expense.ExpenseTypeID = expenseType.ID
+		expense.WalletID = &card.ID
+		expense.Date = today
+		expense.UserID = userID
+		require.NoError(t, db.Create(&expense).Error)
+	}
+
+	service := NewWalletService(db)
+	utilization, err := service.GetWalletUtilization(userID, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, utilization.Unbilled)
+	assert.Equal(t, 100.0, utilization.BilledUnpaid)
+	assert.Equal(t, 300.0, utilization.Outstanding)
+	assert.Equal(t, 700.0, utilization.Available)
+	assert.Equal(t, 30.0, utilization.Utilization)
+	assert.True(t, utilization.OverAlert)
+
+	_, err = service.GetWalletUtilization(userID, debit.ID)
+	assert.ErrorIs(t, err, ErrCreditLimitNotSet)
+}
+
+func TestWalletService_GetWalletUtilizationHistory(t *testing.T) {
+	db := setupTestDB(t)
+	const userID = 1
+	card := Wallet{Name: "Card", IsCredit: true, CreditLimit: 500, UtilizationAlert: 30, UserID: userID}
+	expenseType := ExpenseType{Name: "Shopping", RecurringType: RecurringTypeNone, UserID: userID}
+	require.NoError(t, db.Create(&card).Error)
+	require.NoError(t, db.Create(&expenseType).Error)
+
+	today := NormalizeDateOnly(time.Now().UTC())
+	thisMonth := BeginningOfMonth(today.Year(), int(today.Month()))
+	payment := Payment{WalletID: card.ID, Amount: 100, Date: thisMonth.AddDate(0, -1, 14), UserID: userID}
+	require.NoError(t, db.Create(&payment).Error)
+	paid := Expense{ExpenseTypeID: expenseType.ID, WalletID: &card.ID, PaymentID: &payment.ID, Amount: 100, Date: thisMonth.AddDate(0, -2, 0), UserID: userID}
+	unpaid := Expense{ExpenseTypeID: expenseType.ID, WalletID: &card.ID, Amount: 40, Date: thisMonth, UserID: userID}
+	require.NoError(t, db.Create(&paid).Error)
+	require.NoError(t, db.Create(&unpaid).Error)
+
+	history, err := NewWalletService(db).GetWalletUtilizationHistory(userID, card.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, history.Points, 3)
+	twoMonthsAgo := thisMonth.AddDate(0, -2, 0)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+	assert.Equal(t, EndOfMonth(twoMonthsAgo.Year(), int(twoMonthsAgo.Month())).Format(DateOnlyLayout), history.Points[0].Date)
+	assert.Equal(t, 100.0, history.Points[0].Outstanding, "unpaid until the payment date")
+	assert.Equal(t, 20.0, history.Points[0].Utilization)
+	assert.Equal(t, EndOfMonth(lastMonth.Year(), int(lastMonth.Month())).Format(DateOnlyLayout), history.Points[1].Date)
+	assert.Equal(t, 0.0, history.Points[1].Outstanding)
+	assert.Equal(t, today.Format(DateOnlyLayout), history.Points[2].Date)
+	assert.Equal(t, 40.0, history.Points[2].Outstanding)
+	assert.Equal(t, 8.0, history.Points[2].Utilization)
+}
@@ -0,0 +1,118 @@
+package forecast
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+	"dannyswat/jiceot/internal/expenses"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ForecastHandler struct {
+	service *ForecastService
+	planned *PlannedExpenseService
+}
+
+func NewForecastHandler(service *ForecastService, planned *PlannedExpenseService) *ForecastHandler {
+	return &ForecastHandler{service: service, planned: planned}
+}
+
+func (h *ForecastHandler) GetForecast(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	months := 0
+	if value := c.QueryParam("months"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid months"})
+		}
+		months = parsed
+	}
+	forecast, err := h.service.GetForecast(userID, months)
+	if err != nil {
+		return h.forecastError(c, err, "Failed to build forecast")
+	}
+	return c.JSON(http.StatusOK, forecast)
+}
+
+func (h *ForecastHandler) ListPlannedExpenses(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req PlannedExpenseListRequest
+	req.From = c.QueryParam("from")
+	req.To = c.QueryParam("to")
+	req.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	req.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	response, err := h.planned.ListPlannedExpenses(userID, req)
+	if err != nil {
+		return h.forecastError(c, err, "Failed to list planned expenses")
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ForecastHandler) CreatePlannedExpense(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req PlannedExpenseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	planned, err := h.planned.CreatePlannedExpense(userID, req)
+	if err != nil {
+		return h.forecastError(c, err, "Failed to create planned expense")
+	}
+	return c.JSON(http.StatusCreated, planned)
+}
+
+func (h *ForecastHandler) GetPlannedExpense(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	plannedExpenseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid planned expense ID"})
+	}
+	planned, err := h.planned.GetPlannedExpense(userID, uint(plannedExpenseID))
+	if err != nil {
+		return h.forecastError(c, err, "Failed to get planned expense")
+	}
+	return c.JSON(http.StatusOK, planned)
+}
+
+func (h *ForecastHandler) UpdatePlannedExpense(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	plannedExpenseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid planned expense ID"})
+	}
+	var req PlannedExpenseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request format"})
+	}
+	planned, err := h.planned.UpdatePlannedExpense(userID, uint(plannedExpenseID), req)
+	if err != nil {
+		return h.forecastError(c, err, "Failed to update planned expense")
+	}
+	return c.JSON(http.StatusOK, planned)
+}
+
+func (h *ForecastHandler) DeletePlannedExpense(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	plannedExpenseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid planned expense ID"})
+	}
+	if err := h.planned.DeletePlannedExpense(userID, uint(plannedExpenseID)); err != nil {
+		return h.forecastError(c, err, "Failed to delete planned expense")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Planned expense deleted successfully"})
+}
+
+func (h *ForecastHandler) forecastError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrPlannedExpenseNotFound), errors.Is(err, expenses.ErrExpenseTypeNotFound), errors.Is(err, expenses.ErrWalletNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvalidForecastMonths), errors.Is(err, ErrEmptyPlannedExpenseName), errors.Is(err, ErrInvalidPlannedAmount), errors.Is(err, ErrInvalidPlannedDate):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
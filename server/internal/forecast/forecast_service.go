@@ -0,0 +1,439 @@
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultForecastMonths = 3
+	MaxForecastMonths     = 24
+)
+
+// averageSampleSize is how many of a type's most recent expenses estimate its
+// amount when it has no default amount.
+const averageSampleSize = 3
+
+// maxCyclesPerSource stops a misconfigured schedule from flooding the
+// forecast; a weekly-like rule over two years stays well below it.
+const maxCyclesPerSource = 400
+
+const (
+	ItemKindExpenseType = "expense_type"
+	ItemKindWalletBill  = "wallet_bill"
+	ItemKindPlanned     = "planned"
+)
+
+// Basis values say where a forecast amount comes from.
+const (
+	BasisDefaultAmount = "default_amount"
+	BasisAverage       = "average"
+	BasisUnbilled      = "unbilled"
+	BasisPlanned       = "planned"
+)
+
+var ErrInvalidForecastMonths = errors.New("months must be between 1 and 24")
+
+// ForecastItem is one expected outflow. Overdue items were due before today
+// and are expected today.
+type ForecastItem struct {
+	Date     string  `json:"date"`
+	Kind     string  `json:"kind"`
+	SourceID uint    `json:"source_id"`
+	Name     string  `json:"name"`
+	Amount   float64 `json:"amount"`
+	Basis    string  `json:"basis"`
+	Overdue  bool    `json:"overdue"`
+}
+
+type ForecastDay struct {
+	Date  string         `json:"date"`
+	Total float64        `json:"total"`
+	Items []ForecastItem `json:"items"`
+}
+
+type ForecastMonth struct {
+	Year         int     `json:"year"`
+	Month        int     `json:"month"`
+	Total        float64 `json:"total"`
+	ExpenseTypes float64 `json:"expense_types"`
+	WalletBills  float64 `json:"wallet_bills"`
+	Planned      float64 `json:"planned"`
+}
+
+// Forecast lists expected outflows from today to the end of the last month,
+// grouped by month and by day. Days without outflows are omitted.
+type Forecast struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Total  float64         `json:"total"`
+	Months []ForecastMonth `json:"months"`
+	Days   []ForecastDay   `json:"days"`
+}
+
+// ForecastService projects recurring expense types, credit card bills and
+// planned expenses forward with the schedule helpers used by the dashboard.
+type ForecastService struct {
+	db *gorm.DB
+}
+
+func NewForecastService(db *gorm.DB) *ForecastService {
+	return &ForecastService{db: db}
+}
+
+// GetForecast projects the current month and the following months-1 months.
+func (s *ForecastService) GetForecast(userID uint, months int) (*Forecast, error) {
+	if months == 0 {
+		months = DefaultForecastMonths
+	}
+	if months < 1 || months > MaxForecastMonths {
+		return nil, ErrInvalidForecastMonths
+	}
+	today := expenses.NormalizeDateOnly(time.Now().UTC())
+	last := expenses.BeginningOfMonth(today.Year(), int(today.Month())).AddDate(0, months-1, 0)
+	in := projectionInput{
+		today:         today,
+		end:           expenses.EndOfMonth(last.Year(), int(last.Month())),
+		lastExpense:   make(map[uint]time.Time),
+		recentAmounts: make(map[uint][]float64),
+		lastPayment:   make(map[uint]expenses.Payment),
+	}
+
+	if err := s.db.Where("user_id = ? AND stopped = ? AND recurring_type <> ?", userID, false, expenses.RecurringTypeNone).Find(&in.expenseTypes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load expense types: %w", err)
+	}
+	if err := s.loadExpenseHistory(userID, &in); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Where("user_id = ? AND is_credit = ? AND stopped = ? AND bill_period <> ?", userID, true, false, expenses.WalletPeriodNone).Find(&in.wallets).Error; err != nil {
+		return nil, fmt.Errorf("failed to load wallets: %w", err)
+	}
+	if len(in.wallets) > 0 {
+		walletIDs := make([]uint, 0, len(in.wallets))
+		for _, wallet := range in.wallets {
+			walletIDs = append(walletIDs, wallet.ID)
+		}
+		var payments []expenses.Payment
+		if err := s.db.Where("user_id = ? AND wallet_id IN ? AND date <= ?", userID, walletIDs, today).Order("date DESC, id DESC").Find(&payments).Error; err != nil {
+			return nil, fmt.Errorf("failed to load payments: %w", err)
+		}
+		for _, payment := range payments {
+			if _, ok := in.lastPayment[payment.WalletID]; !ok {
+				in.lastPayment[payment.WalletID] = payment
+			}
+		}
+		if err := s.db.Where("user_id = ? AND wallet_id IN ? AND payment_id IS NULL AND date <= ?", userID, walletIDs, in.end).Find(&in.unbilled).Error; err != nil {
+			return nil, fmt.Errorf("failed to load unbilled expenses: %w", err)
+		}
+	}
+
+	if err := s.db.Where("user_id = ? AND date >= ? AND date <= ?", userID, today, in.end).Find(&in.planned).Error; err != nil {
+		return nil, fmt.Errorf("failed to load planned expenses: %w", err)
+	}
+
+	forecast := project(in)
+	return &forecast, nil
+}
+
+// loadExpenseHistory fills the last expense date and amount history of every
+// recurring type and the recent amounts of types without any amount.
+func (s *ForecastService) loadExpenseHistory(userID uint, in *projectionInput) error {
+	if len(in.expenseTypes) == 0 {
+		return nil
+	}
+	typeIDs := make([]uint, 0, len(in.expenseTypes))
+	for _, expenseType := range in.expenseTypes {
+		typeIDs = append(typeIDs, expenseType.ID)
+	}
+
+	type lastRow struct {
+		ExpenseTypeID uint
+		LastDate      time.Time
+	}
+	var rows []lastRow
+	if err := s.db.Model(&expenses.Expense{}).
+		Select("expense_type_id, MAX(date) AS last_date").
+		Where("user_id = ? AND expense_type_id IN ? AND date <= ?", userID, typeIDs, in.today).
+		Group("expense_type_id").
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load last expenses: %w", err)
+	}
+	for _, row := range rows {
+		in.lastExpense[row.ExpenseTypeID] = row.LastDate
+	}
+
+	amounts, err := expenses.LoadAmountSchedule(s.db, userID, typeIDs)
+	if err != nil {
+		return err
+	}
+	in.amounts = amounts
+
+	averagedIDs := make([]uint, 0)
+	for _, expenseType := range in.expenseTypes {
+		if in.amounts.HasAmount(expenseType) {
+			continue
+		}
+		averagedIDs = append(averagedIDs, expenseType.ID)
+	}
+	if len(averagedIDs) == 0 {
+		return nil
+	}
+	var recent []expenses.Expense
+	if err := s.db.Select("expense_type_id, amount").
+		Where("user_id = ? AND expense_type_id IN ? AND date <= ? AND date >= ?", userID, averagedIDs, in.today, in.today.AddDate(-1, 0, 0)).
+		Order("date DESC, id DESC").
+		Find(&recent).Error; err != nil {
+		return fmt.Errorf("failed to load recent expenses: %w", err)
+	}
+	for _, expense := range recent {
+		if len(in.recentAmounts[expense.ExpenseTypeID]) < averageSampleSize {
+			in.recentAmounts[expense.ExpenseTypeID] = append(in.recentAmounts[expense.ExpenseTypeID], expense.Amount)
+		}
+	}
+	return nil
+}
+
+// projectionInput is everything project needs, loaded up front so the
+// projection itself is pure.
+type projectionInput struct {
+	today         time.Time
+	end           time.Time
+	expenseTypes  []expenses.ExpenseType
+	lastExpense   map[uint]time.Time
+	recentAmounts map[uint][]float64
+	amounts       expenses.AmountSchedule
+	wallets       []expenses.Wallet
+	lastPayment   map[uint]expenses.Payment
+	unbilled      []expenses.Expense
+	planned       []PlannedExpense
+}
+
+type projectedBill struct {
+	due    time.Time
+	amount float64
+}
+
+type projectedItem struct {
+	date time.Time
+	item ForecastItem
+}
+
+// project builds the forecast. Charges to a billing credit wallet, whether
+// recorded, recurring or planned, are not outflows themselves; they are added
+// to the first bill of that wallet due after the charge.
+func project(in projectionInput) Forecast {
+	bills := make(map[uint][]projectedBill, len(in.wallets))
+	for _, wallet := range in.wallets {
+		var lastPayment *expenses.Payment
+		if payment, ok := in.lastPayment[wallet.ID]; ok {
+			lastPayment = &payment
+		}
+		due := expenses.NextWalletDueDate(wallet, in.today, lastPayment)
+		walletBills := make([]projectedBill, 0)
+		limit := cycleLimit(wallet.RemainingOccurrences)
+		for cycle := 0; cycle < limit && !due.After(in.end) && !wallet.EndsBefore(due); cycle++ {
+			walletBills = append(walletBills, projectedBill{due: due})
+			next := expenses.FollowingWalletDueDate(wallet, due)
+			if !next.After(due) {
+				break
+			}
+			due = next
+		}
+		bills[wallet.ID] = walletBills
+	}
+	// charge reports whether walletID is billed; a charge after the last bill
+	// in range is dropped because it is paid after the forecast ends.
+	charge := func(walletID *uint, date time.Time, amount float64) bool {
+		if walletID == nil {
+			return false
+		}
+		walletBills, ok := bills[*walletID]
+		if !ok {
+			return false
+		}
+		for index := range walletBills {
+			if walletBills[index].due.After(date) {
+				walletBills[index].amount += amount
+				break
+			}
+		}
+		return true
+	}
+
+	items := make([]projectedItem, 0)
+	add := func(date time.Time, item ForecastItem) {
+		item.Date = date.Format(expenses.DateOnlyLayout)
+		item.Amount = roundCents(item.Amount)
+		items = append(items, projectedItem{date: date, item: item})
+	}
+
+	for _, expense := range in.unbilled {
+		charge(expense.WalletID, expense.Date, expense.Amount)
+	}
+
+	for _, expenseType := range in.expenseTypes {
+		var lastExpense *time.Time
+		if date, ok := in.lastExpense[expenseType.ID]; ok {
+			lastExpense = &date
+		}
+		dates, overdue := expenseTypeDueDates(expenseType, lastExpense, in.today, in.end)
+		for index, due := range dates {
+			amount, basis := estimateAmount(expenseType, in.amounts, in.recentAmounts[expenseType.ID], due)
+			if amount <= 0 {
+				continue
+			}
+			if charge(expenseType.DefaultWalletID, due, amount) {
+				continue
+			}
+			add(due, ForecastItem{
+				Kind:     ItemKindExpenseType,
+				SourceID: expenseType.ID,
+				Name:     expenseType.Name,
+				Amount:   amount,
+				Basis:    basis,
+				Overdue:  overdue && index == 0,
+			})
+		}
+	}
+
+	for _, planned := range in.planned {
+		date := expenses.NormalizeDateOnly(planned.Date)
+		if date.Before(in.today) || date.After(in.end) || charge(planned.WalletID, date, planned.Amount) {
+			continue
+		}
+		add(date, ForecastItem{
+			Kind:     ItemKindPlanned,
+			SourceID: planned.ID,
+			Name:     planned.Name,
+			Amount:   planned.Amount,
+			Basis:    BasisPlanned,
+		})
+	}
+
+	for _, wallet := range in.wallets {
+		for _, bill := range bills[wallet.ID] {
+			if roundCents(bill.amount) <= 0 {
+				continue
+			}
+			add(bill.due, ForecastItem{
+				Kind:     ItemKindWalletBill,
+				SourceID: wallet.ID,
+				Name:     wallet.Name,
+				Amount:   bill.amount,
+				Basis:    BasisUnbilled,
+			})
+		}
+	}
+
+	return aggregate(in.today, in.end, items)
+}
+
+// expenseTypeDueDates returns the type's due dates from today to end. An
+// unpaid cycle due before today is expected today and reported as overdue;
+// older unpaid cycles are not counted again.
+func expenseTypeDueDates(expenseType expenses.ExpenseType, lastExpense *time.Time, today, end time.Time) ([]time.Time, bool) {
+	dates := make([]time.Time, 0)
+	due, err := expenses.NextExpenseTypeDueDate(expenseType, today, lastExpense)
+	if err != nil || due == nil {
+		return dates, false
+	}
+	overdue := due.Before(today)
+	limit := cycleLimit(expenseType.RemainingOccurrences)
+	for cycle := 0; cycle < maxCyclesPerSource && len(dates) < limit && due != nil && !due.After(end); cycle++ {
+		if !due.Before(today) {
+			dates = append(dates, *due)
+		} else if len(dates) == 0 {
+			dates = append(dates, today)
+		}
+		next, err := expenses.FollowingExpenseTypeDueDate(expenseType, *due)
+		if err != nil || next == nil || !next.After(*due) {
+			break
+		}
+		due = next
+	}
+	return dates, overdue
+}
+
+// cycleLimit is the number of cycles to project for a schedule with the
+// given remaining occurrences.
+func cycleLimit(remaining *int) int {
+	if remaining == nil || *remaining > maxCyclesPerSource {
+		return maxCyclesPerSource
+	}
+	return *remaining
+}
+
+// estimateAmount uses the type's amount in effect on due, or the average of
+// its most recent expenses when it has no amount at all.
+func estimateAmount(expenseType expenses.ExpenseType, amounts expenses.AmountSchedule, recent []float64, due time.Time) (float64, string) {
+	if amounts.HasAmount(expenseType) {
+		return amounts.AmountOn(expenseType, due), BasisDefaultAmount
+	}
+	if len(recent) == 0 {
+		return 0, ""
+	}
+	total := 0.0
+	for _, amount := range recent {
+		total += amount
+	}
+	return roundCents(total / float64(len(recent))), BasisAverage
+}
+
+func aggregate(today, end time.Time, items []projectedItem) Forecast {
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].date.Equal(items[j].date) {
+			return items[i].date.Before(items[j].date)
+		}
+		if items[i].item.Kind != items[j].item.Kind {
+			return items[i].item.Kind < items[j].item.Kind
+		}
+		return items[i].item.Name < items[j].item.Name
+	})
+
+	forecast := Forecast{
+		From:   today.Format(expenses.DateOnlyLayout),
+		To:     end.Format(expenses.DateOnlyLayout),
+		Months: make([]ForecastMonth, 0),
+		Days:   make([]ForecastDay, 0),
+	}
+	monthIndex := make(map[string]int)
+	for month := expenses.BeginningOfMonth(today.Year(), int(today.Month())); !month.After(end); month = month.AddDate(0, 1, 0) {
+		monthIndex[month.Format("2006-01")] = len(forecast.Months)
+		forecast.Months = append(forecast.Months, ForecastMonth{Year: month.Year(), Month: int(month.Month())})
+	}
+
+	for _, projected := range items {
+		item := projected.item
+		if len(forecast.Days) == 0 || forecast.Days[len(forecast.Days)-1].Date != item.Date {
+			forecast.Days = append(forecast.Days, ForecastDay{Date: item.Date, Items: make([]ForecastItem, 0)})
+		}
+		day := &forecast.Days[len(forecast.Days)-1]
+		day.Items = append(day.Items, item)
+		day.Total = roundCents(day.Total + item.Amount)
+
+		month := &forecast.Months[monthIndex[projected.date.Format("2006-01")]]
+		month.Total = roundCents(month.Total + item.Amount)
+		switch item.Kind {
+		case ItemKindExpenseType:
+			month.ExpenseTypes = roundCents(month.ExpenseTypes + item.Amount)
+		case ItemKindWalletBill:
+			month.WalletBills = roundCents(month.WalletBills + item.Amount)
+		case ItemKindPlanned:
+			month.Planned = roundCents(month.Planned + item.Amount)
+		}
+		forecast.Total = roundCents(forecast.Total + item.Amount)
+	}
+	return forecast
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,149 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+)
+
+func mustParseDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := expenses.ParseDateOnly(value)
+	if err != nil {
+		t.Fatalf("parse %s: %v", value, err)
+	}
+	return date
+}
+
+func TestProject(t *testing.T) {
+	walletID := uint(7)
+	flexibleDue := mustParseDate(t, "2025-03-01")
+	in := projectionInput{
+		today: mustParseDate(t, "2025-03-10"),
+		end:   mustParseDate(t, "2025-04-30"),
+		expenseTypes: []expenses.ExpenseType{
+			{ID: 1, Name: "Rent", RecurringType: expenses.RecurringTypeFixedDay, RecurringPeriod: expenses.RecurringPeriodMonthly, RecurringDueDay: 15, DefaultAmount: 100},
+			{ID: 2, Name: "Groceries", RecurringType: expenses.RecurringTypeFlexible, RecurringPeriod: expenses.RecurringPeriodMonthly, NextDueDay: &flexibleDue},
+			{ID: 3, Name: "Streaming", RecurringType: expenses.RecurringTypeFixedDay, RecurringPeriod: expenses.RecurringPeriodMonthly, RecurringDueDay: 20, DefaultAmount: 10, DefaultWalletID: &walletID},
+			{ID: 4, Name: "Unknown", RecurringType: expenses.RecurringTypeFixedDay, RecurringPeriod: expenses.RecurringPeriodMonthly, RecurringDueDay: 1},
+		},
+		lastExpense:   map[uint]time.Time{1: mustParseDate(t, "2025-02-14")},
+		recentAmounts: map[uint][]float64{2: {30, 60}},
+		wallets: []expenses.Wallet{
+			{ID: walletID, Name: "Visa", IsCredit: true, BillPeriod: expenses.WalletPeriodMonthly, BillDueDay: 25},
+		},
+		lastPayment: map[uint]expenses.Payment{},
+		unbilled: []expenses.Expense{
+			{ID: 1, WalletID: &walletID, Amount: 200, Date: mustParseDate(t, "2025-03-01")},
+			{ID: 2, WalletID: &walletID, Amount: 999, Date: mustParseDate(t, "2025-04-28")},
+		},
+		planned: []PlannedExpense{
+			{ID: 1, Name: "Holiday", Amount: 500, Date: mustParseDate(t, "2025-04-02")},
+			{ID: 2, Name: "Tyres", Amount: 50, Date: mustParseDate(t, "2025-04-05"), WalletID: &walletID},
+		},
+	}
+
+	forecast := project(in)
+
+	if forecast.From != "2025-03-10" || forecast.To != "2025-04-30" {
+		t.Fatalf("unexpected range %s..%s", forecast.From, forecast.To)
+	}
+	wantMonths := []ForecastMonth{
+		{Year: 2025, Month: 3, Total: 355, ExpenseTypes: 145, WalletBills: 210},
+		{Year: 2025, Month: 4, Total: 705, ExpenseTypes: 145, WalletBills: 60, Planned: 500},
+	}
+	if len(forecast.Months) != len(wantMonths) {
+		t.Fatalf("expected %d months, got %+v", len(wantMonths), forecast.Months)
+	}
+	for index, want := range wantMonths {
+		if forecast.Months[index] != want {
+			t.Errorf("month %d: expected %+v, got %+v", index, want, forecast.Months[index])
+		}
+	}
+	if forecast.Total != 1060 {
+		t.Errorf("expected total 1060, got %.2f", forecast.Total)
+	}
+
+	type dayItem struct {
+		date    string
+		name    string
+		amount  float64
+		basis   string
+		overdue bool
+	}
+	want := []dayItem{
+		{"2025-03-10", "Groceries", 45, BasisAverage, true},
+		{"2025-03-15", "Rent", 100, BasisDefaultAmount, false},
+		{"2025-03-25", "Visa", 210, BasisUnbilled, false},
+		{"2025-04-01", "Groceries", 45, BasisAverage, false},
+		{"2025-04-02", "Holiday", 500, BasisPlanned, false},
+		{"2025-04-15", "Rent", 100, BasisDefaultAmount, false},
+		{"2025-04-25", "Visa", 60, BasisUnbilled, false},
+	}
+	got := make([]dayItem, 0)
+	for _, day := range forecast.Days {
+		for _, item := range day.Items {
+			got = append(got, dayItem{day.Date, item.Name, item.Amount, item.Basis, item.Overdue})
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d items, got %+v", len(want), got)
+	}
+	for index := range want {
+		if got[index] != want[index] {
+			t.Errorf("item %d: expected %+v, got %+v", index, want[index], got[index])
+		}
+	}
+}
+
+func TestEstimateAmount(t *testing.T) {
+	due := mustParseDate(t, "2025-05-01")
+	amounts := expenses.AmountSchedule{
+		2: {
+			{ExpenseTypeID: 2, Amount: 12, EffectiveFrom: mustParseDate(t, "2025-01-01")},
+			{ExpenseTypeID: 2, Amount: 15, EffectiveFrom: mustParseDate(t, "2025-05-01")},
+		},
+	}
+	tests := []struct {
+		name        string
+		expenseType expenses.ExpenseType
+		recent      []float64
+		wantAmount  float64
+		wantBasis   string
+	}{
+		{name: "default amount wins", expenseType: expenses.ExpenseType{ID: 1, DefaultAmount: 80}, recent: []float64{10}, wantAmount: 80, wantBasis: BasisDefaultAmount},
+		{name: "scheduled change on the due date", expenseType: expenses.ExpenseType{ID: 2, DefaultAmount: 12}, wantAmount: 15, wantBasis: BasisDefaultAmount},
+		{name: "average of recent", expenseType: expenses.ExpenseType{ID: 3}, recent: []float64{10, 20, 20}, wantAmount: 16.67, wantBasis: BasisAverage},
+		{name: "no data", expenseType: expenses.ExpenseType{ID: 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amount, basis := estimateAmount(test.expenseType, amounts, test.recent, due)
+			if amount != test.wantAmount || basis != test.wantBasis {
+				t.Fatalf("expected %.2f %q, got %.2f %q", test.wantAmount, test.wantBasis, amount, basis)
+			}
+		})
+	}
+}
+
+func TestExpenseTypeDueDatesRespectScheduleEnd(t *testing.T) {
+	today := mustParseDate(t, "2025-03-01")
+	end := mustParseDate(t, "2025-12-31")
+	endDate := mustParseDate(t, "2025-05-31")
+	two := 2
+	base := expenses.ExpenseType{RecurringType: expenses.RecurringTypeFixedDay, RecurringPeriod: expenses.RecurringPeriodMonthly, RecurringDueDay: 15}
+
+	limited := base
+	limited.RemainingOccurrences = &two
+	if dates, _ := expenseTypeDueDates(limited, nil, today, end); len(dates) != 2 {
+		t.Errorf("expected 2 cycles with 2 remaining, got %d", len(dates))
+	}
+
+	ending := base
+	ending.EndDate = &endDate
+	dates, _ := expenseTypeDueDates(ending, nil, today, end)
+	if len(dates) != 3 || dates[2].Format(expenses.DateOnlyLayout) != "2025-05-15" {
+		t.Errorf("expected cycles through 2025-05-15, got %v", dates)
+	}
+}
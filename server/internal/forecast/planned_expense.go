@@ -0,0 +1,36 @@
+package forecast
+
+import (
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+
+	"gorm.io/gorm"
+)
+
+// PlannedExpense is a one-off outflow the user expects on a future date, such
+// as an annual insurance premium or a trip. It only feeds the forecast; it is
+// not an expense until the user records one.
+type PlannedExpense struct {
+	ID            uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	Name          string         `json:"name" gorm:"type:varchar(100);not null"`
+	Amount        float64        `json:"amount" gorm:"type:numeric(12,2);not null"`
+	Date          time.Time      `json:"date" gorm:"type:date;not null;index"`
+	ExpenseTypeID *uint          `json:"expense_type_id" gorm:"type:bigint;index"`
+	WalletID      *uint          `json:"wallet_id" gorm:"type:bigint;index"`
+	Note          string         `json:"note" gorm:"type:text"`
+	UserID        uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ExpenseType *expenses.ExpenseType `json:"expense_type,omitempty" gorm:"foreignKey:ExpenseTypeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Wallet      *expenses.Wallet      `json:"wallet,omitempty" gorm:"foreignKey:WalletID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+// RegisterMergeReferences makes planned expenses follow a wallet or expense
+// type when it is merged into another one.
+func RegisterMergeReferences(merge *expenses.MergeService) {
+	merge.AddWalletReferences(expenses.MergeReference{Name: "planned_expenses", Model: &PlannedExpense{}, Column: "wallet_id"})
+	merge.AddExpenseTypeReferences(expenses.MergeReference{Name: "planned_expenses", Model: &PlannedExpense{}, Column: "expense_type_id"})
+}
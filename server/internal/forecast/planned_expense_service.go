@@ -0,0 +1,171 @@
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"dannyswat/jiceot/internal/expenses"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPlannedExpenseNotFound  = errors.New("planned expense not found")
+	ErrEmptyPlannedExpenseName = errors.New("planned expense name is required")
+	ErrInvalidPlannedAmount    = errors.New("planned expense amount must be greater than 0")
+	ErrInvalidPlannedDate      = errors.New("planned expense date must be in YYYY-MM-DD format")
+)
+
+type PlannedExpenseService struct {
+	db *gorm.DB
+}
+
+type PlannedExpenseRequest struct {
+	Name          string  `json:"name"`
+	Amount        float64 `json:"amount"`
+	Date          string  `json:"date"`
+	ExpenseTypeID *uint   `json:"expense_type_id"`
+	WalletID      *uint   `json:"wallet_id"`
+	Note          string  `json:"note"`
+}
+
+type PlannedExpenseListRequest struct {
+	From   string
+	To     string
+	Limit  int
+	Offset int
+}
+
+type PlannedExpenseListResponse struct {
+	PlannedExpenses []PlannedExpense `json:"planned_expenses"`
+	Total           int64            `json:"total"`
+}
+
+func NewPlannedExpenseService(db *gorm.DB) *PlannedExpenseService {
+	return &PlannedExpenseService{db: db}
+}
+
+func (s *PlannedExpenseService) CreatePlannedExpense(userID uint, req PlannedExpenseRequest) (*PlannedExpense, error) {
+	planned := PlannedExpense{UserID: userID}
+	if err := s.apply(userID, &planned, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&planned).Error; err != nil {
+		return nil, fmt.Errorf("failed to create planned expense: %w", err)
+	}
+	return s.GetPlannedExpense(userID, planned.ID)
+}
+
+func (s *PlannedExpenseService) GetPlannedExpense(userID, plannedExpenseID uint) (*PlannedExpense, error) {
+	var planned PlannedExpense
+	if err := s.db.Preload("ExpenseType").Preload("Wallet").Where("id = ? AND user_id = ?", plannedExpenseID, userID).First(&planned).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlannedExpenseNotFound
+		}
+		return nil, fmt.Errorf("failed to get planned expense: %w", err)
+	}
+	return &planned, nil
+}
+
+func (s *PlannedExpenseService) UpdatePlannedExpense(userID, plannedExpenseID uint, req PlannedExpenseRequest) (*PlannedExpense, error) {
+	planned, err := s.GetPlannedExpense(userID, plannedExpenseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(userID, planned, req); err != nil {
+		return nil, err
+	}
+	planned.ExpenseType = nil
+	planned.Wallet = nil
+	if err := s.db.Save(planned).Error; err != nil {
+		return nil, fmt.Errorf("failed to update planned expense: %w", err)
+	}
+	return s.GetPlannedExpense(userID, planned.ID)
+}
+
+func (s *PlannedExpenseService) DeletePlannedExpense(userID, plannedExpenseID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", plannedExpenseID, userID).Delete(&PlannedExpense{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete planned expense: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPlannedExpenseNotFound
+	}
+	return nil
+}
+
+func (s *PlannedExpenseService) ListPlannedExpenses(userID uint, req PlannedExpenseListRequest) (*PlannedExpenseListResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 200 {
+		req.Limit = 200
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+	query := s.db.Model(&PlannedExpense{}).Where("user_id = ?", userID)
+	if req.From != "" {
+		from, err := expenses.ParseDateOnly(req.From)
+		if err != nil {
+			return nil, ErrInvalidPlannedDate
+		}
+		query = query.Where("date >= ?", from)
+	}
+	if req.To != "" {
+		to, err := expenses.ParseDateOnly(req.To)
+		if err != nil {
+			return nil, ErrInvalidPlannedDate
+		}
+		query = query.Where("date <= ?", to)
+	}
+
+	var response PlannedExpenseListResponse
+	if err := query.Count(&response.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count planned expenses: %w", err)
+	}
+	if err := query.Preload("ExpenseType").Preload("Wallet").Order("date ASC, id ASC").Limit(req.Limit).Offset(req.Offset).Find(&response.PlannedExpenses).Error; err != nil {
+		return nil, fmt.Errorf("failed to list planned expenses: %w", err)
+	}
+	return &response, nil
+}
+
+func (s *PlannedExpenseService) apply(userID uint, planned *PlannedExpense, req PlannedExpenseRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrEmptyPlannedExpenseName
+	}
+	if req.Amount <= 0 {
+		return ErrInvalidPlannedAmount
+	}
+	date, err := expenses.ParseDateOnly(strings.TrimSpace(req.Date))
+	if err != nil {
+		return ErrInvalidPlannedDate
+	}
+	if req.ExpenseTypeID != nil {
+		var count int64
+		if err := s.db.Model(&expenses.ExpenseType{}).Where("id = ? AND user_id = ?", *req.ExpenseTypeID, userID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to load expense type: %w", err)
+		}
+		if count == 0 {
+			return expenses.ErrExpenseTypeNotFound
+		}
+	}
+	if req.WalletID != nil {
+		var count int64
+		if err := s.db.Model(&expenses.Wallet{}).Where("id = ? AND user_id = ?", *req.WalletID, userID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to load wallet: %w", err)
+		}
+		if count == 0 {
+			return expenses.ErrWalletNotFound
+		}
+	}
+	planned.Name = name
+	planned.Amount = req.Amount
+	planned.Date = date
+	planned.ExpenseTypeID = req.ExpenseTypeID
+	planned.WalletID = req.WalletID
+	planned.Note = strings.TrimSpace(req.Note)
+	return nil
+}
@@ -1,59 +1,258 @@
 package notifications
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBarkURLEmpty   = errors.New("bark URL is empty")
+	ErrInvalidBarkURL = errors.New("invalid bark URL")
 )
 
-var ErrBarkURLEmpty = errors.New("bark URL is empty")
+// Bark interruption levels. Time-sensitive notifications break through
+// Focus; critical ones also play a sound when the device is muted.
+const (
+	BarkLevelActive        = "active"
+	BarkLevelTimeSensitive = "timeSensitive"
+	BarkLevelPassive       = "passive"
+	BarkLevelCritical      = "critical"
+)
 
 type BarkClient struct {
+	db         *gorm.DB
 	httpClient *http.Client
+	appURL     string
 }
 
-func NewBarkClient() *BarkClient {
+var _ Channel = (*BarkClient)(nil)
+
+// NewBarkClient creates a Bark channel. Each user's sound, level, group and
+// encryption settings are read from db. appURL is the web app's base URL
+// used for click-through links and may be empty.
+func NewBarkClient(db *gorm.DB, appURL string) *BarkClient {
 	return &BarkClient{
+		db:         db,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		appURL:     appURL,
 	}
 }
 
+// barkOptions are a user's Bark preferences from NotificationSetting.
+type barkOptions struct {
+	EncryptionKey string
+	EncryptionIV  string
+	Sound         string
+	Level         string
+	Group         string
+}
+
+// barkPayload is the body of Bark's JSON push API. When encrypting, the
+// content fields are sent inside Ciphertext instead.
+type barkPayload struct {
+	DeviceKey  string `json:"device_key,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Body       string `json:"body,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Icon       string `json:"icon,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Sound      string `json:"sound,omitempty"`
+	Level      string `json:"level,omitempty"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+	IV         string `json:"iv,omitempty"`
+}
+
+type barkResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
 // Send pushes a notification via Bark.
 // barkURL is the user's Bark server URL (e.g. "https://api.day.app/YOURKEY").
 func (c *BarkClient) Send(barkURL, title, body string) error {
-	if barkURL == "" {
-		return ErrBarkURLEmpty
-	}
+	return c.push(barkURL, pushContent{Title: title, Body: body}, barkOptions{})
+}
 
-	// Validate URL
-	parsed, err := url.Parse(barkURL)
+// push posts content to the server's /push endpoint, so long bodies are
+// not limited by URL length.
+func (c *BarkClient) push(barkURL string, content pushContent, options barkOptions) error {
+	serverURL, deviceKey, err := splitBarkURL(barkURL)
 	if err != nil {
-		return fmt.Errorf("invalid bark URL: %w", err)
-	}
-	if parsed.Scheme != "https" && parsed.Scheme != "http" {
-		return fmt.Errorf("bark URL must use http or https scheme")
+		return err
 	}
 
-	// Build the Bark push URL: <barkURL>/<title>/<body>
-	pushURL := strings.TrimRight(barkURL, "/") +
-		"/" + url.PathEscape(title) +
-		"/" + url.PathEscape(body) +
-		"?group=jiceot&icon=https://jiceot.dannyswat.me/jiceot.svg"
+	group := options.Group
+	if group == "" {
+		group = pushGroup
+	}
+	payload := barkPayload{
+		Title: content.Title,
+		Body:  content.Body,
+		URL:   content.ClickURL,
+		Icon:  pushIconURL,
+		Group: group,
+		Sound: options.Sound,
+		Level: options.Level,
+	}
+	if options.EncryptionKey != "" {
+		payload, err = encryptBarkPayload(payload, options.EncryptionKey, options.EncryptionIV)
+		if err != nil {
+			return err
+		}
+	}
+	payload.DeviceKey = deviceKey
 
-	resp, err := c.httpClient.Get(pushURL)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode bark payload: %w", err)
+	}
+	resp, err := c.httpClient.Post(serverURL+"/push", "application/json; charset=utf-8", bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("bark request failed: %w", err)
 	}
 	defer resp.Body.Close()
-	io.Copy(io.Discard, resp.Body)
 
+	var result barkResponse
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(respBody, &result) == nil && result.Code != 0 && result.Code != http.StatusOK {
+		return fmt.Errorf("bark returned code %d: %s", result.Code, result.Message)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("bark returned status %d", resp.StatusCode)
 	}
 
 	return nil
 }
+
+func (c *BarkClient) Type() string {
+	return ChannelBark
+}
+
+func (c *BarkClient) Validate(destination NotificationDestination) error {
+	return validateBarkURL(destination.Target)
+}
+
+// Deliver implements Channel; the destination target is the Bark server URL.
+func (c *BarkClient) Deliver(destination NotificationDestination, message Message) error {
+	options, err := c.options(destination.UserID)
+	if err != nil {
+		return err
+	}
+	if options.Level == "" && message.Kind == MessageKindReminder && mostPressingDays(message.Items) < 0 {
+		options.Level = BarkLevelTimeSensitive
+	}
+	return c.push(destination.Target, newPushContent(message, c.appURL), options)
+}
+
+func (c *BarkClient) options(userID uint) (barkOptions, error) {
+	var setting NotificationSetting
+	err := c.db.Where("user_id = ?", userID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return barkOptions{}, nil
+	}
+	if err != nil {
+		return barkOptions{}, fmt.Errorf("failed to load bark settings: %w", err)
+	}
+	return barkOptions{
+		EncryptionKey: setting.BarkEncryptionKey,
+		EncryptionIV:  setting.BarkEncryptionIV,
+		Sound:         setting.BarkSound,
+		Level:         setting.BarkLevel,
+		Group:         setting.BarkGroup,
+	}, nil
+}
+
+// encryptBarkPayload moves the content of payload into an AES-CBC
+// ciphertext that the Bark app decrypts with the same key and IV. Sound,
+// level and group stay in the clear because the server needs them. Without
+// a configured IV a random one is sent with each push.
+func encryptBarkPayload(payload barkPayload, key, iv string) (barkPayload, error) {
+	sendIV := iv == ""
+	if sendIV {
+		var err error
+		if iv, err = randomBarkIV(); err != nil {
+			return barkPayload{}, err
+		}
+	}
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return barkPayload{}, fmt.Errorf("%w: %v", ErrInvalidBarkEncryptionKey, err)
+	}
+	if len(iv) != aes.BlockSize {
+		return barkPayload{}, ErrInvalidBarkEncryptionIV
+	}
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return barkPayload{}, fmt.Errorf("failed to encode bark payload: %w", err)
+	}
+	// PKCS#7 padding.
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, []byte(iv)).CryptBlocks(ciphertext, plaintext)
+
+	encrypted := barkPayload{
+		Group:      payload.Group,
+		Sound:      payload.Sound,
+		Level:      payload.Level,
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}
+	if sendIV {
+		encrypted.IV = iv
+	}
+	return encrypted, nil
+}
+
+// randomBarkIV returns a 16-character alphanumeric IV; the Bark app reads
+// IVs as text.
+func randomBarkIV() (string, error) {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate bark IV: %w", err)
+	}
+	for i := range b {
+		b[i] = charset[int(b[i])%len(charset)]
+	}
+	return string(b), nil
+}
+
+// splitBarkURL splits a Bark URL such as "https://api.day.app/KEY" into the
+// server URL and the device key.
+func splitBarkURL(barkURL string) (string, string, error) {
+	if barkURL == "" {
+		return "", "", ErrBarkURLEmpty
+	}
+	parsed, err := validatePushURL(barkURL, ErrInvalidBarkURL)
+	if err != nil {
+		return "", "", err
+	}
+	path := strings.Trim(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	deviceKey := path[slash+1:]
+	if deviceKey == "" {
+		return "", "", fmt.Errorf("%w: missing device key", ErrInvalidBarkURL)
+	}
+	serverURL := parsed.Scheme + "://" + parsed.Host
+	if slash >= 0 {
+		serverURL += "/" + path[:slash]
+	}
+	return serverURL, deviceKey, nil
+}
+
+func validateBarkURL(barkURL string) error {
+	_, _, err := splitBarkURL(barkURL)
+	return err
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const ChannelBark = "bark"
+
+var (
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	// ErrInvalidDestinationTarget is wrapped by channels defined outside this
+	// package to reject a target.
+	ErrInvalidDestinationTarget = errors.New("invalid notification destination target")
+)
+
+// Channel delivers messages to one kind of destination, such as a Bark
+// device. Type is the value stored in NotificationDestination.Channel.
+type Channel interface {
+	Type() string
+	// Validate checks a destination's target before it is saved.
+	Validate(destination NotificationDestination) error
+	Deliver(destination NotificationDestination, message Message) error
+}
+
+// ChannelRegistry holds the channels the server can deliver through.
+type ChannelRegistry struct {
+	channels map[string]Channel
+}
+
+func NewChannelRegistry(channels ...Channel) *ChannelRegistry {
+	registry := &ChannelRegistry{channels: make(map[string]Channel, len(channels))}
+	for _, channel := range channels {
+		registry.Register(channel)
+	}
+	return registry
+}
+
+// Register adds channel, replacing any channel of the same type. Channels
+// are registered at startup, before the registry is shared.
+func (r *ChannelRegistry) Register(channel Channel) {
+	r.channels[channel.Type()] = channel
+}
+
+func (r *ChannelRegistry) Get(channelType string) (Channel, error) {
+	channel, ok := r.channels[strings.ToLower(strings.TrimSpace(channelType))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channelType)
+	}
+	return channel, nil
+}
+
+// Types lists the registered channel types in alphabetical order.
+func (r *ChannelRegistry) Types() []string {
+	types := make([]string, 0, len(r.channels))
+	for channelType := range r.channels {
+		types = append(types, channelType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// DeliveryResult is the outcome of sending a message to one destination.
+type DeliveryResult struct {
+	DestinationID uint   `json:"destination_id"`
+	Name          string `json:"name"`
+	Channel       string `json:"channel"`
+	Success       bool   `json:"success"`
+	Error         string `json:"error,omitempty"`
+}
+
+// sendAll delivers message to every destination and reports each outcome.
+// A failing destination does not stop delivery to the others.
+func (r *ChannelRegistry) sendAll(destinations []NotificationDestination, message Message) []DeliveryResult {
+	results := make([]DeliveryResult, 0, len(destinations))
+	for _, destination := range destinations {
+		result := DeliveryResult{DestinationID: destination.ID, Name: destination.Name, Channel: destination.Channel}
+		channel, err := r.Get(destination.Channel)
+		if err == nil {
+			err = channel.Deliver(destination, message)
+		}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeChannel struct {
+	channelType string
+	fail        map[string]bool
+	sent        []string
+}
+
+func (c *fakeChannel) Type() string {
+	return c.channelType
+}
+
+func (c *fakeChannel) Validate(NotificationDestination) error {
+	return nil
+}
+
+func (c *fakeChannel) Deliver(destination NotificationDestination, message Message) error {
+	if c.fail[destination.Target] {
+		return errors.New("unreachable")
+	}
+	c.sent = append(c.sent, destination.Target+": "+message.Title)
+	return nil
+}
+
+func TestChannelRegistryGet(t *testing.T) {
+	registry := NewChannelRegistry(&fakeChannel{channelType: "fake"}, NewBarkClient(nil, ""))
+
+	if _, err := registry.Get(" Fake "); err != nil {
+		t.Fatalf("expected channel lookup to ignore case and spaces, got %v", err)
+	}
+	if _, err := registry.Get("pager"); !errors.Is(err, ErrUnknownChannel) {
+		t.Fatalf("expected ErrUnknownChannel, got %v", err)
+	}
+	if types := registry.Types(); len(types) != 2 || types[0] != ChannelBark || types[1] != "fake" {
+		t.Fatalf("unexpected channel types %v", types)
+	}
+}
+
+func TestChannelRegistrySendAll(t *testing.T) {
+	channel := &fakeChannel{channelType: "fake", fail: map[string]bool{"down": true}}
+	registry := NewChannelRegistry(channel)
+	destinations := []NotificationDestination{
+		{ID: 1, Channel: "fake", Target: "down"},
+		{ID: 2, Channel: "fake", Target: "up"},
+		{ID: 3, Channel: "pager", Target: "up"},
+	}
+
+	results := registry.sendAll(destinations, Message{Title: "Due"})
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Success || results[0].Error != "unreachable" {
+		t.Errorf("expected the first destination to fail, got %+v", results[0])
+	}
+	if !results[1].Success || results[1].Error != "" {
+		t.Errorf("expected delivery after a failed destination, got %+v", results[1])
+	}
+	if results[2].Success || results[2].Error == "" {
+		t.Errorf("expected an unknown channel to fail, got %+v", results[2])
+	}
+	if len(channel.sent) != 1 || channel.sent[0] != "up: Due" {
+		t.Errorf("unexpected deliveries %v", channel.sent)
+	}
+}
+
+func TestValidateBarkURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{url: "https://api.day.app/KEY", want: nil},
+		{url: "", want: ErrBarkURLEmpty},
+		{url: "ftp://api.day.app/KEY", want: ErrInvalidBarkURL},
+		{url: "https://api.day.app", want: ErrInvalidBarkURL},
+	}
+	for _, tt := range tests {
+		if err := validateBarkURL(tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("validateBarkURL(%q) = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const ChannelEmail = "email"
+
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+)
+
+var ErrInvalidEmailAddress = errors.New("invalid email address")
+
+//go:embed templates/email.html.tmpl templates/email.txt.tmpl
+var emailTemplateFiles embed.FS
+
+var (
+	emailHTMLTemplate = htmltemplate.Must(htmltemplate.ParseFS(emailTemplateFiles, "templates/email.html.tmpl"))
+	emailTextTemplate = texttemplate.Must(texttemplate.ParseFS(emailTemplateFiles, "templates/email.txt.tmpl"))
+)
+
+// SMTPConfig holds the server-level SMTP settings used for email reminders.
+// Security is starttls, tls (implicit TLS, usually port 465) or none.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Security string
+}
+
+// EmailChannel delivers notifications by SMTP. Destinations are email
+// addresses and must be verified before reminders are sent to them.
+type EmailChannel struct {
+	config  SMTPConfig
+	timeout time.Duration
+}
+
+var _ Channel = (*EmailChannel)(nil)
+
+func NewEmailChannel(config SMTPConfig) *EmailChannel {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	config.Security = strings.ToLower(strings.TrimSpace(config.Security))
+	if config.Security == "" {
+		config.Security = SMTPSecurityStartTLS
+	}
+	return &EmailChannel{config: config, timeout: 15 * time.Second}
+}
+
+func (c *EmailChannel) Type() string {
+	return ChannelEmail
+}
+
+func (c *EmailChannel) RequiresVerification() bool {
+	return true
+}
+
+func (c *EmailChannel) Validate(destination NotificationDestination) error {
+	address, err := mail.ParseAddress(destination.Target)
+	if err != nil || address.Address != destination.Target {
+		return ErrInvalidEmailAddress
+	}
+	return nil
+}
+
+func (c *EmailChannel) Deliver(destination NotificationDestination, message Message) error {
+	from, err := mail.ParseAddress(c.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP sender address: %w", err)
+	}
+	subject, text, html, err := renderEmail(message)
+	if err != nil {
+		return err
+	}
+	body, err := buildEmail(c.config.From, destination.Target, subject, text, html, time.Now())
+	if err != nil {
+		return err
+	}
+	return c.send(from.Address, destination.Target, body)
+}
+
+func (c *EmailChannel) send(from, to string, body []byte) error {
+	address := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var conn net.Conn
+	var err error
+	if c.config.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: c.config.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp connect failed: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if c.config.Security == SMTPSecurityStartTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: c.config.Host}); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+	if c.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp sender rejected: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp recipient rejected: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data failed: %w", err)
+	}
+	if _, err := writer.Write(body); err != nil {
+		return fmt.Errorf("smtp write failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// emailContent is the data passed to the email templates.
+type emailContent struct {
+	Lang    string
+	Subject string
+	Intro   string
+	Items   []string
+	Code    string
+	Note    string
+	Footer  string
+}
+
+// renderEmail returns the localized subject and the plain-text and HTML
+// bodies of message.
+func renderEmail(message Message) (string, string, string, error) {
+	locale := localeStrings(message.Language)
+	content := emailContent{
+		Lang:    message.Language,
+		Subject: locale.subject(message),
+		Footer:  locale.Footer,
+	}
+	switch message.Kind {
+	case MessageKindReminder:
+		content.Intro = locale.ReminderIntro
+		for _, item := range message.Items {
+			content.Items = append(content.Items, locale.itemText(item))
+		}
+	case MessageKindTest:
+		content.Intro = locale.TestBody
+	case MessageKindVerification:
+		content.Intro = locale.VerifyIntro
+		content.Code = message.Code
+		content.Note = locale.VerifyExpiry
+	default:
+		content.Intro = message.Body
+	}
+
+	var text, html bytes.Buffer
+	if err := emailTextTemplate.Execute(&text, content); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email text: %w", err)
+	}
+	if err := emailHTMLTemplate.Execute(&html, content); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email html: %w", err)
+	}
+	return content.Subject, text.String(), html.String(), nil
+}
+
+// buildEmail assembles a multipart/alternative message with quoted-printable
+// text and HTML parts.
+func buildEmail(from, to, subject, text, html string, date time.Time) ([]byte, error) {
+	token := make([]byte, 12)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate message boundary: %w", err)
+	}
+	boundary := "jiceot-" + hex.EncodeToString(token)
+	domain := "jiceot"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(address.Address, "@"); at >= 0 {
+			domain = address.Address[at+1:]
+		}
+	}
+
+	var buffer bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buffer, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", to)
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(token), domain))
+	header("MIME-Version", "1.0")
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	buffer.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain; charset=UTF-8", body: text},
+		{contentType: "text/html; charset=UTF-8", body: html},
+	} {
+		fmt.Fprintf(&buffer, "--%s\r\n", boundary)
+		header("Content-Type", part.contentType)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buffer.WriteString("\r\n")
+		writer := quotedprintable.NewWriter(&buffer)
+		if _, err := writer.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n"))); err != nil {
+			return nil, fmt.Errorf("failed to encode email body: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode email body: %w", err)
+		}
+		buffer.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buffer, "--%s--\r\n", boundary)
+	return buffer.Bytes(), nil
+}
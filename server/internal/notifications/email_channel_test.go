@@ -0,0 +1,231 @@
+package notifications
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal SMTP server that accepts one message per connection
+// and records the envelope and data it received.
+type smtpSink struct {
+	listener net.Listener
+	messages chan sinkMessage
+}
+
+type sinkMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	sink := &smtpSink{listener: listener, messages: make(chan sinkMessage, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go sink.serve()
+	return sink
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+
+	var message sinkMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.to = append(message.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			message.data = data.String()
+			s.messages <- message
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailChannelDeliver(t *testing.T) {
+	sink := newSMTPSink(t)
+	channel := NewEmailChannel(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     sink.port(),
+		From:     "Jiceot <reminders@example.com>",
+		Security: SMTPSecurityNone,
+	})
+	destination := NotificationDestination{Channel: ChannelEmail, Target: "alex@example.com"}
+	message := reminderMessage([]DueItem{
+		{Kind: DueItemWallet, Name: "Visa", Days: -2},
+		{Kind: DueItemExpenseType, Name: "Rent <flat>", Days: 0, Final: true},
+	}, "en")
+
+	if err := channel.Deliver(destination, message); err != nil {
+		t.Fatalf("Deliver returned %v", err)
+	}
+
+	var received sinkMessage
+	select {
+	case received = <-sink.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the message")
+	}
+	if received.from != "reminders@example.com" {
+		t.Errorf("unexpected envelope sender %q", received.from)
+	}
+	if len(received.to) != 1 || received.to[0] != "alex@example.com" {
+		t.Errorf("unexpected envelope recipients %v", received.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(received.data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if subject := parsed.Header.Get("Subject"); subject != "Jiceot: 2 due item(s)" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if !strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("unexpected content type %q", parsed.Header.Get("Content-Type"))
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	for _, want := range []string{"- Visa — 2 day(s) overdue", "- Rent <flat> — final payment, due today", "<li>Rent &lt;flat&gt; — final payment, due today</li>"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestEmailChannelDeliverConnectionFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	channel := NewEmailChannel(SMTPConfig{Host: "127.0.0.1", Port: port, From: "reminders@example.com", Security: SMTPSecurityNone})
+	err = channel.Deliver(NotificationDestination{Target: "alex@example.com"}, testMessage("en"))
+	if err == nil || !strings.Contains(err.Error(), "smtp connect failed") {
+		t.Fatalf("expected a connect error, got %v", err)
+	}
+}
+
+func TestEmailChannelValidate(t *testing.T) {
+	channel := NewEmailChannel(SMTPConfig{Host: "localhost"})
+	tests := []struct {
+		target string
+		want   error
+	}{
+		{target: "alex@example.com", want: nil},
+		{target: "Alex <alex@example.com>", want: ErrInvalidEmailAddress},
+		{target: "not-an-address", want: ErrInvalidEmailAddress},
+		{target: "", want: ErrInvalidEmailAddress},
+	}
+	for _, tt := range tests {
+		if err := channel.Validate(NotificationDestination{Target: tt.target}); !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.target, err, tt.want)
+		}
+	}
+}
+
+func TestRenderEmailLocalized(t *testing.T) {
+	tests := []struct {
+		name    string
+		message Message
+		subject string
+		text    []string
+	}{
+		{
+			name:    "traditional chinese reminder",
+			message: reminderMessage([]DueItem{{Kind: DueItemWallet, Name: "Visa", Days: 3, Final: true}}, "zh-Hant"),
+			subject: "Jiceot：1 項即將到期",
+			text:    []string{"以下項目即將到期：", "Visa：最後一期帳單，3 天後到期"},
+		},
+		{
+			name:    "simplified chinese verification",
+			message: verificationMessage("042917", "zh-Hans"),
+			subject: "确认你的 Jiceot 提醒地址",
+			text:    []string{"042917", "验证码将在 24 小时后失效。"},
+		},
+		{
+			name:    "unknown language falls back to english",
+			message: testMessage("fr"),
+			subject: "Jiceot Test",
+			text:    []string{"Notifications are working!"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, text, html, err := renderEmail(tt.message)
+			if err != nil {
+				t.Fatalf("renderEmail returned %v", err)
+			}
+			if subject != tt.subject {
+				t.Errorf("subject = %q, want %q", subject, tt.subject)
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(text, want) {
+					t.Errorf("expected text to contain %q, got:\n%s", want, text)
+				}
+				if !strings.Contains(html, want) {
+					t.Errorf("expected html to contain %q, got:\n%s", want, html)
+				}
+			}
+		})
+	}
+}
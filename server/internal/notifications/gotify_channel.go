@@ -0,0 +1,106 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ChannelGotify = "gotify"
+
+var (
+	ErrInvalidGotifyURL    = errors.New("invalid gotify server URL")
+	ErrGotifyTokenRequired = errors.New("gotify application token is required")
+)
+
+// gotifyPriorities maps push urgency onto Gotify's 0-10 scale. The Android
+// app shows 8 and above as high priority notifications.
+var gotifyPriorities = map[pushUrgency]int{
+	pushUrgencyDefault: 5,
+	pushUrgencyHigh:    7,
+	pushUrgencyUrgent:  10,
+}
+
+// GotifyChannel posts to a Gotify server. The destination target is the
+// server URL and the auth token is the application token to post as.
+type GotifyChannel struct {
+	httpClient *http.Client
+	appURL     string
+}
+
+var _ Channel = (*GotifyChannel)(nil)
+
+func NewGotifyChannel(appURL string) *GotifyChannel {
+	return &GotifyChannel{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		appURL:     appURL,
+	}
+}
+
+func (c *GotifyChannel) Type() string {
+	return ChannelGotify
+}
+
+func (c *GotifyChannel) Validate(destination NotificationDestination) error {
+	if _, err := validatePushURL(destination.Target, ErrInvalidGotifyURL); err != nil {
+		return err
+	}
+	if destination.AuthToken == "" {
+		return ErrGotifyTokenRequired
+	}
+	return nil
+}
+
+type gotifyPayload struct {
+	Title    string                 `json:"title,omitempty"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+func (c *GotifyChannel) Deliver(destination NotificationDestination, message Message) error {
+	if err := c.Validate(destination); err != nil {
+		return err
+	}
+	content := newPushContent(message, c.appURL)
+	body := gotifyPayload{
+		Title:    content.Title,
+		Message:  content.Body,
+		Priority: gotifyPriorities[content.Urgency],
+	}
+	if content.ClickURL != "" {
+		body.Extras = map[string]interface{}{
+			"client::notification": map[string]interface{}{
+				"click": map[string]string{"url": content.ClickURL},
+			},
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode gotify payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(destination.Target, "/")+"/message", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("gotify request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", destination.AuthToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gotify request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("gotify returned status %d", resp.StatusCode)
+	}
+	return nil
+}
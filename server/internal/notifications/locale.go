@@ -0,0 +1,133 @@
+package notifications
+
+import (
+	"fmt"
+
+	"dannyswat/jiceot/internal/users"
+)
+
+// messageStrings holds the translated text of rendered notifications.
+type messageStrings struct {
+	ReminderSubject    string
+	ReminderIntro      string
+	TestSubject        string
+	TestBody           string
+	VerifySubject      string
+	VerifyIntro        string
+	VerifyExpiry       string
+	DueToday           string
+	DueTomorrow        string
+	DueInDays          string
+	Overdue            string
+	FinalBill          string
+	FinalPayment       string
+	Footer             string
+	ItemSeparator      string
+	FinalItemSeparator string
+}
+
+var messageLocales = map[string]messageStrings{
+	"en": {
+		ReminderSubject:    "Jiceot: %d due item(s)",
+		ReminderIntro:      "These items are due soon:",
+		TestSubject:        "Jiceot Test",
+		TestBody:           "Notifications are working!",
+		VerifySubject:      "Confirm your Jiceot reminder address",
+		VerifyIntro:        "Enter this code in Jiceot to start receiving reminders here:",
+		VerifyExpiry:       "The code expires in 24 hours.",
+		DueToday:           "due today",
+		DueTomorrow:        "due tomorrow",
+		DueInDays:          "due in %d days",
+		Overdue:            "%d day(s) overdue",
+		FinalBill:          "final bill",
+		FinalPayment:       "final payment",
+		Footer:             "You receive this message because reminders are enabled in Jiceot.",
+		ItemSeparator:      " — ",
+		FinalItemSeparator: ", ",
+	},
+	"zh-Hant": {
+		ReminderSubject:    "Jiceot：%d 項即將到期",
+		ReminderIntro:      "以下項目即將到期：",
+		TestSubject:        "Jiceot 測試",
+		TestBody:           "通知運作正常！",
+		VerifySubject:      "確認你的 Jiceot 提醒地址",
+		VerifyIntro:        "請在 Jiceot 輸入以下驗證碼，以開始在此接收提醒：",
+		VerifyExpiry:       "驗證碼將於 24 小時後失效。",
+		DueToday:           "今天到期",
+		DueTomorrow:        "明天到期",
+		DueInDays:          "%d 天後到期",
+		Overdue:            "已逾期 %d 天",
+		FinalBill:          "最後一期帳單",
+		FinalPayment:       "最後一期付款",
+		Footer:             "你收到此訊息，是因為你在 Jiceot 啟用了提醒。",
+		ItemSeparator:      "：",
+		FinalItemSeparator: "，",
+	},
+	"zh-Hans": {
+		ReminderSubject:    "Jiceot：%d 项即将到期",
+		ReminderIntro:      "以下项目即将到期：",
+		TestSubject:        "Jiceot 测试",
+		TestBody:           "通知运行正常！",
+		VerifySubject:      "确认你的 Jiceot 提醒地址",
+		VerifyIntro:        "请在 Jiceot 输入以下验证码，以开始在此接收提醒：",
+		VerifyExpiry:       "验证码将在 24 小时后失效。",
+		DueToday:           "今天到期",
+		DueTomorrow:        "明天到期",
+		DueInDays:          "%d 天后到期",
+		Overdue:            "已逾期 %d 天",
+		FinalBill:          "最后一期账单",
+		FinalPayment:       "最后一期付款",
+		Footer:             "你收到此消息，是因为你在 Jiceot 启用了提醒。",
+		ItemSeparator:      "：",
+		FinalItemSeparator: "，",
+	},
+}
+
+// localeStrings returns the strings for language, falling back to English.
+func localeStrings(language string) messageStrings {
+	if strings, ok := messageLocales[language]; ok {
+		return strings
+	}
+	return messageLocales[users.DefaultLanguage]
+}
+
+func (s messageStrings) dueLabel(days int) string {
+	switch {
+	case days < 0:
+		return fmt.Sprintf(s.Overdue, -days)
+	case days == 0:
+		return s.DueToday
+	case days == 1:
+		return s.DueTomorrow
+	default:
+		return fmt.Sprintf(s.DueInDays, days)
+	}
+}
+
+// itemText renders a due item in the language of s, without the emoji
+// prefixes used by plain reminders.
+func (s messageStrings) itemText(item DueItem) string {
+	label := s.dueLabel(item.Days)
+	if item.Final {
+		final := s.FinalPayment
+		if item.Kind == DueItemWallet {
+			final = s.FinalBill
+		}
+		label = final + s.FinalItemSeparator + label
+	}
+	return item.Name + s.ItemSeparator + label
+}
+
+// subject returns the localized title of message.
+func (s messageStrings) subject(message Message) string {
+	switch message.Kind {
+	case MessageKindReminder:
+		return fmt.Sprintf(s.ReminderSubject, len(message.Items))
+	case MessageKindTest:
+		return s.TestSubject
+	case MessageKindVerification:
+		return s.VerifySubject
+	default:
+		return message.Title
+	}
+}
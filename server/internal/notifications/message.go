@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MessageKindReminder     = "reminder"
+	MessageKindTest         = "test"
+	MessageKindVerification = "verification"
+
+	DueItemWallet      = "wallet"
+	DueItemExpenseType = "expense_type"
+)
+
+// Message is a notification ready for delivery. Title and Body are the plain
+// English rendering; channels that render their own layout use Kind, Items,
+// Language and Code instead.
+type Message struct {
+	Kind     string
+	Title    string
+	Body     string
+	Items    []DueItem
+	Language string
+	Code     string
+}
+
+// DueItem is one wallet bill or expense type in a reminder.
+type DueItem struct {
+	Kind     string    `json:"kind"`
+	ID       uint      `json:"id"`
+	Name     string    `json:"name"`
+	DueDate  time.Time `json:"due_date"`
+	Days     int       `json:"days"`
+	Flexible bool      `json:"flexible"`
+	Final    bool      `json:"final"`
+}
+
+// Text renders the item as one line of a plain reminder.
+func (i DueItem) Text() string {
+	switch {
+	case i.Final && i.Kind == DueItemWallet:
+		return fmt.Sprintf("🏁 %s — final bill, %s", i.Name, dueLabel(i.Days))
+	case i.Final:
+		return fmt.Sprintf("🏁 %s — final payment, %s", i.Name, dueLabel(i.Days))
+	case i.Kind == DueItemWallet:
+		return fmt.Sprintf("💳 %s — %s", i.Name, dueLabel(i.Days))
+	case i.Flexible:
+		return fmt.Sprintf("🔄 %s — %s", i.Name, dueLabel(i.Days))
+	default:
+		return fmt.Sprintf("📋 %s — %s", i.Name, dueLabel(i.Days))
+	}
+}
+
+func reminderMessage(items []DueItem, language string) Message {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, item.Text())
+	}
+	return Message{
+		Kind:     MessageKindReminder,
+		Title:    fmt.Sprintf("Jiceot: %d due item(s)", len(items)),
+		Body:     strings.Join(lines, "\n"),
+		Items:    items,
+		Language: language,
+	}
+}
+
+func testMessage(language string) Message {
+	return Message{Kind: MessageKindTest, Title: "Jiceot Test", Body: "Notifications are working!", Language: language}
+}
+
+func verificationMessage(code, language string) Message {
+	return Message{
+		Kind:     MessageKindVerification,
+		Title:    "Jiceot verification code",
+		Body:     "Your Jiceot verification code is " + code,
+		Language: language,
+		Code:     code,
+	}
+}
+
+func dueLabel(days int) string {
+	if days < 0 {
+		return fmt.Sprintf("%d day(s) overdue", -days)
+	}
+	if days == 0 {
+		return "due today"
+	}
+	if days == 1 {
+		return "due tomorrow"
+	}
+	return fmt.Sprintf("due in %d days", days)
+}
+
+// userLanguage returns the user's preferred language, or the default when it
+// cannot be loaded.
+func userLanguage(db *gorm.DB, userID uint) string {
+	var user users.User
+	if err := db.Select("language").Where("id = ?", userID).First(&user).Error; err != nil || user.Language == "" {
+		return users.DefaultLanguage
+	}
+	return user.Language
+}
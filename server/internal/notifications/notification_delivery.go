@@ -0,0 +1,164 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Failed reminders are retried after 5, 15, 45 and 135 minutes, as long as
+// the retry falls on the same day in the user's timezone.
+const (
+	maxDeliveryAttempts  = 5
+	firstRetryDelay      = 5 * time.Minute
+	retryBackoffFactor   = 3
+	retryBatchSize       = 50
+	deliveryRetention    = 30 * 24 * time.Hour
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// NotificationDelivery records one attempt to send a message to a
+// destination. A failed reminder attempt with NextAttemptAt set is retried,
+// and the retry is recorded as a new attempt.
+type NotificationDelivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey;type:bigint"`
+	UserID        uint       `json:"user_id" gorm:"type:bigint;not null;index"`
+	DestinationID uint       `json:"destination_id" gorm:"type:bigint;not null;index"`
+	Channel       string     `json:"channel" gorm:"type:varchar(20);not null"`
+	Name          string     `json:"name" gorm:"type:varchar(100);not null;default:''"`
+	Kind          string     `json:"kind" gorm:"type:varchar(20);not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Attempt       int        `json:"attempt" gorm:"not null;default:1"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null"`
+	Error         string     `json:"error" gorm:"type:text;not null;default:''"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"type:timestamptz;index"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+
+	User        *users.User              `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Destination *NotificationDestination `json:"-" gorm:"foreignKey:DestinationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// deliveryPayload is the stored form of a message. Verification codes are
+// masked so the log does not reveal them.
+type deliveryPayload struct {
+	Kind     string    `json:"kind"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Items    []DueItem `json:"items,omitempty"`
+	Language string    `json:"language,omitempty"`
+}
+
+func encodeDeliveryPayload(message Message) string {
+	payload := deliveryPayload{
+		Kind:     message.Kind,
+		Title:    message.Title,
+		Body:     message.Body,
+		Items:    message.Items,
+		Language: message.Language,
+	}
+	if message.Code != "" {
+		payload.Body = strings.ReplaceAll(payload.Body, message.Code, strings.Repeat("•", len(message.Code)))
+	}
+	data, _ := json.Marshal(payload)
+	return string(data)
+}
+
+func decodeDeliveryPayload(data string) (Message, error) {
+	var payload deliveryPayload
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return Message{}, fmt.Errorf("invalid delivery payload: %w", err)
+	}
+	return Message{
+		Kind:     payload.Kind,
+		Title:    payload.Title,
+		Body:     payload.Body,
+		Items:    payload.Items,
+		Language: payload.Language,
+	}, nil
+}
+
+// retryDelay returns how long to wait after the given failed attempt.
+func retryDelay(attempt int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= retryBackoffFactor
+	}
+	return delay
+}
+
+// nextDeliveryAttempt returns when to retry a message whose attempt failed
+// at at, or nil once the attempts are used up or the retry would fall on
+// the next day in loc.
+func nextDeliveryAttempt(attempt int, at time.Time, loc *time.Location) *time.Time {
+	if attempt >= maxDeliveryAttempts {
+		return nil
+	}
+	next := at.Add(retryDelay(attempt))
+	if next.In(loc).Format("2006-01-02") != at.In(loc).Format("2006-01-02") {
+		return nil
+	}
+	return &next
+}
+
+// recordDeliveries stores the latest outcome on each destination and logs
+// each result as an attempt to deliver message. Failed results are
+// scheduled for retry at nextAttempt when it is not nil.
+func recordDeliveries(db *gorm.DB, userID uint, message Message, results []DeliveryResult, attempt int, at time.Time, nextAttempt *time.Time) {
+	payload := encodeDeliveryPayload(message)
+	for _, result := range results {
+		updates := map[string]interface{}{"last_attempt_at": at, "last_error": result.Error}
+		if result.Success {
+			updates["last_success_at"] = at
+		}
+		db.Model(&NotificationDestination{}).Where("id = ?", result.DestinationID).Updates(updates)
+
+		delivery := NotificationDelivery{
+			UserID:        userID,
+			DestinationID: result.DestinationID,
+			Channel:       result.Channel,
+			Name:          result.Name,
+			Kind:          message.Kind,
+			Payload:       payload,
+			Attempt:       attempt,
+			Status:        DeliveryStatusSucceeded,
+			Error:         result.Error,
+			CreatedAt:     at,
+		}
+		if !result.Success {
+			delivery.Status = DeliveryStatusFailed
+			delivery.NextAttemptAt = nextAttempt
+		}
+		db.Create(&delivery)
+	}
+}
+
+// listDeliveries returns the user's most recent delivery attempts, newest
+// first, optionally for one destination.
+func listDeliveries(db *gorm.DB, userID, destinationID uint, limit int) ([]NotificationDelivery, error) {
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+	query := db.Where("user_id = ?", userID)
+	if destinationID != 0 {
+		query = query.Where("destination_id = ?", destinationID)
+	}
+	var deliveries []NotificationDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{5 * time.Minute, 15 * time.Minute, 45 * time.Minute, 135 * time.Minute}
+	for i, delay := range want {
+		if got := retryDelay(i + 1); got != delay {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+}
+
+func TestNextDeliveryAttempt(t *testing.T) {
+	hongKong, err := time.LoadLocation("Asia/Hong_Kong")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 09:00 in Hong Kong.
+	morning := time.Date(2026, 5, 1, 1, 0, 0, 0, time.UTC)
+	// 23:50 in Hong Kong, still 15:50 in UTC.
+	lateNight := time.Date(2026, 5, 1, 15, 50, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		attempt int
+		at      time.Time
+		loc     *time.Location
+		want    *time.Time
+	}{
+		{name: "first retry", attempt: 1, at: morning, loc: hongKong, want: ptrTime(morning.Add(5 * time.Minute))},
+		{name: "backoff", attempt: 3, at: morning, loc: hongKong, want: ptrTime(morning.Add(45 * time.Minute))},
+		{name: "attempts used up", attempt: maxDeliveryAttempts, at: morning, loc: hongKong},
+		{name: "past midnight locally", attempt: 2, at: lateNight, loc: hongKong},
+		{name: "same day in UTC", attempt: 2, at: lateNight, loc: time.UTC, want: ptrTime(lateNight.Add(15 * time.Minute))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextDeliveryAttempt(tt.attempt, tt.at, tt.loc)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("nextDeliveryAttempt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func TestDeliveryPayloadRoundTrip(t *testing.T) {
+	message := reminderMessage([]DueItem{{Kind: DueItemExpenseType, ID: 4, Name: "Rent", DueDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Days: 0}}, "zh-TW")
+
+	got, err := decodeDeliveryPayload(encodeDeliveryPayload(message))
+	if err != nil {
+		t.Fatalf("decodeDeliveryPayload returned %v", err)
+	}
+	if !reflect.DeepEqual(got, message) {
+		t.Errorf("round trip = %+v, want %+v", got, message)
+	}
+}
+
+func TestDeliveryPayloadMasksVerificationCode(t *testing.T) {
+	payload := encodeDeliveryPayload(verificationMessage("493021", "en"))
+	if strings.Contains(payload, "493021") {
+		t.Errorf("expected the code to be masked, got %s", payload)
+	}
+	message, err := decodeDeliveryPayload(payload)
+	if err != nil {
+		t.Fatalf("decodeDeliveryPayload returned %v", err)
+	}
+	if message.Kind != MessageKindVerification || message.Code != "" {
+		t.Errorf("unexpected message %+v", message)
+	}
+}
@@ -0,0 +1,37 @@
+package notifications
+
+import (
+	"time"
+
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+type NotificationDestination struct {
+	ID                    uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	UserID                uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	Channel               string         `json:"channel" gorm:"type:varchar(20);not null"`
+	Name                  string         `json:"name" gorm:"type:varchar(100);not null;default:''"`
+	Target                string         `json:"target" gorm:"type:varchar(500);not null"`
+	AuthToken             string         `json:"-" gorm:"type:varchar(255);not null;default:''"`
+	HasAuthToken          bool           `json:"has_auth_token" gorm:"-"`
+	Enabled               bool           `json:"enabled" gorm:"not null;default:true"`
+	PendingVerification   bool           `json:"pending_verification" gorm:"not null;default:false"`
+	VerificationCodeHash  string         `json:"-" gorm:"type:varchar(64);not null;default:''"`
+	VerificationExpiresAt *time.Time     `json:"-" gorm:"type:timestamptz"`
+	VerificationAttempts  int            `json:"-" gorm:"not null;default:0"`
+	LastAttemptAt         *time.Time     `json:"last_attempt_at" gorm:"type:timestamptz"`
+	LastSuccessAt         *time.Time     `json:"last_success_at" gorm:"type:timestamptz"`
+	LastError             string         `json:"last_error" gorm:"type:text;not null;default:''"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
+
+	User *users.User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (d *NotificationDestination) AfterFind(*gorm.DB) error {
+	d.HasAuthToken = d.AuthToken != ""
+	return nil
+}
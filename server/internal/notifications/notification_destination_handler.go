@@ -0,0 +1,198 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationDestinationHandler struct {
+	service *NotificationDestinationService
+}
+
+func NewNotificationDestinationHandler(service *NotificationDestinationService) *NotificationDestinationHandler {
+	return &NotificationDestinationHandler{service: service}
+}
+
+// ListDestinations handles GET /api/notification-destinations
+func (h *NotificationDestinationHandler) ListDestinations(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	destinations, err := h.service.ListDestinations(userID)
+	if err != nil {
+		return h.destinationError(c, err, "Failed to list notification destinations")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"destinations": destinations,
+		"channels":     h.service.channels.Types(),
+	})
+}
+
+// CreateDestination handles POST /api/notification-destinations
+func (h *NotificationDestinationHandler) CreateDestination(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req NotificationDestinationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	destination, err := h.service.CreateDestination(userID, req)
+	if err != nil {
+		return h.destinationError(c, err, "Failed to create notification destination")
+	}
+	return c.JSON(http.StatusCreated, destination)
+}
+
+// UpdateDestination handles PUT /api/notification-destinations/:id
+func (h *NotificationDestinationHandler) UpdateDestination(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid destination ID"})
+	}
+	var req NotificationDestinationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	destination, err := h.service.UpdateDestination(userID, uint(destinationID), req)
+	if err != nil {
+		return h.destinationError(c, err, "Failed to update notification destination")
+	}
+	return c.JSON(http.StatusOK, destination)
+}
+
+// DeleteDestination handles DELETE /api/notification-destinations/:id
+func (h *NotificationDestinationHandler) DeleteDestination(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid destination ID"})
+	}
+	if err := h.service.DeleteDestination(userID, uint(destinationID)); err != nil {
+		return h.destinationError(c, err, "Failed to delete notification destination")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Notification destination deleted successfully"})
+}
+
+// VerifyDestination handles POST /api/notification-destinations/:id/verify
+func (h *NotificationDestinationHandler) VerifyDestination(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid destination ID"})
+	}
+	var req VerifyDestinationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	destination, err := h.service.VerifyDestination(userID, uint(destinationID), req.Code)
+	if err != nil {
+		return h.destinationError(c, err, "Failed to verify notification destination")
+	}
+	return c.JSON(http.StatusOK, destination)
+}
+
+// ResendVerification handles POST /api/notification-destinations/:id/resend-verification
+func (h *NotificationDestinationHandler) ResendVerification(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid destination ID"})
+	}
+	result, err := h.service.ResendVerification(userID, uint(destinationID))
+	if err != nil {
+		return h.destinationError(c, err, "Failed to send verification code")
+	}
+	if !result.Success {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":  "Failed to send verification code: " + result.Error,
+			"result": result,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Verification code sent successfully",
+		"result":  result,
+	})
+}
+
+// TestDestination handles POST /api/notification-destinations/:id/test
+func (h *NotificationDestinationHandler) TestDestination(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid destination ID"})
+	}
+	result, err := h.service.TestDestination(userID, uint(destinationID))
+	if err != nil {
+		return h.destinationError(c, err, "Failed to send test notification")
+	}
+	if !result.Success {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":  "Failed to send test notification: " + result.Error,
+			"result": result,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Test notification sent successfully",
+		"result":  result,
+	})
+}
+
+// TestAllDestinations handles POST /api/notification-settings/test and sends
+// a test message to every enabled destination.
+func (h *NotificationDestinationHandler) TestAllDestinations(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	results, err := h.service.TestAll(userID)
+	if err != nil {
+		return h.destinationError(c, err, "Failed to send test notification")
+	}
+	for _, result := range results {
+		if !result.Success {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"error":   "Failed to send test notification to " + result.Name + ": " + result.Error,
+				"results": results,
+			})
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Test notification sent successfully",
+		"results": results,
+	})
+}
+
+// ListDeliveries handles GET /api/notification-deliveries
+func (h *NotificationDestinationHandler) ListDeliveries(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var destinationID uint64
+	if value := c.QueryParam("destination_id"); value != "" {
+		var err error
+		if destinationID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid destination ID"})
+		}
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	deliveries, err := h.service.ListDeliveries(userID, uint(destinationID), limit)
+	if err != nil {
+		return h.destinationError(c, err, "Failed to list notification deliveries")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+func (h *NotificationDestinationHandler) destinationError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrNotificationDestinationNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrDestinationNotVerified), errors.Is(err, ErrDestinationAlreadyVerified):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrUnknownChannel), errors.Is(err, ErrEmptyDestinationTarget), errors.Is(err, ErrNoNotificationDestinations),
+		errors.Is(err, ErrBarkURLEmpty), errors.Is(err, ErrInvalidBarkURL), errors.Is(err, ErrInvalidEmailAddress),
+		errors.Is(err, ErrInvalidNtfyTopicURL), errors.Is(err, ErrInvalidGotifyURL), errors.Is(err, ErrGotifyTokenRequired),
+		errors.Is(err, ErrInvalidVerificationCode), errors.Is(err, ErrVerificationCodeExpired), errors.Is(err, ErrInvalidDestinationTarget),
+		errors.Is(err, ErrWebPushSubscriptionRequired):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,346 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Verification codes are valid for a day and allow a few guesses before a
+// new code has to be requested.
+const (
+	verificationCodeTTL     = 24 * time.Hour
+	maxVerificationAttempts = 5
+)
+
+var (
+	ErrNotificationDestinationNotFound = errors.New("notification destination not found")
+	ErrEmptyDestinationTarget          = errors.New("destination target is required")
+	ErrNoNotificationDestinations      = errors.New("no enabled notification destinations")
+	ErrDestinationNotVerified          = errors.New("destination has not been verified")
+	ErrDestinationAlreadyVerified      = errors.New("destination is already verified")
+	ErrInvalidVerificationCode         = errors.New("invalid verification code")
+	ErrVerificationCodeExpired         = errors.New("verification code has expired, request a new one")
+)
+
+type NotificationDestinationService struct {
+	db       *gorm.DB
+	channels *ChannelRegistry
+}
+
+// NotificationDestinationRequest creates or updates a destination. AuthToken
+// is left unchanged when omitted and cleared when empty.
+type NotificationDestinationRequest struct {
+	Channel   string  `json:"channel"`
+	Name      string  `json:"name"`
+	Target    string  `json:"target"`
+	AuthToken *string `json:"auth_token"`
+	Enabled   *bool   `json:"enabled"`
+}
+
+type VerifyDestinationRequest struct {
+	Code string `json:"code"`
+}
+
+func NewNotificationDestinationService(db *gorm.DB, channels *ChannelRegistry) *NotificationDestinationService {
+	return &NotificationDestinationService{db: db, channels: channels}
+}
+
+func (s *NotificationDestinationService) ListDestinations(userID uint) ([]NotificationDestination, error) {
+	destinations := make([]NotificationDestination, 0)
+	if err := s.db.Where("user_id = ?", userID).Order("id ASC").Find(&destinations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification destinations: %w", err)
+	}
+	return destinations, nil
+}
+
+func (s *NotificationDestinationService) GetDestination(userID, destinationID uint) (*NotificationDestination, error) {
+	var destination NotificationDestination
+	if err := s.db.Where("id = ? AND user_id = ?", destinationID, userID).First(&destination).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificationDestinationNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification destination: %w", err)
+	}
+	return &destination, nil
+}
+
+// CreateDestination saves a destination. Destinations of a channel that
+// requires verification start pending and are sent a code straight away; a
+// failed send is recorded on the destination and can be retried.
+func (s *NotificationDestinationService) CreateDestination(userID uint, req NotificationDestinationRequest) (*NotificationDestination, error) {
+	destination := NotificationDestination{UserID: userID, Enabled: true}
+	channel, err := s.apply(&destination, req)
+	if err != nil {
+		return nil, err
+	}
+	destination.PendingVerification = requiresVerification(channel)
+	if err := s.db.Create(&destination).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification destination: %w", err)
+	}
+	if destination.PendingVerification {
+		if err := s.sendVerification(&destination); err != nil {
+			return nil, err
+		}
+	}
+	return &destination, nil
+}
+
+func (s *NotificationDestinationService) UpdateDestination(userID, destinationID uint, req NotificationDestinationRequest) (*NotificationDestination, error) {
+	destination, err := s.GetDestination(userID, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	previous := *destination
+	channel, err := s.apply(destination, req)
+	if err != nil {
+		return nil, err
+	}
+	// A changed address has to be verified again; an unchanged one keeps its
+	// state so that editing the name does not reset a pending code.
+	changed := destination.Channel != previous.Channel || destination.Target != previous.Target
+	if !requiresVerification(channel) {
+		destination.PendingVerification = false
+	} else if changed {
+		destination.PendingVerification = true
+	}
+	if err := s.db.Save(destination).Error; err != nil {
+		return nil, fmt.Errorf("failed to update notification destination: %w", err)
+	}
+	if destination.PendingVerification && changed {
+		if err := s.sendVerification(destination); err != nil {
+			return nil, err
+		}
+	}
+	return destination, nil
+}
+
+// ResendVerification sends a new code to a destination awaiting verification.
+func (s *NotificationDestinationService) ResendVerification(userID, destinationID uint) (*DeliveryResult, error) {
+	destination, err := s.GetDestination(userID, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	if !destination.PendingVerification {
+		return nil, ErrDestinationAlreadyVerified
+	}
+	if err := s.sendVerification(destination); err != nil {
+		return nil, err
+	}
+	result := DeliveryResult{DestinationID: destination.ID, Name: destination.Name, Channel: destination.Channel, Success: destination.LastError == "", Error: destination.LastError}
+	return &result, nil
+}
+
+// VerifyDestination confirms a destination with the code sent to it.
+func (s *NotificationDestinationService) VerifyDestination(userID, destinationID uint, code string) (*NotificationDestination, error) {
+	destination, err := s.GetDestination(userID, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	if !destination.PendingVerification {
+		return nil, ErrDestinationAlreadyVerified
+	}
+	if destination.VerificationExpiresAt == nil || time.Now().After(*destination.VerificationExpiresAt) || destination.VerificationAttempts >= maxVerificationAttempts {
+		return nil, ErrVerificationCodeExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(strings.TrimSpace(code))), []byte(destination.VerificationCodeHash)) != 1 {
+		if err := s.db.Model(destination).Update("verification_attempts", gorm.Expr("verification_attempts + 1")).Error; err != nil {
+			return nil, fmt.Errorf("failed to record verification attempt: %w", err)
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+	destination.PendingVerification = false
+	destination.VerificationCodeHash = ""
+	destination.VerificationExpiresAt = nil
+	destination.VerificationAttempts = 0
+	if err := s.db.Save(destination).Error; err != nil {
+		return nil, fmt.Errorf("failed to verify notification destination: %w", err)
+	}
+	return destination, nil
+}
+
+func (s *NotificationDestinationService) DeleteDestination(userID, destinationID uint) error {
+	if _, err := s.GetDestination(userID, destinationID); err != nil {
+		return err
+	}
+	if err := s.db.Where("id = ? AND user_id = ?", destinationID, userID).Delete(&NotificationDestination{}).Error; err != nil {
+		return fmt.Errorf("failed to delete notification destination: %w", err)
+	}
+	return nil
+}
+
+// TestDestination sends a test message to one destination, even a disabled one.
+func (s *NotificationDestinationService) TestDestination(userID, destinationID uint) (*DeliveryResult, error) {
+	destination, err := s.GetDestination(userID, destinationID)
+	if err != nil {
+		return nil, err
+	}
+	if destination.PendingVerification {
+		return nil, ErrDestinationNotVerified
+	}
+	results := s.deliver(userID, []NotificationDestination{*destination}, testMessage(userLanguage(s.db, userID)))
+	return &results[0], nil
+}
+
+// TestAll sends a test message to each of the user's enabled destinations.
+func (s *NotificationDestinationService) TestAll(userID uint) ([]DeliveryResult, error) {
+	destinations, err := enabledDestinations(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(destinations) == 0 {
+		return nil, ErrNoNotificationDestinations
+	}
+	return s.deliver(userID, destinations, testMessage(userLanguage(s.db, userID))), nil
+}
+
+// ListDeliveries returns the user's most recent delivery attempts, newest
+// first. A non-zero destinationID limits them to that destination.
+func (s *NotificationDestinationService) ListDeliveries(userID, destinationID uint, limit int) ([]NotificationDelivery, error) {
+	if destinationID != 0 {
+		if _, err := s.GetDestination(userID, destinationID); err != nil {
+			return nil, err
+		}
+	}
+	return listDeliveries(s.db, userID, destinationID, limit)
+}
+
+// deliver sends message and records the outcome. Messages sent from the
+// API are not retried; the caller sees the result straight away.
+func (s *NotificationDestinationService) deliver(userID uint, destinations []NotificationDestination, message Message) []DeliveryResult {
+	results := s.channels.sendAll(destinations, message)
+	recordDeliveries(s.db, userID, message, results, 1, time.Now().UTC(), nil)
+	return results
+}
+
+// sendVerification issues a new code for destination and sends it.
+func (s *NotificationDestinationService) sendVerification(destination *NotificationDestination) error {
+	code, err := generateVerificationCode()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(verificationCodeTTL)
+	destination.VerificationCodeHash = hashVerificationCode(code)
+	destination.VerificationExpiresAt = &expiresAt
+	destination.VerificationAttempts = 0
+	if err := s.db.Save(destination).Error; err != nil {
+		return fmt.Errorf("failed to store verification code: %w", err)
+	}
+	result := s.deliver(destination.UserID, []NotificationDestination{*destination}, verificationMessage(code, userLanguage(s.db, destination.UserID)))[0]
+	destination.LastError = result.Error
+	return nil
+}
+
+func (s *NotificationDestinationService) apply(destination *NotificationDestination, req NotificationDestinationRequest) (Channel, error) {
+	channel, err := s.channels.Get(req.Channel)
+	if err != nil {
+		return nil, err
+	}
+	destination.Channel = channel.Type()
+	destination.Name = strings.TrimSpace(req.Name)
+	destination.Target = strings.TrimSpace(req.Target)
+	if destination.Name == "" {
+		destination.Name = destination.Channel
+	}
+	if req.AuthToken != nil {
+		destination.AuthToken = strings.TrimSpace(*req.AuthToken)
+	}
+	destination.HasAuthToken = destination.AuthToken != ""
+	if req.Enabled != nil {
+		destination.Enabled = *req.Enabled
+	}
+	if destination.Target == "" {
+		return nil, ErrEmptyDestinationTarget
+	}
+	if err := channel.Validate(*destination); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+// requiresVerification reports whether destinations of channel must be
+// confirmed with a code before reminders are sent to them.
+func requiresVerification(channel Channel) bool {
+	verifying, ok := channel.(interface{ RequiresVerification() bool })
+	return ok && verifying.RequiresVerification()
+}
+
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func enabledDestinations(db *gorm.DB, userID uint) ([]NotificationDestination, error) {
+	var destinations []NotificationDestination
+	if err := db.Where("user_id = ? AND enabled = ? AND pending_verification = ?", userID, true, false).Order("id ASC").Find(&destinations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load notification destinations: %w", err)
+	}
+	return destinations, nil
+}
+
+// syncLegacyBarkDestination keeps the Bark URL from notification settings in
+// step with the user's first Bark destination. An empty URL removes it.
+func syncLegacyBarkDestination(db *gorm.DB, userID uint, barkURL string) error {
+	barkURL = strings.TrimSpace(barkURL)
+	var destination NotificationDestination
+	err := db.Where("user_id = ? AND channel = ?", userID, ChannelBark).Order("id ASC").First(&destination).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load bark destination: %w", err)
+	}
+	exists := err == nil
+	if barkURL == "" {
+		if !exists {
+			return nil
+		}
+		if err := db.Delete(&destination).Error; err != nil {
+			return fmt.Errorf("failed to delete bark destination: %w", err)
+		}
+		return nil
+	}
+	if !exists {
+		destination = NotificationDestination{UserID: userID, Channel: ChannelBark, Name: "Bark", Enabled: true}
+	}
+	destination.Target = barkURL
+	if err := validateBarkURL(barkURL); err != nil {
+		return err
+	}
+	if err := db.Save(&destination).Error; err != nil {
+		return fmt.Errorf("failed to save bark destination: %w", err)
+	}
+	return nil
+}
+
+// MigrateLegacyBarkDestinations creates a Bark destination for every user
+// whose notification settings still carry only a Bark URL. Users who have
+// ever had a Bark destination, even a deleted one, are left alone.
+func MigrateLegacyBarkDestinations(db *gorm.DB) error {
+	var settings []NotificationSetting
+	if err := db.Where("bark_url <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM notification_destinations d WHERE d.user_id = notification_settings.user_id AND d.channel = ?)", ChannelBark).
+		Find(&settings).Error; err != nil {
+		return fmt.Errorf("failed to load legacy bark settings: %w", err)
+	}
+	for _, setting := range settings {
+		destination := NotificationDestination{UserID: setting.UserID, Channel: ChannelBark, Name: "Bark", Target: setting.BarkURL, Enabled: true}
+		if err := db.Create(&destination).Error; err != nil {
+			return fmt.Errorf("failed to migrate bark destination for user %d: %w", setting.UserID, err)
+		}
+	}
+	return nil
+}
@@ -7,15 +7,30 @@ import (
 )
 
 type NotificationSetting struct {
-	ID             uint           `json:"id" gorm:"primaryKey;type:bigint"`
-	UserID         uint           `json:"user_id" gorm:"type:bigint;not null;uniqueIndex"`
-	BarkURL        string         `json:"bark_url" gorm:"type:varchar(500)"`
-	Enabled        bool           `json:"enabled" gorm:"not null;default:false"`
-	ReminderTime   string         `json:"reminder_time" gorm:"type:varchar(5);not null;default:''"` // HH:MM
-	Timezone       string         `json:"timezone" gorm:"type:varchar(100);not null;default:''"`
-	DueDaysAhead   int            `json:"due_days_ahead" gorm:"not null;default:3"`
-	LastNotifiedAt *time.Time     `json:"last_notified_at" gorm:"type:timestamptz"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             uint       `json:"id" gorm:"primaryKey;type:bigint"`
+	UserID         uint       `json:"user_id" gorm:"type:bigint;not null;uniqueIndex"`
+	BarkURL        string     `json:"bark_url" gorm:"type:varchar(500)"`
+	Enabled        bool       `json:"enabled" gorm:"not null;default:false"`
+	ReminderTime   string     `json:"reminder_time" gorm:"type:varchar(5);not null;default:''"` // HH:MM
+	Timezone       string     `json:"timezone" gorm:"type:varchar(100);not null;default:''"`
+	DueDaysAhead   int        `json:"due_days_ahead" gorm:"not null;default:3"`
+	LastNotifiedAt *time.Time `json:"last_notified_at" gorm:"type:timestamptz"`
+
+	// Bark options, applied to all of the user's Bark destinations. An empty
+	// level sends overdue reminders as time-sensitive.
+	BarkEncryptionKey    string `json:"-" gorm:"type:varchar(32);not null;default:''"`
+	HasBarkEncryptionKey bool   `json:"has_bark_encryption_key" gorm:"-"`
+	BarkEncryptionIV     string `json:"bark_encryption_iv" gorm:"type:varchar(16);not null;default:''"`
+	BarkSound            string `json:"bark_sound" gorm:"type:varchar(50);not null;default:''"`
+	BarkLevel            string `json:"bark_level" gorm:"type:varchar(20);not null;default:''"`
+	BarkGroup            string `json:"bark_group" gorm:"type:varchar(50);not null;default:''"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (s *NotificationSetting) AfterFind(*gorm.DB) error {
+	s.HasBarkEncryptionKey = s.BarkEncryptionKey != ""
+	return nil
 }
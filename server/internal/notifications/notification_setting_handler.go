@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"errors"
 	"net/http"
 
 	"dannyswat/jiceot/internal/auth"
@@ -42,32 +43,17 @@ func (h *NotificationSettingHandler) UpdateSettings(c echo.Context) error {
 	}
 
 	setting, err := h.service.Update(userID, req)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update notification settings",
+	if errors.Is(err, ErrInvalidBarkURL) || errors.Is(err, ErrInvalidBarkEncryptionKey) || errors.Is(err, ErrInvalidBarkEncryptionIV) ||
+		errors.Is(err, ErrInvalidBarkSound) || errors.Is(err, ErrInvalidBarkLevel) || errors.Is(err, ErrInvalidBarkGroup) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
 		})
 	}
-
-	return c.JSON(http.StatusOK, setting)
-}
-
-// TestBark handles POST /api/notification-settings/test
-func (h *NotificationSettingHandler) TestBark(c echo.Context) error {
-	userID := auth.GetUserIDFromContext(c)
-
-	err := h.service.TestBark(userID)
 	if err != nil {
-		if err == ErrBarkURLEmpty {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Bark URL is not configured",
-			})
-		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to send test notification: " + err.Error(),
+			"error": "Failed to update notification settings",
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Test notification sent successfully",
-	})
+	return c.JSON(http.StatusOK, setting)
 }
@@ -1,9 +1,21 @@
 package notifications
 
 import (
+	"crypto/aes"
+	"errors"
+	"strings"
+
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidBarkEncryptionKey = errors.New("bark encryption key must be 16, 24 or 32 characters")
+	ErrInvalidBarkEncryptionIV  = errors.New("bark encryption IV must be 16 characters")
+	ErrInvalidBarkSound         = errors.New("bark sound must be a sound name of letters, digits, '-', '_' or '.'")
+	ErrInvalidBarkLevel         = errors.New("bark level must be active, timeSensitive, passive or critical")
+	ErrInvalidBarkGroup         = errors.New("bark group must be at most 50 characters")
+)
+
 type NotificationSettingService struct {
 	db *gorm.DB
 }
@@ -17,18 +29,28 @@ func (s *NotificationSettingService) GetByUserID(userID uint) (*NotificationSett
 	err := s.db.Where("user_id = ?", userID).First(&setting).Error
 	if err == gorm.ErrRecordNotFound {
 		// Return defaults
-		return &NotificationSetting{
+		setting = NotificationSetting{
 			UserID:       userID,
 			Enabled:      false,
 			ReminderTime: "",
 			Timezone:     "",
 			DueDaysAhead: 3,
-		}, nil
+		}
+		setting.BarkURL, err = legacyBarkURL(s.db, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &setting, nil
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	setting.BarkURL, err = legacyBarkURL(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	normalizedTimezone := normalizeNotificationTimezone(setting.Timezone)
 	if normalizedTimezone != setting.Timezone {
 		if err := s.db.Model(&NotificationSetting{}).Where("id = ?", setting.ID).Update("timezone", normalizedTimezone).Error; err != nil {
@@ -46,6 +68,13 @@ type UpdateNotificationSettingRequest struct {
 	ReminderTime *string `json:"reminder_time"`
 	Timezone     *string `json:"timezone"`
 	DueDaysAhead *int    `json:"due_days_ahead"`
+
+	// An empty encryption key turns encryption off.
+	BarkEncryptionKey *string `json:"bark_encryption_key"`
+	BarkEncryptionIV  *string `json:"bark_encryption_iv"`
+	BarkSound         *string `json:"bark_sound"`
+	BarkLevel         *string `json:"bark_level"`
+	BarkGroup         *string `json:"bark_group"`
 }
 
 func (s *NotificationSettingService) Update(userID uint, req UpdateNotificationSettingRequest) (*NotificationSetting, error) {
@@ -67,7 +96,10 @@ func (s *NotificationSettingService) Update(userID uint, req UpdateNotificationS
 	}
 
 	if req.BarkURL != nil {
-		setting.BarkURL = *req.BarkURL
+		if err := syncLegacyBarkDestination(s.db, userID, *req.BarkURL); err != nil {
+			return nil, err
+		}
+		setting.BarkURL = strings.TrimSpace(*req.BarkURL)
 	}
 	if req.Enabled != nil {
 		setting.Enabled = *req.Enabled
@@ -88,6 +120,9 @@ func (s *NotificationSettingService) Update(userID uint, req UpdateNotificationS
 		}
 		setting.DueDaysAhead = days
 	}
+	if err := applyBarkOptions(&setting, req); err != nil {
+		return nil, err
+	}
 
 	if err := s.db.Save(&setting).Error; err != nil {
 		return nil, err
@@ -95,14 +130,63 @@ func (s *NotificationSettingService) Update(userID uint, req UpdateNotificationS
 	return &setting, nil
 }
 
-func (s *NotificationSettingService) TestBark(userID uint) error {
-	setting, err := s.GetByUserID(userID)
-	if err != nil {
-		return err
+// applyBarkOptions validates and sets the Bark fields present in req.
+func applyBarkOptions(setting *NotificationSetting, req UpdateNotificationSettingRequest) error {
+	if req.BarkEncryptionKey != nil {
+		key := strings.TrimSpace(*req.BarkEncryptionKey)
+		if key != "" && len(key) != 16 && len(key) != 24 && len(key) != 32 {
+			return ErrInvalidBarkEncryptionKey
+		}
+		setting.BarkEncryptionKey = key
+	}
+	if req.BarkEncryptionIV != nil {
+		iv := strings.TrimSpace(*req.BarkEncryptionIV)
+		if iv != "" && len(iv) != aes.BlockSize {
+			return ErrInvalidBarkEncryptionIV
+		}
+		setting.BarkEncryptionIV = iv
+	}
+	if req.BarkSound != nil {
+		sound := strings.TrimSpace(*req.BarkSound)
+		if len(sound) > 50 || strings.TrimFunc(sound, isBarkSoundRune) != "" {
+			return ErrInvalidBarkSound
+		}
+		setting.BarkSound = sound
+	}
+	if req.BarkLevel != nil {
+		level := strings.TrimSpace(*req.BarkLevel)
+		switch level {
+		case "", BarkLevelActive, BarkLevelTimeSensitive, BarkLevelPassive, BarkLevelCritical:
+		default:
+			return ErrInvalidBarkLevel
+		}
+		setting.BarkLevel = level
+	}
+	if req.BarkGroup != nil {
+		group := strings.TrimSpace(*req.BarkGroup)
+		if len(group) > 50 {
+			return ErrInvalidBarkGroup
+		}
+		setting.BarkGroup = group
+	}
+	setting.HasBarkEncryptionKey = setting.BarkEncryptionKey != ""
+	return nil
+}
+
+func isBarkSoundRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'
+}
+
+// legacyBarkURL returns the target of the user's first Bark destination,
+// which the settings API still exposes as bark_url.
+func legacyBarkURL(db *gorm.DB, userID uint) (string, error) {
+	var destination NotificationDestination
+	err := db.Where("user_id = ? AND channel = ?", userID, ChannelBark).Order("id ASC").First(&destination).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", nil
 	}
-	if setting.BarkURL == "" {
-		return ErrBarkURLEmpty
+	if err != nil {
+		return "", err
 	}
-	client := NewBarkClient()
-	return client.Send(setting.BarkURL, "Jiceot Test", "Bark notifications are working!")
+	return destination.Target, nil
 }
@@ -2,9 +2,7 @@ package notifications
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"dannyswat/jiceot/internal/expenses"
@@ -12,24 +10,48 @@ import (
 	"gorm.io/gorm"
 )
 
+// EventReminderDue is published with the due items whenever a reminder is
+// sent.
+const EventReminderDue = "reminder.due"
+
+// EventPublisher receives reminder events alongside channel delivery.
+// Publish returns how many subscribers the event was queued for.
+type EventPublisher interface {
+	Publish(userID uint, eventType string, data interface{}) int
+}
+
+// ReminderEvent is the data of a reminder.due event.
+type ReminderEvent struct {
+	Items []DueItem `json:"items"`
+}
+
 type Notifier struct {
-	db     *gorm.DB
-	bark   *BarkClient
-	cancel context.CancelFunc
-	done   chan struct{}
+	db       *gorm.DB
+	channels *ChannelRegistry
+	events   EventPublisher
+	cancel   context.CancelFunc
+	done     chan struct{}
 }
 
-func NewNotifier(db *gorm.DB) *Notifier {
+func NewNotifier(db *gorm.DB, channels *ChannelRegistry) *Notifier {
 	return &Notifier{
-		db:   db,
-		bark: NewBarkClient(),
-		done: make(chan struct{}),
+		db:       db,
+		channels: channels,
+		done:     make(chan struct{}),
 	}
 }
 
+// SetEventPublisher sets where reminder events are published.
+func (n *Notifier) SetEventPublisher(publisher EventPublisher) {
+	n.events = publisher
+}
+
 // Start begins the background notification loop. It checks every minute
 // whether any user's reminder time has arrived (in their local timezone)
-// and sends a Bark notification if there are due items.
+// and sends the due items to each of the user's enabled destinations.
+// Failed deliveries are retried with backoff until the end of the user's
+// day. Expired push subscriptions and old delivery records are removed
+// hourly.
 func (n *Notifier) Start() {
 	ctx, cancel := context.WithCancel(context.Background())
 	n.cancel = cancel
@@ -38,10 +60,13 @@ func (n *Notifier) Start() {
 		defer close(n.done)
 		ticker := time.NewTicker(1 * time.Minute)
 		defer ticker.Stop()
+		cleanupTicker := time.NewTicker(1 * time.Hour)
+		defer cleanupTicker.Stop()
 
 		log.Println("[notifier] Background notifier started")
 
 		// Run once immediately on startup
+		n.cleanup()
 		n.checkAndNotify()
 
 		for {
@@ -51,6 +76,8 @@ func (n *Notifier) Start() {
 				return
 			case <-ticker.C:
 				n.checkAndNotify()
+			case <-cleanupTicker.C:
+				n.cleanup()
 			}
 		}
 	}()
@@ -64,14 +91,71 @@ func (n *Notifier) Stop() {
 	}
 }
 
+// cleanup removes push subscriptions that can no longer be delivered to and
+// delivery records past their retention.
+func (n *Notifier) cleanup() {
+	now := time.Now().UTC()
+	removed, err := CleanupPushSubscriptions(n.db, now)
+	if err != nil {
+		log.Printf("[notifier] Failed to clean up push subscriptions: %v", err)
+	} else if removed > 0 {
+		log.Printf("[notifier] Removed %d expired push subscription(s)", removed)
+	}
+	if err := n.db.Where("created_at < ?", now.Add(-deliveryRetention)).Delete(&NotificationDelivery{}).Error; err != nil {
+		log.Printf("[notifier] Failed to prune notification deliveries: %v", err)
+	}
+}
+
+// retryDeliveries re-sends failed reminders whose retry is due. Each retry
+// is recorded as a new attempt, which schedules the next retry if it fails.
+func (n *Notifier) retryDeliveries(now time.Time) {
+	var due []NotificationDelivery
+	if err := n.db.Where("next_attempt_at <= ?", now).Order("id ASC").Limit(retryBatchSize).Find(&due).Error; err != nil {
+		log.Printf("[notifier] Failed to load deliveries to retry: %v", err)
+		return
+	}
+	for _, delivery := range due {
+		// Clear the schedule first so a retry is attempted once.
+		claimed := n.db.Model(&NotificationDelivery{}).Where("id = ? AND next_attempt_at IS NOT NULL", delivery.ID).Update("next_attempt_at", nil)
+		if claimed.Error != nil || claimed.RowsAffected == 0 {
+			continue
+		}
+		n.retryDelivery(delivery, now)
+	}
+}
+
+func (n *Notifier) retryDelivery(delivery NotificationDelivery, now time.Time) {
+	var destination NotificationDestination
+	if err := n.db.Where("id = ? AND enabled = ? AND pending_verification = ?", delivery.DestinationID, true, false).First(&destination).Error; err != nil {
+		// Removed or disabled since the last attempt.
+		return
+	}
+	message, err := decodeDeliveryPayload(delivery.Payload)
+	if err != nil {
+		log.Printf("[notifier] Failed to retry delivery %d: %v", delivery.ID, err)
+		return
+	}
+
+	attempt := delivery.Attempt + 1
+	results := n.channels.sendAll([]NotificationDestination{destination}, message)
+	recordDeliveries(n.db, delivery.UserID, message, results, attempt, now, nextDeliveryAttempt(attempt, now, UserLocation(n.db, delivery.UserID)))
+	if !results[0].Success {
+		log.Printf("[notifier] Attempt %d of %s notification to user %d (destination %d) failed: %s", attempt, destination.Channel, delivery.UserID, destination.ID, results[0].Error)
+		return
+	}
+	n.db.Model(&NotificationSetting{}).Where("user_id = ?", delivery.UserID).Update("last_notified_at", now)
+	log.Printf("[notifier] Sent %s notification to user %d (destination %d) on attempt %d", destination.Channel, delivery.UserID, destination.ID, attempt)
+}
+
 func (n *Notifier) checkAndNotify() {
 	var settings []NotificationSetting
-	if err := n.db.Where("enabled = ? AND bark_url <> ''", true).Find(&settings).Error; err != nil {
+	if err := n.db.Where("enabled = ?", true).Find(&settings).Error; err != nil {
 		log.Printf("[notifier] Failed to load notification settings: %v", err)
 		return
 	}
 
 	now := time.Now().UTC()
+	n.retryDeliveries(now)
 
 	for _, setting := range settings {
 		loc, normalizedTimezone, err := loadNotificationLocation(setting.Timezone)
@@ -103,32 +187,53 @@ func (n *Notifier) checkAndNotify() {
 			}
 		}
 
+		destinations, err := enabledDestinations(n.db, setting.UserID)
+		if err != nil {
+			log.Printf("[notifier] Failed to load destinations for user %d: %v", setting.UserID, err)
+			continue
+		}
+		if len(destinations) == 0 && n.events == nil {
+			continue
+		}
+
 		// Collect due items for this user
 		items := n.collectDueItems(setting.UserID, setting.DueDaysAhead)
 		if len(items) == 0 {
 			continue
 		}
 
-		// Build notification
-		title := fmt.Sprintf("Jiceot: %d due item(s)", len(items))
-		body := strings.Join(items, "\n")
+		published := 0
+		if n.events != nil {
+			published = n.events.Publish(setting.UserID, EventReminderDue, ReminderEvent{Items: items})
+		}
+
+		message := reminderMessage(items, userLanguage(n.db, setting.UserID))
 
-		if err := n.bark.Send(setting.BarkURL, title, body); err != nil {
-			log.Printf("[notifier] Failed to send bark to user %d: %v", setting.UserID, err)
+		results := n.channels.sendAll(destinations, message)
+		recordDeliveries(n.db, setting.UserID, message, results, 1, now, nextDeliveryAttempt(1, now, loc))
+		delivered := 0
+		for _, result := range results {
+			if result.Success {
+				delivered++
+				continue
+			}
+			log.Printf("[notifier] Failed to send %s notification to user %d (destination %d): %s", result.Channel, setting.UserID, result.DestinationID, result.Error)
+		}
+		if delivered == 0 && published == 0 {
 			continue
 		}
 
 		// Update last notified time
 		n.db.Model(&NotificationSetting{}).Where("id = ?", setting.ID).Update("last_notified_at", now)
-		log.Printf("[notifier] Sent notification to user %d: %d items", setting.UserID, len(items))
+		log.Printf("[notifier] Sent notification to user %d: %d items to %d of %d destination(s) and %d webhook(s)", setting.UserID, len(items), delivered, len(results), published)
 	}
 }
 
-func (n *Notifier) collectDueItems(userID uint, daysAhead int) []string {
+func (n *Notifier) collectDueItems(userID uint, daysAhead int) []DueItem {
 	now := expenses.NormalizeDateOnly(time.Now().UTC())
 	cutoff := now.AddDate(0, 0, daysAhead)
 
-	var items []string
+	var items []DueItem
 
 	// 1. Due wallets, using the same due-date logic as the dashboard but
 	// filtered by the notification lead-time window.
@@ -162,15 +267,21 @@ func (n *Notifier) collectDueItems(userID uint, daysAhead int) []string {
 				lastPayment = &payment
 			}
 			nextDue := expenses.NextWalletDueDate(w, now, lastPayment)
-			if nextDue.After(cutoff) {
+			if nextDue.After(cutoff) || w.EndsBefore(nextDue) {
 				continue
 			}
 			monthKey := nextDue.Format("2006-01")
 			if paidMonths[w.ID][monthKey] {
 				continue
 			}
-			days := dayDiff(now, nextDue)
-			items = append(items, fmt.Sprintf("💳 %s — %s", w.Name, dueLabel(days)))
+			items = append(items, DueItem{
+				Kind:    DueItemWallet,
+				ID:      w.ID,
+				Name:    w.Name,
+				DueDate: nextDue,
+				Days:    dayDiff(now, nextDue),
+				Final:   w.EndingSoonReminder && expenses.IsFinalWalletDueDate(w, nextDue),
+			})
 		}
 	}
 
@@ -214,14 +325,20 @@ func (n *Notifier) collectDueItems(userID uint, daysAhead int) []string {
 				continue
 			}
 			days := dayDiff(now, *nextDue)
-			if !shouldNotifyExpenseType(et, days) {
+			// The ending-soon reminder applies whatever the type's reminder setting.
+			final := et.EndingSoonReminder && expenses.IsFinalExpenseTypeDueDate(et, *nextDue)
+			if !final && !shouldNotifyExpenseType(et, days) {
 				continue
 			}
-			label := "📋"
-			if et.RecurringType == expenses.RecurringTypeFlexible {
-				label = "🔄"
-			}
-			items = append(items, fmt.Sprintf("%s %s — %s", label, et.Name, dueLabel(days)))
+			items = append(items, DueItem{
+				Kind:     DueItemExpenseType,
+				ID:       et.ID,
+				Name:     et.Name,
+				DueDate:  *nextDue,
+				Days:     days,
+				Flexible: et.RecurringType == expenses.RecurringTypeFlexible,
+				Final:    final,
+			})
 		}
 	}
 
@@ -256,16 +373,3 @@ func shouldNotifyExpenseType(expenseType expenses.ExpenseType, daysUntilDue int)
 		return true
 	}
 }
-
-func dueLabel(days int) string {
-	if days < 0 {
-		return fmt.Sprintf("%d day(s) overdue", -days)
-	}
-	if days == 0 {
-		return "due today"
-	}
-	if days == 1 {
-		return "due tomorrow"
-	}
-	return fmt.Sprintf("due in %d days", days)
-}
@@ -0,0 +1,123 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ChannelNtfy = "ntfy"
+
+var ErrInvalidNtfyTopicURL = errors.New("invalid ntfy topic URL")
+
+// ntfyPriorities maps push urgency onto ntfy's 1-5 scale (3 is default).
+var ntfyPriorities = map[pushUrgency]int{
+	pushUrgencyDefault: 3,
+	pushUrgencyHigh:    4,
+	pushUrgencyUrgent:  5,
+}
+
+// NtfyChannel publishes to an ntfy topic. The destination target is the
+// topic URL (e.g. "https://ntfy.sh/my-topic") and the optional auth token is
+// sent as a bearer token for protected topics.
+type NtfyChannel struct {
+	httpClient *http.Client
+	appURL     string
+}
+
+var _ Channel = (*NtfyChannel)(nil)
+
+func NewNtfyChannel(appURL string) *NtfyChannel {
+	return &NtfyChannel{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		appURL:     appURL,
+	}
+}
+
+func (c *NtfyChannel) Type() string {
+	return ChannelNtfy
+}
+
+func (c *NtfyChannel) Validate(destination NotificationDestination) error {
+	_, _, err := splitNtfyTopicURL(destination.Target)
+	return err
+}
+
+// ntfyPayload is the body of ntfy's JSON publish API.
+type ntfyPayload struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Icon     string   `json:"icon,omitempty"`
+}
+
+// Deliver publishes through the JSON API at the server root so that titles
+// and bodies need no header encoding.
+func (c *NtfyChannel) Deliver(destination NotificationDestination, message Message) error {
+	serverURL, topic, err := splitNtfyTopicURL(destination.Target)
+	if err != nil {
+		return err
+	}
+	content := newPushContent(message, c.appURL)
+	payload, err := json.Marshal(ntfyPayload{
+		Topic:    topic,
+		Title:    content.Title,
+		Message:  content.Body,
+		Priority: ntfyPriorities[content.Urgency],
+		Tags:     content.Tags,
+		Click:    content.ClickURL,
+		Icon:     pushIconURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode ntfy payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, serverURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("ntfy request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if destination.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+destination.AuthToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ntfy request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// splitNtfyTopicURL splits a topic URL into the server URL to publish to and
+// the topic name, its last path segment.
+func splitNtfyTopicURL(topicURL string) (string, string, error) {
+	parsed, err := validatePushURL(topicURL, ErrInvalidNtfyTopicURL)
+	if err != nil {
+		return "", "", err
+	}
+	path := strings.Trim(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	topic := path[slash+1:]
+	if topic == "" {
+		return "", "", fmt.Errorf("%w: missing topic", ErrInvalidNtfyTopicURL)
+	}
+	parsed.Path = "/" + path[:slash+1]
+	parsed.RawPath = ""
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String(), topic, nil
+}
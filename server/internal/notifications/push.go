@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Shared by the push channels so notifications from Jiceot group together
+// and carry the same icon on every service.
+const (
+	pushGroup   = "jiceot"
+	pushIconURL = "https://jiceot.dannyswat.me/jiceot.svg"
+)
+
+// pushUrgency ranks a message by its most pressing due item. Each push
+// channel maps it onto its own priority scale.
+type pushUrgency int
+
+const (
+	pushUrgencyDefault pushUrgency = iota
+	pushUrgencyHigh
+	pushUrgencyUrgent
+)
+
+// urgentOverdueDays is how many days overdue an item must be before a
+// reminder is sent at the highest priority.
+const urgentOverdueDays = 3
+
+// pushContent is the rendering of a message common to the push channels
+// (Bark, ntfy and Gotify).
+type pushContent struct {
+	Title    string
+	Body     string
+	Urgency  pushUrgency
+	Tags     []string
+	ClickURL string
+}
+
+// newPushContent renders message for the push channels. appURL is the base
+// URL of the web app used for click-through links; links are omitted when it
+// is empty.
+func newPushContent(message Message, appURL string) pushContent {
+	content := pushContent{Title: message.Title, Body: message.Body}
+	switch message.Kind {
+	case MessageKindReminder:
+		days := mostPressingDays(message.Items)
+		switch {
+		case days <= -urgentOverdueDays:
+			content.Urgency = pushUrgencyUrgent
+			content.Tags = []string{"rotating_light", "overdue"}
+		case days < 0:
+			content.Urgency = pushUrgencyHigh
+			content.Tags = []string{"warning", "overdue"}
+		case days == 0:
+			content.Urgency = pushUrgencyHigh
+			content.Tags = []string{"alarm_clock", "due-today"}
+		default:
+			content.Tags = []string{"calendar", "upcoming"}
+		}
+		content.ClickURL = appLink(appURL, reminderPath(message.Items))
+	case MessageKindTest:
+		content.Tags = []string{"white_check_mark"}
+		content.ClickURL = appLink(appURL, "/settings/notifications")
+	}
+	return content
+}
+
+// mostPressingDays returns the smallest days-until-due of items, the same
+// value dueLabel renders.
+func mostPressingDays(items []DueItem) int {
+	if len(items) == 0 {
+		return 0
+	}
+	days := items[0].Days
+	for _, item := range items[1:] {
+		if item.Days < days {
+			days = item.Days
+		}
+	}
+	return days
+}
+
+// reminderPath deep-links a reminder with a single item to the form that
+// settles it, and any other reminder to the due items list.
+func reminderPath(items []DueItem) string {
+	if len(items) != 1 {
+		return "/due-items"
+	}
+	item := items[0]
+	query := url.Values{}
+	id := strconv.FormatUint(uint64(item.ID), 10)
+	if item.Kind == DueItemWallet {
+		query.Set("wallet_id", id)
+		return "/payments/new?" + query.Encode()
+	}
+	query.Set("expense_type_id", id)
+	query.Set("date", item.DueDate.Format("2006-01-02"))
+	return "/expenses/new?" + query.Encode()
+}
+
+func appLink(appURL, path string) string {
+	if appURL == "" {
+		return ""
+	}
+	return strings.TrimRight(appURL, "/") + path
+}
+
+// validatePushURL checks that target is an absolute http or https URL,
+// wrapping failures in invalid.
+func validatePushURL(target string, invalid error) (*url.URL, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", invalid, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return nil, fmt.Errorf("%w: must use http or https scheme", invalid)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("%w: missing host", invalid)
+	}
+	return parsed, nil
+}
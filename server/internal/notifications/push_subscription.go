@@ -0,0 +1,28 @@
+package notifications
+
+import (
+	"time"
+
+	"dannyswat/jiceot/internal/users"
+)
+
+// PushSubscription is a browser's Web Push subscription. Each belongs to the
+// signed-in device that created it and is delivered to through its own
+// webpush destination, so it can be renamed, disabled and tested like any
+// other destination.
+type PushSubscription struct {
+	ID            uint       `json:"id" gorm:"primaryKey;type:bigint"`
+	UserID        uint       `json:"user_id" gorm:"type:bigint;not null;index"`
+	DeviceID      uint       `json:"device_id" gorm:"type:bigint;not null;uniqueIndex"`
+	DestinationID uint       `json:"destination_id" gorm:"type:bigint;not null;uniqueIndex"`
+	Endpoint      string     `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	P256dh        string     `json:"-" gorm:"type:varchar(100);not null"`
+	Auth          string     `json:"-" gorm:"type:varchar(50);not null"`
+	ExpiresAt     *time.Time `json:"expires_at" gorm:"type:timestamptz"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	User        *users.User              `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Device      *users.UserDevice        `json:"-" gorm:"foreignKey:DeviceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Destination *NotificationDestination `json:"-" gorm:"foreignKey:DestinationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -0,0 +1,330 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewPushContent(t *testing.T) {
+	due := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		message  Message
+		urgency  pushUrgency
+		tags     []string
+		clickURL string
+	}{
+		{
+			name:     "long overdue wallet",
+			message:  reminderMessage([]DueItem{{Kind: DueItemWallet, ID: 4, Name: "Visa", Days: -3}}, "en"),
+			urgency:  pushUrgencyUrgent,
+			tags:     []string{"rotating_light", "overdue"},
+			clickURL: "https://jiceot.example.com/payments/new?wallet_id=4",
+		},
+		{
+			name:     "recently overdue expense type",
+			message:  reminderMessage([]DueItem{{Kind: DueItemExpenseType, ID: 9, Name: "Rent", DueDate: due, Days: -1}}, "en"),
+			urgency:  pushUrgencyHigh,
+			tags:     []string{"warning", "overdue"},
+			clickURL: "https://jiceot.example.com/expenses/new?date=2026-03-14&expense_type_id=9",
+		},
+		{
+			name: "most pressing item wins",
+			message: reminderMessage([]DueItem{
+				{Kind: DueItemExpenseType, ID: 1, Name: "Gym", Days: 3},
+				{Kind: DueItemWallet, ID: 2, Name: "Visa", Days: 0},
+			}, "en"),
+			urgency:  pushUrgencyHigh,
+			tags:     []string{"alarm_clock", "due-today"},
+			clickURL: "https://jiceot.example.com/due-items",
+		},
+		{
+			name:     "upcoming",
+			message:  reminderMessage([]DueItem{{Kind: DueItemWallet, ID: 2, Name: "Visa", Days: 2}, {Kind: DueItemWallet, ID: 3, Name: "Amex", Days: 5}}, "en"),
+			urgency:  pushUrgencyDefault,
+			tags:     []string{"calendar", "upcoming"},
+			clickURL: "https://jiceot.example.com/due-items",
+		},
+		{
+			name:     "test message",
+			message:  testMessage("en"),
+			urgency:  pushUrgencyDefault,
+			tags:     []string{"white_check_mark"},
+			clickURL: "https://jiceot.example.com/settings/notifications",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := newPushContent(tt.message, "https://jiceot.example.com/")
+			if content.Title != tt.message.Title || content.Body != tt.message.Body {
+				t.Errorf("expected the message text to be kept, got %q / %q", content.Title, content.Body)
+			}
+			if content.Urgency != tt.urgency {
+				t.Errorf("urgency = %d, want %d", content.Urgency, tt.urgency)
+			}
+			if !reflect.DeepEqual(content.Tags, tt.tags) {
+				t.Errorf("tags = %v, want %v", content.Tags, tt.tags)
+			}
+			if content.ClickURL != tt.clickURL {
+				t.Errorf("click URL = %q, want %q", content.ClickURL, tt.clickURL)
+			}
+		})
+	}
+
+	if content := newPushContent(testMessage("en"), ""); content.ClickURL != "" {
+		t.Errorf("expected no click URL without an app URL, got %q", content.ClickURL)
+	}
+}
+
+func TestSplitNtfyTopicURL(t *testing.T) {
+	tests := []struct {
+		target string
+		server string
+		topic  string
+		err    error
+	}{
+		{target: "https://ntfy.sh/jiceot-alerts", server: "https://ntfy.sh/", topic: "jiceot-alerts"},
+		{target: "https://home.example.com/ntfy/bills/", server: "https://home.example.com/ntfy/", topic: "bills"},
+		{target: "https://ntfy.sh/", err: ErrInvalidNtfyTopicURL},
+		{target: "ntfy.sh/bills", err: ErrInvalidNtfyTopicURL},
+	}
+	for _, tt := range tests {
+		server, topic, err := splitNtfyTopicURL(tt.target)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("splitNtfyTopicURL(%q) error = %v, want %v", tt.target, err, tt.err)
+			continue
+		}
+		if server != tt.server || topic != tt.topic {
+			t.Errorf("splitNtfyTopicURL(%q) = %q, %q, want %q, %q", tt.target, server, topic, tt.server, tt.topic)
+		}
+	}
+}
+
+func TestNtfyChannelDeliver(t *testing.T) {
+	var got ntfyPayload
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.Method != http.MethodPost || r.URL.Path != "/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	channel := NewNtfyChannel("https://jiceot.example.com")
+	destination := NotificationDestination{Channel: ChannelNtfy, Target: server.URL + "/bills", AuthToken: "tk_secret"}
+	message := reminderMessage([]DueItem{{Kind: DueItemWallet, ID: 7, Name: "Visa", Days: -5}}, "en")
+
+	if err := channel.Deliver(destination, message); err != nil {
+		t.Fatalf("Deliver returned %v", err)
+	}
+	if auth != "Bearer tk_secret" {
+		t.Errorf("unexpected authorization header %q", auth)
+	}
+	if got.Topic != "bills" || got.Title != message.Title || got.Message != message.Body {
+		t.Errorf("unexpected payload %+v", got)
+	}
+	if got.Priority != 5 || got.Click != "https://jiceot.example.com/payments/new?wallet_id=7" {
+		t.Errorf("unexpected priority or click URL %+v", got)
+	}
+}
+
+func TestGotifyChannelDeliver(t *testing.T) {
+	var got gotifyPayload
+	var key string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("X-Gotify-Key")
+		if r.Method != http.MethodPost || r.URL.Path != "/message" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := NewGotifyChannel("https://jiceot.example.com")
+	destination := NotificationDestination{Channel: ChannelGotify, Target: server.URL + "/", AuthToken: "app-token"}
+	message := reminderMessage([]DueItem{{Kind: DueItemWallet, ID: 7, Name: "Visa", Days: 0}}, "en")
+
+	if err := channel.Deliver(destination, message); err != nil {
+		t.Fatalf("Deliver returned %v", err)
+	}
+	if key != "app-token" {
+		t.Errorf("unexpected application token %q", key)
+	}
+	if got.Priority != 7 || got.Message != message.Body {
+		t.Errorf("unexpected payload %+v", got)
+	}
+	click, _ := got.Extras["client::notification"].(map[string]interface{})["click"].(map[string]interface{})
+	if click["url"] != "https://jiceot.example.com/payments/new?wallet_id=7" {
+		t.Errorf("unexpected click extras %v", got.Extras)
+	}
+
+	status = http.StatusUnauthorized
+	if err := channel.Deliver(destination, message); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+	destination.AuthToken = ""
+	if err := channel.Validate(destination); !errors.Is(err, ErrGotifyTokenRequired) {
+		t.Errorf("expected ErrGotifyTokenRequired, got %v", err)
+	}
+}
+
+func TestSplitBarkURL(t *testing.T) {
+	tests := []struct {
+		target    string
+		server    string
+		deviceKey string
+		err       error
+	}{
+		{target: "https://api.day.app/KEY", server: "https://api.day.app", deviceKey: "KEY"},
+		{target: "https://bark.example.com/bark/KEY/", server: "https://bark.example.com/bark", deviceKey: "KEY"},
+		{target: "https://api.day.app/", err: ErrInvalidBarkURL},
+		{target: "", err: ErrBarkURLEmpty},
+	}
+	for _, tt := range tests {
+		server, deviceKey, err := splitBarkURL(tt.target)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("splitBarkURL(%q) error = %v, want %v", tt.target, err, tt.err)
+			continue
+		}
+		if server != tt.server || deviceKey != tt.deviceKey {
+			t.Errorf("splitBarkURL(%q) = %q, %q, want %q, %q", tt.target, server, deviceKey, tt.server, tt.deviceKey)
+		}
+	}
+}
+
+func newBarkServer(t *testing.T, got *barkPayload) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/push" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		if got.DeviceKey == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"message":"failed to get device token"}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"message":"success"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBarkClientPush(t *testing.T) {
+	var got barkPayload
+	server := newBarkServer(t, &got)
+	client := NewBarkClient(nil, "")
+	content := newPushContent(reminderMessage([]DueItem{{Kind: DueItemWallet, ID: 7, Name: "Visa", Days: -1}}, "en"), "https://jiceot.example.com")
+	long := string(bytes.Repeat([]byte("x"), 5000))
+	content.Body += long
+
+	if err := client.push(server.URL+"/KEY", content, barkOptions{Sound: "minuet", Level: BarkLevelTimeSensitive}); err != nil {
+		t.Fatalf("push returned %v", err)
+	}
+	want := barkPayload{
+		DeviceKey: "KEY",
+		Title:     content.Title,
+		Body:      content.Body,
+		URL:       "https://jiceot.example.com/payments/new?wallet_id=7",
+		Icon:      pushIconURL,
+		Group:     pushGroup,
+		Sound:     "minuet",
+		Level:     BarkLevelTimeSensitive,
+	}
+	if got != want {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+
+	err := client.push(server.URL+"/unknown", content, barkOptions{})
+	if err == nil || err.Error() != "bark returned code 400: failed to get device token" {
+		t.Errorf("expected the Bark error message, got %v", err)
+	}
+}
+
+func TestBarkClientPushEncrypted(t *testing.T) {
+	var got barkPayload
+	server := newBarkServer(t, &got)
+	client := NewBarkClient(nil, "")
+	content := pushContent{Title: "Jiceot", Body: "Rent is due today"}
+	key := "0123456789abcdef"
+
+	for _, iv := range []string{"fedcba9876543210", ""} {
+		options := barkOptions{EncryptionKey: key, EncryptionIV: iv, Sound: "bell", Group: "bills"}
+		if err := client.push(server.URL+"/KEY", content, options); err != nil {
+			t.Fatalf("push returned %v", err)
+		}
+		if got.Title != "" || got.Body != "" || got.Sound != "bell" || got.Group != "bills" || got.DeviceKey != "KEY" {
+			t.Errorf("expected only delivery fields in the clear, got %+v", got)
+		}
+		if (iv == "") != (got.IV != "") {
+			t.Errorf("expected an IV to be sent only when none is configured, got %q", got.IV)
+		}
+		sentIV := iv
+		if sentIV == "" {
+			sentIV = got.IV
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(got.Ciphertext)
+		if err != nil || len(ciphertext)%aes.BlockSize != 0 {
+			t.Fatalf("invalid ciphertext %q", got.Ciphertext)
+		}
+		block, _ := aes.NewCipher([]byte(key))
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, []byte(sentIV)).CryptBlocks(plaintext, ciphertext)
+		plaintext = plaintext[:len(plaintext)-int(plaintext[len(plaintext)-1])]
+		var inner barkPayload
+		if err := json.Unmarshal(plaintext, &inner); err != nil {
+			t.Fatalf("failed to decode decrypted payload %q: %v", plaintext, err)
+		}
+		if inner.Title != "Jiceot" || inner.Body != "Rent is due today" || inner.Icon != pushIconURL {
+			t.Errorf("unexpected decrypted payload %+v", inner)
+		}
+		got = barkPayload{}
+	}
+}
+
+func TestApplyBarkOptions(t *testing.T) {
+	str := func(value string) *string { return &value }
+	tests := []struct {
+		name string
+		req  UpdateNotificationSettingRequest
+		want error
+	}{
+		{name: "valid", req: UpdateNotificationSettingRequest{BarkEncryptionKey: str("0123456789abcdef0123456789abcdef"), BarkEncryptionIV: str("fedcba9876543210"), BarkSound: str("minuet.caf"), BarkLevel: str(BarkLevelCritical), BarkGroup: str("Bills")}},
+		{name: "clear", req: UpdateNotificationSettingRequest{BarkEncryptionKey: str(""), BarkLevel: str("")}},
+		{name: "short key", req: UpdateNotificationSettingRequest{BarkEncryptionKey: str("short")}, want: ErrInvalidBarkEncryptionKey},
+		{name: "short IV", req: UpdateNotificationSettingRequest{BarkEncryptionIV: str("short")}, want: ErrInvalidBarkEncryptionIV},
+		{name: "sound path", req: UpdateNotificationSettingRequest{BarkSound: str("../alarm")}, want: ErrInvalidBarkSound},
+		{name: "level", req: UpdateNotificationSettingRequest{BarkLevel: str("loud")}, want: ErrInvalidBarkLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting := NotificationSetting{BarkEncryptionKey: "0123456789abcdef"}
+			if err := applyBarkOptions(&setting, tt.req); !errors.Is(err, tt.want) {
+				t.Fatalf("applyBarkOptions returned %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && setting.HasBarkEncryptionKey != (setting.BarkEncryptionKey != "") {
+				t.Errorf("has_bark_encryption_key out of sync: %+v", setting)
+			}
+		})
+	}
+}
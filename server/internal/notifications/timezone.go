@@ -3,6 +3,8 @@ package notifications
 import (
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 var notificationTimezoneAliases = map[string]string{
@@ -31,3 +33,17 @@ func loadNotificationLocation(value string) (*time.Location, string, error) {
 
 	return loc, normalized, nil
 }
+
+// UserLocation returns the timezone of the user's notification settings, or
+// UTC when none is set or it cannot be loaded.
+func UserLocation(db *gorm.DB, userID uint) *time.Location {
+	var setting NotificationSetting
+	if err := db.Select("timezone").Where("user_id = ?", userID).First(&setting).Error; err != nil {
+		return time.UTC
+	}
+	loc, _, err := loadNotificationLocation(setting.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
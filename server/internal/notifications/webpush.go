@@ -0,0 +1,169 @@
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// webPushRecordSize is the aes128gcm record size. Payloads are sent as a
+	// single record.
+	webPushRecordSize = 4096
+	// maxWebPushPayload is the largest plaintext that keeps the encrypted
+	// body within the 4096 bytes every push service accepts.
+	maxWebPushPayload = 4096 - 86 - 16 - 1
+	// vapidTokenTTL is how long a VAPID token is valid; push services reject
+	// tokens that expire more than 24 hours ahead.
+	vapidTokenTTL = 12 * time.Hour
+)
+
+var (
+	ErrInvalidVAPIDKey        = errors.New("invalid VAPID key")
+	ErrWebPushPayloadTooLarge = errors.New("web push payload is too large")
+)
+
+// VAPIDKeys identify this server to push services (RFC 8292). Browsers bind
+// each subscription to the public key.
+type VAPIDKeys struct {
+	private   *ecdsa.PrivateKey
+	publicKey []byte
+	subject   string
+}
+
+// ParseVAPIDKeys reads a PEM-encoded P-256 private key. subject is a mailto:
+// or https: contact for the push service operators.
+func ParseVAPIDKeys(pemKey, subject string) (*VAPIDKeys, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block", ErrInvalidVAPIDKey)
+	}
+	private, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVAPIDKey, err)
+	}
+	public, err := private.PublicKey.ECDH()
+	if err != nil || public.Curve() != ecdh.P256() {
+		return nil, fmt.Errorf("%w: must be a P-256 key", ErrInvalidVAPIDKey)
+	}
+	return &VAPIDKeys{private: private, publicKey: public.Bytes(), subject: subject}, nil
+}
+
+// PublicKey returns the uncompressed public key, base64url-encoded, as the
+// browser's pushManager.subscribe expects for applicationServerKey.
+func (k *VAPIDKeys) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.publicKey)
+}
+
+// authorization returns the Authorization header for a push to endpoint.
+func (k *VAPIDKeys) authorization(endpoint string, now time.Time) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": now.Add(vapidTokenTTL).Unix(),
+		"sub": k.subject,
+	})
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return "vapid t=" + signed + ", k=" + k.PublicKey(), nil
+}
+
+// encryptWebPush encrypts plaintext for a subscription as an aes128gcm body
+// (RFC 8291). uaPublic and authSecret are the subscription's p256dh and auth
+// keys.
+func encryptWebPush(plaintext, uaPublic, authSecret []byte) ([]byte, error) {
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate web push key: %w", err)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate web push salt: %w", err)
+	}
+	return sealWebPush(plaintext, uaPublic, authSecret, asPrivate, salt)
+}
+
+// sealWebPush is encryptWebPush with the ephemeral key and salt supplied.
+func sealWebPush(plaintext, uaPublic, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(plaintext) > maxWebPushPayload {
+		return nil, ErrWebPushPayloadTooLarge
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+	sharedSecret, err := asPrivate.ECDH(uaKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive web push secret: %w", err)
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	cek, nonce, err := webPushKeys(sharedSecret, authSecret, salt, uaPublic, asPublic)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, key ID length and the sender's public key.
+	body := make([]byte, 0, 16+4+1+len(asPublic)+len(plaintext)+1+gcm.Overhead())
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, webPushRecordSize)
+	body = append(body, byte(len(asPublic)))
+	body = append(body, asPublic...)
+	// 0x02 marks the last (and only) record.
+	record := append(append([]byte{}, plaintext...), 0x02)
+	return gcm.Seal(body, nonce, record, nil), nil
+}
+
+// webPushKeys derives the content encryption key and nonce from the ECDH
+// secret, mixing in the subscription's auth secret and both public keys.
+func webPushKeys(sharedSecret, authSecret, salt, uaPublic, asPublic []byte) ([]byte, []byte, error) {
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	prkKey, err := hkdf.Extract(sha256.New, sharedSecret, authSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	ikm, err := hkdf.Expand(sha256.New, prkKey, string(keyInfo), 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
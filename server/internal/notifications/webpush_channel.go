@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const ChannelWebPush = "webpush"
+
+// webPushTTL is how long a push service keeps a message for an offline
+// browser. Reminders are daily, so older ones are not worth showing.
+const webPushTTL = 24 * time.Hour
+
+// maxWebPushBody caps the body so long reminders fit the payload limit.
+const maxWebPushBody = 2000
+
+var (
+	ErrWebPushSubscriptionRequired = errors.New("web push destinations are added by enabling notifications in the browser")
+	ErrWebPushSubscriptionExpired  = errors.New("web push subscription has expired")
+)
+
+// webPushUrgencies maps push urgency onto the Urgency header (RFC 8030).
+var webPushUrgencies = map[pushUrgency]string{
+	pushUrgencyDefault: "normal",
+	pushUrgencyHigh:    "high",
+	pushUrgencyUrgent:  "high",
+}
+
+// WebPushChannel sends encrypted Web Push messages to a browser
+// subscription. The destination's subscription holds the endpoint and keys;
+// its target only shows which push service the browser uses.
+type WebPushChannel struct {
+	db         *gorm.DB
+	vapid      *VAPIDKeys
+	httpClient *http.Client
+	appURL     string
+}
+
+var _ Channel = (*WebPushChannel)(nil)
+
+func NewWebPushChannel(db *gorm.DB, vapid *VAPIDKeys, appURL string) *WebPushChannel {
+	return &WebPushChannel{
+		db:         db,
+		vapid:      vapid,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		appURL:     appURL,
+	}
+}
+
+func (c *WebPushChannel) Type() string {
+	return ChannelWebPush
+}
+
+// Validate only accepts destinations created by subscribing a browser.
+func (c *WebPushChannel) Validate(destination NotificationDestination) error {
+	if destination.ID == 0 {
+		return ErrWebPushSubscriptionRequired
+	}
+	var count int64
+	if err := c.db.Model(&PushSubscription{}).Where("destination_id = ?", destination.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check web push subscription: %w", err)
+	}
+	if count == 0 {
+		return ErrWebPushSubscriptionRequired
+	}
+	return nil
+}
+
+// webPushPayload is the JSON the service worker receives in its push event.
+type webPushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Tag   string `json:"tag"`
+	Icon  string `json:"icon"`
+	URL   string `json:"url"`
+}
+
+// Deliver pushes message to the destination's subscription. A subscription
+// the push service reports as gone is removed with its destination.
+func (c *WebPushChannel) Deliver(destination NotificationDestination, message Message) error {
+	var subscription PushSubscription
+	if err := c.db.Where("destination_id = ?", destination.ID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWebPushSubscriptionRequired
+		}
+		return fmt.Errorf("failed to load web push subscription: %w", err)
+	}
+	err := c.push(subscription, message, time.Now())
+	if errors.Is(err, ErrWebPushSubscriptionExpired) {
+		if removeErr := removePushSubscriptions(c.db, []PushSubscription{subscription}); removeErr != nil {
+			return fmt.Errorf("%w (%v)", err, removeErr)
+		}
+	}
+	return err
+}
+
+func (c *WebPushChannel) push(subscription PushSubscription, message Message, now time.Time) error {
+	content := newPushContent(message, c.appURL)
+	clickURL := content.ClickURL
+	if clickURL == "" {
+		// The service worker resolves paths against the app's origin.
+		clickURL = "/"
+	}
+	payload, err := json.Marshal(webPushPayload{
+		Title: content.Title,
+		Body:  truncateUTF8(content.Body, maxWebPushBody),
+		Tag:   pushGroup,
+		Icon:  pushIconURL,
+		URL:   clickURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode web push payload: %w", err)
+	}
+	uaPublic, err := base64.RawURLEncoding.DecodeString(subscription.P256dh)
+	if err != nil {
+		return fmt.Errorf("invalid web push subscription key: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(subscription.Auth)
+	if err != nil {
+		return fmt.Errorf("invalid web push auth secret: %w", err)
+	}
+	body, err := encryptWebPush(payload, uaPublic, authSecret)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.vapid.authorization(subscription.Endpoint, now)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create web push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+	req.Header.Set("Urgency", webPushUrgencies[content.Urgency])
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("web push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrWebPushSubscriptionExpired
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// truncateUTF8 shortens s to at most limit bytes without splitting a rune.
+func truncateUTF8(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,89 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+
+	"dannyswat/jiceot/internal/auth"
+	"dannyswat/jiceot/internal/users"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebPushHandler struct {
+	service       *WebPushService
+	deviceService *users.UserDeviceService
+}
+
+func NewWebPushHandler(service *WebPushService, deviceService *users.UserDeviceService) *WebPushHandler {
+	return &WebPushHandler{service: service, deviceService: deviceService}
+}
+
+// GetStatus handles GET /api/web-push
+func (h *WebPushHandler) GetStatus(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var deviceID uint
+	if device, ok := h.currentDevice(c, userID); ok {
+		deviceID = device.ID
+	}
+	status, err := h.service.GetStatus(userID, deviceID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load web push status"})
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// Subscribe handles PUT /api/web-push/subscription
+func (h *WebPushHandler) Subscribe(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	device, ok := h.currentDevice(c, userID)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Sign in again on this device to enable push notifications"})
+	}
+	var req PushSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	subscription, err := h.service.Subscribe(userID, device.ID, req)
+	if err != nil {
+		return h.webPushError(c, err, "Failed to save push subscription")
+	}
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// Unsubscribe handles DELETE /api/web-push/subscription
+func (h *WebPushHandler) Unsubscribe(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	device, ok := h.currentDevice(c, userID)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": ErrPushSubscriptionNotFound.Error()})
+	}
+	if err := h.service.Unsubscribe(userID, device.ID); err != nil {
+		return h.webPushError(c, err, "Failed to remove push subscription")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// currentDevice finds the signed-in device from its refresh token cookie.
+func (h *WebPushHandler) currentDevice(c echo.Context, userID uint) (*users.UserDevice, bool) {
+	cookie, err := c.Cookie("refresh_token")
+	if err != nil || cookie.Value == "" {
+		return nil, false
+	}
+	device, err := h.deviceService.GetDeviceByRefreshToken(cookie.Value)
+	if err != nil || device.UserID != userID {
+		return nil, false
+	}
+	return device, true
+}
+
+func (h *WebPushHandler) webPushError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrPushSubscriptionNotFound), errors.Is(err, users.ErrDeviceNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvalidPushSubscription):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,228 @@
+package notifications
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPushSubscription  = errors.New("invalid push subscription")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+)
+
+// PushSubscriptionRequest is the browser's PushSubscription.toJSON().
+type PushSubscriptionRequest struct {
+	Endpoint       string   `json:"endpoint"`
+	ExpirationTime *float64 `json:"expirationTime"`
+	Keys           struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// WebPushStatus tells the browser which key to subscribe with and whether
+// the current device is already subscribed.
+type WebPushStatus struct {
+	PublicKey    string            `json:"public_key"`
+	Subscribed   bool              `json:"subscribed"`
+	Subscription *PushSubscription `json:"subscription,omitempty"`
+}
+
+type WebPushService struct {
+	db    *gorm.DB
+	vapid *VAPIDKeys
+}
+
+func NewWebPushService(db *gorm.DB, vapid *VAPIDKeys) *WebPushService {
+	return &WebPushService{db: db, vapid: vapid}
+}
+
+func (s *WebPushService) GetStatus(userID, deviceID uint) (*WebPushStatus, error) {
+	status := &WebPushStatus{PublicKey: s.vapid.PublicKey()}
+	var subscription PushSubscription
+	err := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).First(&subscription).Error
+	if err == nil {
+		status.Subscribed = true
+		status.Subscription = &subscription
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get push subscription: %w", err)
+	}
+	return status, nil
+}
+
+// Subscribe stores the device's subscription. Resubscribing updates the
+// keys in place and keeps the destination's settings; an endpoint or device
+// previously registered to another account is taken over.
+func (s *WebPushService) Subscribe(userID, deviceID uint, req PushSubscriptionRequest) (*PushSubscription, error) {
+	endpoint, p256dh, auth, err := parsePushSubscription(req)
+	if err != nil {
+		return nil, err
+	}
+	var expiresAt *time.Time
+	if req.ExpirationTime != nil {
+		expiry := time.UnixMilli(int64(*req.ExpirationTime)).UTC()
+		expiresAt = &expiry
+	}
+
+	var device users.UserDevice
+	if err := s.db.Where("id = ? AND user_id = ?", deviceID, userID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, users.ErrDeviceNotFound
+		}
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+
+	var subscription *PushSubscription
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var existing []PushSubscription
+		if err := tx.Where("endpoint = ? OR device_id = ?", endpoint.String(), deviceID).Order("id ASC").Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to find push subscriptions: %w", err)
+		}
+		var stale []PushSubscription
+		for i := range existing {
+			if subscription == nil && existing[i].UserID == userID {
+				subscription = &existing[i]
+			} else {
+				stale = append(stale, existing[i])
+			}
+		}
+		if err := removePushSubscriptions(tx, stale); err != nil {
+			return err
+		}
+
+		if subscription == nil {
+			subscription = &PushSubscription{UserID: userID}
+		} else if err := tx.Where("id = ?", subscription.DestinationID).First(&NotificationDestination{}).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			// The destination was deleted; subscribing again restores it.
+			subscription.DestinationID = 0
+		} else if err != nil {
+			return fmt.Errorf("failed to get web push destination: %w", err)
+		}
+
+		if subscription.DestinationID == 0 {
+			destination := NotificationDestination{
+				UserID:  userID,
+				Channel: ChannelWebPush,
+				Name:    device.DeviceName,
+				Target:  endpoint.Host,
+				Enabled: true,
+			}
+			if err := tx.Create(&destination).Error; err != nil {
+				return fmt.Errorf("failed to create web push destination: %w", err)
+			}
+			subscription.DestinationID = destination.ID
+		} else if err := tx.Model(&NotificationDestination{}).Where("id = ?", subscription.DestinationID).Update("target", endpoint.Host).Error; err != nil {
+			return fmt.Errorf("failed to update web push destination: %w", err)
+		}
+
+		subscription.DeviceID = deviceID
+		subscription.Endpoint = endpoint.String()
+		subscription.P256dh = p256dh
+		subscription.Auth = auth
+		subscription.ExpiresAt = expiresAt
+		if err := tx.Omit("User", "Device", "Destination").Save(subscription).Error; err != nil {
+			return fmt.Errorf("failed to save push subscription: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Unsubscribe removes the device's subscription and its destination.
+func (s *WebPushService) Unsubscribe(userID, deviceID uint) error {
+	var subscriptions []PushSubscription
+	if err := s.db.Where("user_id = ? AND device_id = ?", userID, deviceID).Find(&subscriptions).Error; err != nil {
+		return fmt.Errorf("failed to get push subscription: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return ErrPushSubscriptionNotFound
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return removePushSubscriptions(tx, subscriptions)
+	})
+}
+
+// CleanupPushSubscriptions removes subscriptions that can no longer be
+// delivered to: past their expiration time, on a device that signed out or
+// whose session expired, or whose destination was deleted. It returns how
+// many were removed.
+func CleanupPushSubscriptions(db *gorm.DB, now time.Time) (int, error) {
+	var stale []PushSubscription
+	err := db.Where("expires_at < ?", now).
+		Or("NOT EXISTS (SELECT 1 FROM user_devices WHERE user_devices.id = push_subscriptions.device_id AND user_devices.deleted_at IS NULL AND user_devices.expires_at > ?)", now).
+		Or("NOT EXISTS (SELECT 1 FROM notification_destinations WHERE notification_destinations.id = push_subscriptions.destination_id AND notification_destinations.deleted_at IS NULL AND notification_destinations.channel = ?)", ChannelWebPush).
+		Find(&stale).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find stale push subscriptions: %w", err)
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return removePushSubscriptions(tx, stale)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+// removePushSubscriptions deletes subscriptions along with their webpush
+// destinations.
+func removePushSubscriptions(db *gorm.DB, subscriptions []PushSubscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(subscriptions))
+	destinationIDs := make([]uint, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+		destinationIDs = append(destinationIDs, subscription.DestinationID)
+	}
+	if err := db.Where("id IN ?", ids).Delete(&PushSubscription{}).Error; err != nil {
+		return fmt.Errorf("failed to delete push subscriptions: %w", err)
+	}
+	if err := db.Where("id IN ? AND channel = ?", destinationIDs, ChannelWebPush).Delete(&NotificationDestination{}).Error; err != nil {
+		return fmt.Errorf("failed to delete web push destinations: %w", err)
+	}
+	return nil
+}
+
+// parsePushSubscription validates the endpoint and keys, returning the keys
+// in unpadded base64url form.
+func parsePushSubscription(req PushSubscriptionRequest) (*url.URL, string, string, error) {
+	endpoint, err := validatePushURL(strings.TrimSpace(req.Endpoint), ErrInvalidPushSubscription)
+	if err != nil {
+		return nil, "", "", err
+	}
+	p256dh, err := decodePushKey(req.Keys.P256dh)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("%w: invalid p256dh key", ErrInvalidPushSubscription)
+	}
+	if _, err := ecdh.P256().NewPublicKey(p256dh); err != nil {
+		return nil, "", "", fmt.Errorf("%w: p256dh is not a P-256 public key", ErrInvalidPushSubscription)
+	}
+	auth, err := decodePushKey(req.Keys.Auth)
+	if err != nil || len(auth) != 16 {
+		return nil, "", "", fmt.Errorf("%w: auth secret must be 16 bytes", ErrInvalidPushSubscription)
+	}
+	return endpoint, base64.RawURLEncoding.EncodeToString(p256dh), base64.RawURLEncoding.EncodeToString(auth), nil
+}
+
+// decodePushKey accepts base64url with or without padding, as browsers
+// differ.
+func decodePushKey(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(value), "="))
+}
@@ -0,0 +1,236 @@
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func decodeB64(t *testing.T, value string) []byte {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("invalid base64 %q: %v", value, err)
+	}
+	return data
+}
+
+// openWebPush decrypts an aes128gcm body as the browser would.
+func openWebPush(t *testing.T, body []byte, uaPrivate *ecdh.PrivateKey, authSecret []byte) []byte {
+	t.Helper()
+	if len(body) < 21 {
+		t.Fatalf("body too short: %d bytes", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != webPushRecordSize {
+		t.Errorf("record size = %d, want %d", rs, webPushRecordSize)
+	}
+	idLength := int(body[20])
+	asPublic := body[21 : 21+idLength]
+	asKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		t.Fatalf("invalid sender key: %v", err)
+	}
+	sharedSecret, err := uaPrivate.ECDH(asKey)
+	if err != nil {
+		t.Fatalf("ECDH failed: %v", err)
+	}
+	cek, nonce, err := webPushKeys(sharedSecret, authSecret, salt, uaPrivate.PublicKey().Bytes(), asPublic)
+	if err != nil {
+		t.Fatalf("key derivation failed: %v", err)
+	}
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, nonce, body[21+idLength:], nil)
+	if err != nil {
+		t.Fatalf("decryption failed: %v", err)
+	}
+	if len(record) == 0 || record[len(record)-1] != 0x02 {
+		t.Fatalf("expected a final record delimiter, got %x", record)
+	}
+	return record[:len(record)-1]
+}
+
+// TestSealWebPushRFC8291 checks the example in RFC 8291, Appendix A.
+func TestSealWebPushRFC8291(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(decodeB64(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic := decodeB64(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	authSecret := decodeB64(t, "BTBZMqHH6r4Tts7J_aSIgg")
+	salt := decodeB64(t, "DGv6ra1nlYgDCS1FRnbzlw")
+
+	body, err := sealWebPush([]byte("When I grow up, I want to be a watermelon"), uaPublic, authSecret, asPrivate, salt)
+	if err != nil {
+		t.Fatalf("sealWebPush returned %v", err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := base64.RawURLEncoding.EncodeToString(body); got != want {
+		t.Errorf("body = %s\nwant   %s", got, want)
+	}
+}
+
+func TestSealWebPushRejectsLargePayload(t *testing.T) {
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	_, err := encryptWebPush(make([]byte, maxWebPushPayload+1), uaPrivate.PublicKey().Bytes(), make([]byte, 16))
+	if !errors.Is(err, ErrWebPushPayloadTooLarge) {
+		t.Errorf("expected ErrWebPushPayloadTooLarge, got %v", err)
+	}
+}
+
+func testVAPIDKeys(t *testing.T) (*VAPIDKeys, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalECPrivateKey(key)
+	vapid, err := ParseVAPIDKeys(string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), "mailto:ops@example.com")
+	if err != nil {
+		t.Fatalf("ParseVAPIDKeys returned %v", err)
+	}
+	return vapid, key
+}
+
+// TestWebPushChannelPush delivers to a local push service stand-in that
+// checks the VAPID token and decrypts the payload.
+func TestWebPushChannelPush(t *testing.T) {
+	vapid, vapidKey := testVAPIDKeys(t)
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	var headers http.Header
+	var payload webPushPayload
+	var claims jwt.MapClaims
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 4096 {
+			t.Errorf("body is %d bytes", len(body))
+		}
+		json.Unmarshal(openWebPush(t, body, uaPrivate, authSecret), &payload)
+
+		token, key, _ := strings.Cut(strings.TrimPrefix(r.Header.Get("Authorization"), "vapid t="), ", k=")
+		if key != vapid.PublicKey() {
+			t.Errorf("unexpected VAPID public key %q", key)
+		}
+		_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+			return &vapidKey.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"ES256"}))
+		if err != nil {
+			t.Errorf("invalid VAPID token: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	channel := NewWebPushChannel(nil, vapid, "https://app.example.com")
+	subscription := PushSubscription{
+		Endpoint: server.URL + "/push/abc",
+		P256dh:   base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(authSecret),
+	}
+	message := reminderMessage([]DueItem{{Kind: DueItemWallet, ID: 3, Name: "Visa", Days: -1}}, "en")
+
+	if err := channel.push(subscription, message, time.Now()); err != nil {
+		t.Fatalf("push returned %v", err)
+	}
+	if headers.Get("Content-Encoding") != "aes128gcm" || headers.Get("TTL") != "86400" || headers.Get("Urgency") != "high" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if payload.Title != message.Title || payload.Body != message.Body || payload.URL != "https://app.example.com/payments/new?wallet_id=3" || payload.Tag != pushGroup {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if claims["aud"] != server.URL || claims["sub"] != "mailto:ops@example.com" {
+		t.Errorf("unexpected VAPID claims %v", claims)
+	}
+}
+
+func TestWebPushChannelPushExpired(t *testing.T) {
+	vapid, _ := testVAPIDKeys(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	subscription := PushSubscription{
+		Endpoint: server.URL,
+		P256dh:   base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+	}
+	err := NewWebPushChannel(nil, vapid, "").push(subscription, testMessage("en"), time.Now())
+	if !errors.Is(err, ErrWebPushSubscriptionExpired) {
+		t.Errorf("expected ErrWebPushSubscriptionExpired, got %v", err)
+	}
+}
+
+func TestParsePushSubscription(t *testing.T) {
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	p256dh := base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes())
+	auth := base64.URLEncoding.EncodeToString(make([]byte, 16))
+
+	tests := []struct {
+		name     string
+		endpoint string
+		p256dh   string
+		auth     string
+		valid    bool
+	}{
+		{name: "valid with padded auth", endpoint: "https://fcm.googleapis.com/fcm/send/abc", p256dh: p256dh, auth: auth, valid: true},
+		{name: "relative endpoint", endpoint: "/push", p256dh: p256dh, auth: auth},
+		{name: "short key", endpoint: "https://push.example.com/x", p256dh: "BCVx", auth: auth},
+		{name: "short auth", endpoint: "https://push.example.com/x", p256dh: p256dh, auth: "AAAA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := PushSubscriptionRequest{Endpoint: tt.endpoint}
+			req.Keys.P256dh = tt.p256dh
+			req.Keys.Auth = tt.auth
+			_, gotP256dh, gotAuth, err := parsePushSubscription(req)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidPushSubscription) {
+					t.Errorf("expected ErrInvalidPushSubscription, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePushSubscription returned %v", err)
+			}
+			if gotP256dh != p256dh || gotAuth != strings.TrimRight(auth, "=") {
+				t.Errorf("unexpected keys %q %q", gotP256dh, gotAuth)
+			}
+		})
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("short", 10); got != "short" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateUTF8(strings.Repeat("é", 10), 9); got != "ééé…" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateUTF8(strings.Repeat("é", 10), 10); got != "ééé…" {
+		t.Errorf("expected a rune to stay whole, got %q", got)
+	}
+}
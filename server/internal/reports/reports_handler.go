@@ -43,7 +43,14 @@ func (h *ReportsHandler) GetMonthlyReport(c echo.Context) error {
 		})
 	}
 
-	report, err := h.service.GetMonthlyReport(userID, year, month)
+	depth, err := reportDepth(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid depth",
+		})
+	}
+
+	report, err := h.service.GetMonthlyReport(userID, year, month, depth)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to load monthly report",
@@ -70,7 +77,14 @@ func (h *ReportsHandler) GetYearlyReport(c echo.Context) error {
 		})
 	}
 
-	report, err := h.service.GetYearlyReport(userID, year)
+	depth, err := reportDepth(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid depth",
+		})
+	}
+
+	report, err := h.service.GetYearlyReport(userID, year, depth)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to load yearly report",
@@ -79,3 +93,16 @@ func (h *ReportsHandler) GetYearlyReport(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, report)
 }
+
+// reportDepth reads the optional depth query parameter; 0 means the default.
+func reportDepth(c echo.Context) (int, error) {
+	value := c.QueryParam("depth")
+	if value == "" {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 {
+		return 0, strconv.ErrSyntax
+	}
+	return depth, nil
+}
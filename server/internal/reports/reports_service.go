@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/users"
 
 	"gorm.io/gorm"
 )
@@ -19,8 +20,11 @@ type MonthlyReport struct {
 	To                   string                         `json:"to"`
 	TotalExpenses        float64                        `json:"total_expenses"`
 	TotalPayments        float64                        `json:"total_payments"`
+	TotalTransfers       float64                        `json:"total_transfers"`
 	ExpenseTypeBreakdown map[string]TypeBreakdownItem   `json:"expense_type_breakdown"`
 	ParentTypeBreakdown  map[string]TypeBreakdownItem   `json:"parent_type_breakdown"`
+	Depth                int                            `json:"depth"`
+	CategoryBreakdown    map[string]TypeBreakdownItem   `json:"category_breakdown"`
 	WalletBreakdown      map[string]WalletBreakdownItem `json:"wallet_breakdown"`
 }
 
@@ -29,6 +33,7 @@ type TypeBreakdownItem struct {
 	Count  int     `json:"count"`
 	Color  string  `json:"color"`
 	Icon   string  `json:"icon"`
+	Path   string  `json:"path,omitempty"`
 }
 
 type WalletBreakdownItem struct {
@@ -49,30 +54,52 @@ type YearlyReport struct {
 type YearlySummary struct {
 	TotalExpenses          float64 `json:"total_expenses"`
 	TotalPayments          float64 `json:"total_payments"`
+	TotalTransfers         float64 `json:"total_transfers"`
 	AverageMonthlyExpenses float64 `json:"average_monthly_expenses"`
 	AverageMonthlyPayments float64 `json:"average_monthly_payments"`
 }
 
+// DefaultReportDepth rolls category totals up to top-level expense types.
+const DefaultReportDepth = 1
+
+// reportOptions holds the per-user settings shared by every month of a report.
+type reportOptions struct {
+	installmentView string
+	depth           int
+	expenseTypes    map[uint]expenses.ExpenseType
+}
+
 func NewReportsService(db *gorm.DB) *ReportsService {
 	return &ReportsService{db: db}
 }
 
-func (s *ReportsService) GetMonthlyReport(userID uint, year, month int) (*MonthlyReport, error) {
-	return s.buildMonthlyReport(userID, year, month)
+// GetMonthlyReport builds the report for one month. Category totals are
+// rolled up to depth levels of the expense type hierarchy.
+func (s *ReportsService) GetMonthlyReport(userID uint, year, month, depth int) (*MonthlyReport, error) {
+	options, err := s.loadReportOptions(userID, depth)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildMonthlyReport(userID, year, month, options)
 }
 
-func (s *ReportsService) GetYearlyReport(userID uint, year int) (*YearlyReport, error) {
+func (s *ReportsService) GetYearlyReport(userID uint, year, depth int) (*YearlyReport, error) {
 	var months []MonthlyReport
-	var totalExpenses, totalPayments float64
+	var totalExpenses, totalPayments, totalTransfers float64
 
+	options, err := s.loadReportOptions(userID, depth)
+	if err != nil {
+		return nil, err
+	}
 	for month := 1; month <= 12; month++ {
-		monthReport, err := s.buildMonthlyReport(userID, year, month)
+		monthReport, err := s.buildMonthlyReport(userID, year, month, options)
 		if err != nil {
 			return nil, err
 		}
 		months = append(months, *monthReport)
 		totalExpenses += monthReport.TotalExpenses
 		totalPayments += monthReport.TotalPayments
+		totalTransfers += monthReport.TotalTransfers
 	}
 
 	return &YearlyReport{
@@ -81,28 +108,59 @@ func (s *ReportsService) GetYearlyReport(userID uint, year int) (*YearlyReport,
 		Summary: YearlySummary{
 			TotalExpenses:          totalExpenses,
 			TotalPayments:          totalPayments,
+			TotalTransfers:         totalTransfers,
 			AverageMonthlyExpenses: totalExpenses / 12,
 			AverageMonthlyPayments: totalPayments / 12,
 		},
 	}, nil
 }
 
-func (s *ReportsService) buildMonthlyReport(userID uint, year, month int) (*MonthlyReport, error) {
+func (s *ReportsService) buildMonthlyReport(userID uint, year, month int, options reportOptions) (*MonthlyReport, error) {
 	from := expenses.BeginningOfMonth(year, month)
 	to := expenses.EndOfMonth(year, month)
 
+	expenseQuery := s.db.Preload("ExpenseType.Parent").Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to)
+	if options.installmentView == users.InstallmentViewFull {
+		expenseQuery = expenseQuery.Where("installment_plan_id IS NULL")
+	}
 	var monthlyExpenses []expenses.Expense
-	if err := s.db.Preload("ExpenseType.Parent").Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).Find(&monthlyExpenses).Error; err != nil {
+	if err := expenseQuery.Find(&monthlyExpenses).Error; err != nil {
 		return nil, err
 	}
 
+	// In the full view an installment purchase counts once, for its total, in
+	// the month it was bought instead of as monthly charges.
+	if options.installmentView == users.InstallmentViewFull {
+		var plans []expenses.InstallmentPlan
+		if err := s.db.Preload("ExpenseType.Parent").Where("user_id = ? AND purchase_date >= ? AND purchase_date <= ?", userID, from, to).Find(&plans).Error; err != nil {
+			return nil, err
+		}
+		for _, plan := range plans {
+			monthlyExpenses = append(monthlyExpenses, expenses.Expense{
+				ExpenseTypeID: plan.ExpenseTypeID,
+				Amount:        plan.TotalAmount,
+				Date:          plan.PurchaseDate,
+				ExpenseType:   plan.ExpenseType,
+			})
+		}
+	}
+
+	// Payments created by transfers only move money between wallets, so they
+	// are reported separately instead of counting as spending.
+	transferPayments := s.db.Model(&expenses.Transfer{}).Select("payment_id").Where("user_id = ?", userID)
 	var monthlyPayments []expenses.Payment
-	if err := s.db.Preload("Wallet").Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).Find(&monthlyPayments).Error; err != nil {
+	if err := s.db.Preload("Wallet").Where("user_id = ? AND date >= ? AND date <= ? AND id NOT IN (?)", userID, from, to, transferPayments).Find(&monthlyPayments).Error; err != nil {
+		return nil, err
+	}
+
+	var totalTransfers float64
+	if err := s.db.Model(&expenses.Transfer{}).Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).Select("COALESCE(SUM(amount), 0)").Scan(&totalTransfers).Error; err != nil {
 		return nil, err
 	}
 
 	expenseTypeBreakdown := make(map[string]TypeBreakdownItem)
 	parentTypeBreakdown := make(map[string]TypeBreakdownItem)
+	categoryBreakdown := make(map[string]TypeBreakdownItem)
 	walletBreakdown := make(map[string]WalletBreakdownItem)
 
 	var totalExpenses float64
@@ -127,13 +185,34 @@ func (s *ReportsService) buildMonthlyReport(userID uint, year, month int) (*Mont
 				parentIcon = expense.ExpenseType.Parent.Icon
 			}
 		}
+		path := expenses.ExpenseTypePath(options.expenseTypes, expense.ExpenseTypeID)
 		child := expenseTypeBreakdown[typeName]
 		child.Amount += expense.Amount
 		child.Count++
 		child.Color = color
 		child.Icon = icon
+		child.Path = expenses.FormatExpenseTypePath(path)
 		expenseTypeBreakdown[typeName] = child
 
+		categoryName := typeName
+		categoryColor := color
+		categoryIcon := icon
+		if len(path) > options.depth {
+			path = path[:options.depth]
+		}
+		if len(path) > 0 {
+			categoryName = expenses.FormatExpenseTypePath(path)
+			categoryColor = path[len(path)-1].Color
+			categoryIcon = path[len(path)-1].Icon
+		}
+		category := categoryBreakdown[categoryName]
+		category.Amount += expense.Amount
+		category.Count++
+		category.Color = categoryColor
+		category.Icon = categoryIcon
+		category.Path = categoryName
+		categoryBreakdown[categoryName] = category
+
 		parent := parentTypeBreakdown[parentName]
 		parent.Amount += expense.Amount
 		parent.Count++
@@ -174,12 +253,39 @@ func (s *ReportsService) buildMonthlyReport(userID uint, year, month int) (*Mont
 		To:                   to.Format(expenses.DateOnlyLayout),
 		TotalExpenses:        totalExpenses,
 		TotalPayments:        totalPayments,
+		TotalTransfers:       totalTransfers,
 		ExpenseTypeBreakdown: expenseTypeBreakdown,
 		ParentTypeBreakdown:  parentTypeBreakdown,
+		Depth:                options.depth,
+		CategoryBreakdown:    categoryBreakdown,
 		WalletBreakdown:      walletBreakdown,
 	}, nil
 }
 
+func (s *ReportsService) loadReportOptions(userID uint, depth int) (reportOptions, error) {
+	if depth <= 0 {
+		depth = DefaultReportDepth
+	}
+	var user users.User
+	if err := s.db.Select("id", "installment_view").First(&user, userID).Error; err != nil {
+		return reportOptions{}, err
+	}
+	// Deleted types are included so old expenses still resolve to a path.
+	var expenseTypes []expenses.ExpenseType
+	if err := s.db.Unscoped().Where("user_id = ?", userID).Find(&expenseTypes).Error; err != nil {
+		return reportOptions{}, err
+	}
+	options := reportOptions{
+		installmentView: user.InstallmentView,
+		depth:           depth,
+		expenseTypes:    make(map[uint]expenses.ExpenseType, len(expenseTypes)),
+	}
+	for _, expenseType := range expenseTypes {
+		options.expenseTypes[expenseType.ID] = expenseType
+	}
+	return options, nil
+}
+
 func parseAmount(amount string) (float64, error) {
 	if amount == "" {
 		return 0, nil
@@ -0,0 +1,462 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/notifications"
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+// maxSuggestedTypes caps the expense types offered when /add names none.
+const maxSuggestedTypes = 8
+
+// Amount formats accepted by /add besides plain numbers: "8,50" with a
+// decimal comma and "1,200.50" with thousands separators.
+var (
+	decimalComma    = regexp.MustCompile(`^\d+,\d{1,2}$`)
+	thousandsCommas = regexp.MustCompile(`^\d{1,3}(,\d{3})+(\.\d+)?$`)
+)
+
+const (
+	helpText = "Commands:\n" +
+		"/add 45 lunch — log an expense\n" +
+		"/unlink — stop reminders in this chat\n\n" +
+		"Tap ✅ on a reminder to mark it paid."
+	notLinkedText = "This chat is not linked yet. In Jiceot, open Settings → Notifications and link Telegram."
+	addUsageText  = "Usage: /add <amount> <expense type or note>, e.g. /add 45 lunch"
+)
+
+// Bot long-polls the Bot API for messages. It links chats to users and lets
+// linked chats log expenses with /add and mark reminders paid from inline
+// buttons, through ExpenseService.
+type Bot struct {
+	db       *gorm.DB
+	client   *Client
+	expenses *expenses.ExpenseService
+	username string
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewBot(db *gorm.DB, client *Client, expenseService *expenses.ExpenseService) *Bot {
+	return &Bot{
+		db:       db,
+		client:   client,
+		expenses: expenseService,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start looks up the bot's username for link URLs and begins polling for
+// updates.
+func (b *Bot) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	if me, err := b.client.GetMe(ctx); err != nil {
+		log.Printf("[telegram] Failed to get bot info: %v", err)
+	} else {
+		b.username = me.Username
+	}
+
+	go func() {
+		defer close(b.done)
+		log.Printf("[telegram] Bot @%s started", b.username)
+
+		var offset int64
+		for {
+			updates, err := b.client.GetUpdates(ctx, offset)
+			if ctx.Err() != nil {
+				log.Println("[telegram] Bot stopped")
+				return
+			}
+			if err != nil {
+				log.Printf("[telegram] Failed to get updates: %v", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(5 * time.Second):
+				}
+				continue
+			}
+			for _, update := range updates {
+				offset = update.UpdateID + 1
+				b.handleUpdate(ctx, update)
+			}
+		}
+	}()
+}
+
+// Stop gracefully shuts down the bot.
+func (b *Bot) Stop() {
+	if b.cancel != nil {
+		b.cancel()
+		<-b.done
+	}
+}
+
+func (b *Bot) handleUpdate(ctx context.Context, update Update) {
+	switch {
+	case update.CallbackQuery != nil:
+		b.handleCallback(ctx, *update.CallbackQuery)
+	case update.Message != nil:
+		b.handleMessage(ctx, *update.Message)
+	}
+}
+
+func (b *Bot) handleMessage(ctx context.Context, message Message) {
+	command, args := parseCommand(message.Text)
+	var reply string
+	switch command {
+	case "/start":
+		reply = b.start(message.Chat, args)
+	case "/add":
+		b.add(ctx, message, args)
+		return
+	case "/unlink":
+		reply = b.unlink(message.Chat.ID)
+	default:
+		reply = helpText
+	}
+	b.reply(ctx, message.Chat.ID, 0, reply, nil)
+}
+
+func (b *Bot) start(chat Chat, code string) string {
+	if code == "" {
+		if _, linked, err := b.chatUser(chat.ID); err == nil && linked {
+			return "This chat is linked to Jiceot.\n\n" + helpText
+		}
+		return notLinkedText
+	}
+	if _, err := b.linkChat(code, chat); err != nil {
+		if errors.Is(err, ErrInvalidLinkCode) || errors.Is(err, ErrChatLinkedToOther) {
+			return "Could not link this chat: " + err.Error() + "."
+		}
+		log.Printf("[telegram] Failed to link chat %d: %v", chat.ID, err)
+		return "Could not link this chat. Please try again."
+	}
+	return "✅ Linked! Due reminders will be sent here.\n\n" + helpText
+}
+
+func (b *Bot) unlink(chatID int64) string {
+	unlinked, err := b.unlinkChat(chatID)
+	if err != nil {
+		log.Printf("[telegram] Failed to unlink chat %d: %v", chatID, err)
+		return "Could not unlink this chat. Please try again."
+	}
+	if !unlinked {
+		return notLinkedText
+	}
+	return "This chat is unlinked and will no longer receive reminders."
+}
+
+// add logs an expense for "/add <amount> <text>". When text starts with an
+// expense type name the rest becomes the note; otherwise the user picks a
+// type from buttons and the whole text becomes the note.
+func (b *Bot) add(ctx context.Context, message Message, args string) {
+	chatID := message.Chat.ID
+	userID, linked, err := b.chatUser(chatID)
+	if err != nil {
+		log.Printf("[telegram] %v", err)
+		b.reply(ctx, chatID, 0, "Something went wrong. Please try again.", nil)
+		return
+	}
+	if !linked {
+		b.reply(ctx, chatID, 0, notLinkedText, nil)
+		return
+	}
+	amount, text, ok := parseAddArgs(args)
+	if !ok {
+		b.reply(ctx, chatID, 0, addUsageText, nil)
+		return
+	}
+	types, err := b.expenseTypes(userID)
+	if err != nil {
+		log.Printf("[telegram] Failed to load expense types for user %d: %v", userID, err)
+		b.reply(ctx, chatID, 0, "Something went wrong. Please try again.", nil)
+		return
+	}
+	if expenseType, note, found := matchExpenseType(types, text); found {
+		b.reply(ctx, chatID, 0, b.logExpense(userID, expenseType, amount, note), nil)
+		return
+	}
+	if len(types) == 0 {
+		b.reply(ctx, chatID, 0, "Create an expense type in Jiceot first.", nil)
+		return
+	}
+	keyboard := b.typeKeyboard(userID, types, amount)
+	b.reply(ctx, chatID, message.MessageID, fmt.Sprintf("Which expense type is %s for?", formatAmount(amount)), keyboard)
+}
+
+func (b *Bot) handleCallback(ctx context.Context, query CallbackQuery) {
+	if query.Message == nil {
+		b.answer(ctx, query.ID, "")
+		return
+	}
+	chatID := query.Message.Chat.ID
+	userID, linked, err := b.chatUser(chatID)
+	if err != nil || !linked {
+		b.answer(ctx, query.ID, "This chat is not linked")
+		return
+	}
+	action, expenseTypeID, arg, ok := parseCallback(query.Data)
+	if !ok {
+		b.answer(ctx, query.ID, "Unknown action")
+		return
+	}
+
+	var reply string
+	switch action {
+	case callbackPaid:
+		var done bool
+		reply, done = b.markPaid(userID, expenseTypeID, arg)
+		if done {
+			b.removeButton(ctx, *query.Message, query.Data)
+		}
+	case callbackAdd:
+		cents, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || cents <= 0 {
+			b.answer(ctx, query.ID, "Unknown action")
+			return
+		}
+		var expenseType expenses.ExpenseType
+		if err := b.db.Where("id = ? AND user_id = ?", expenseTypeID, userID).First(&expenseType).Error; err != nil {
+			b.answer(ctx, query.ID, "That expense type no longer exists")
+			return
+		}
+		note := ""
+		if original := query.Message.ReplyToMessage; original != nil {
+			_, args := parseCommand(original.Text)
+			_, note, _ = parseAddArgs(args)
+		}
+		reply = b.logExpense(userID, expenseType, float64(cents)/100, note)
+		b.removeKeyboard(ctx, *query.Message)
+	default:
+		b.answer(ctx, query.ID, "Unknown action")
+		return
+	}
+	b.answer(ctx, query.ID, "")
+	b.reply(ctx, chatID, 0, reply, nil)
+}
+
+// markPaid logs the amount in effect for an expense type's due date, unless
+// the due date has already been settled. It reports whether the button is
+// spent.
+func (b *Bot) markPaid(userID, expenseTypeID uint, dueValue string) (string, bool) {
+	due, err := time.Parse("2006-01-02", dueValue)
+	if err != nil {
+		return "Unknown action.", false
+	}
+	var expenseType expenses.ExpenseType
+	if err := b.db.Where("id = ? AND user_id = ?", expenseTypeID, userID).First(&expenseType).Error; err != nil {
+		return "That expense type no longer exists.", true
+	}
+
+	var lastExpenseDate *time.Time
+	var last expenses.Expense
+	if err := b.db.Where("user_id = ? AND expense_type_id = ?", userID, expenseTypeID).Order("date DESC").First(&last).Error; err == nil {
+		lastExpenseDate = &last.Date
+	}
+	nextDue, err := expenses.NextExpenseTypeDueDate(expenseType, time.Now().UTC(), lastExpenseDate)
+	if err == nil && (nextDue == nil || nextDue.After(due)) {
+		return fmt.Sprintf("%s is already marked as paid.", expenseType.Name), true
+	}
+
+	schedule, err := expenses.LoadAmountSchedule(b.db, userID, []uint{expenseTypeID})
+	if err != nil {
+		log.Printf("[telegram] %v", err)
+		return "Something went wrong. Please try again.", false
+	}
+	amount := schedule.AmountOn(expenseType, due)
+	if amount <= 0 {
+		return fmt.Sprintf("%s has no default amount. Send /add <amount> %s instead.", expenseType.Name, expenseType.Name), false
+	}
+	return b.logExpense(userID, expenseType, amount, ""), true
+}
+
+func (b *Bot) logExpense(userID uint, expenseType expenses.ExpenseType, amount float64, note string) string {
+	today := time.Now().In(notifications.UserLocation(b.db, userID)).Format("2006-01-02")
+	expense, err := b.expenses.CreateExpense(userID, expenses.CreateExpenseRequest{
+		ExpenseTypeID: expenseType.ID,
+		WalletID:      expenseType.DefaultWalletID,
+		Amount:        amount,
+		Date:          today,
+		Note:          note,
+	})
+	if err != nil {
+		log.Printf("[telegram] Failed to log expense for user %d: %v", userID, err)
+		return "Could not log the expense: " + err.Error()
+	}
+	return fmt.Sprintf("✅ Logged %s%s for %s.", b.currencySymbol(userID), formatAmount(expense.Amount), expenseType.Name)
+}
+
+func (b *Bot) expenseTypes(userID uint) ([]expenses.ExpenseType, error) {
+	var types []expenses.ExpenseType
+	if err := b.db.Where("user_id = ? AND stopped = ?", userID, false).Order("name ASC").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// typeKeyboard offers the user's most used expense types of the last 90
+// days, followed by the rest in name order.
+func (b *Bot) typeKeyboard(userID uint, types []expenses.ExpenseType, amount float64) *InlineKeyboardMarkup {
+	type usage struct {
+		ExpenseTypeID uint
+		Uses          int
+	}
+	var usages []usage
+	b.db.Model(&expenses.Expense{}).
+		Select("expense_type_id, COUNT(*) AS uses").
+		Where("user_id = ? AND date >= ?", userID, time.Now().UTC().AddDate(0, 0, -90)).
+		Group("expense_type_id").Find(&usages)
+	uses := make(map[uint]int, len(usages))
+	for _, usage := range usages {
+		uses[usage.ExpenseTypeID] = usage.Uses
+	}
+	sorted := append([]expenses.ExpenseType(nil), types...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return uses[sorted[i].ID] > uses[sorted[j].ID]
+	})
+	if len(sorted) > maxSuggestedTypes {
+		sorted = sorted[:maxSuggestedTypes]
+	}
+
+	cents := int64(math.Round(amount * 100))
+	var rows [][]InlineKeyboardButton
+	for i := 0; i < len(sorted); i += 2 {
+		row := []InlineKeyboardButton{{Text: sorted[i].Name, CallbackData: addCallback(sorted[i].ID, cents)}}
+		if i+1 < len(sorted) {
+			row = append(row, InlineKeyboardButton{Text: sorted[i+1].Name, CallbackData: addCallback(sorted[i+1].ID, cents)})
+		}
+		rows = append(rows, row)
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (b *Bot) currencySymbol(userID uint) string {
+	var user users.User
+	if err := b.db.Select("currency_symbol").Where("id = ?", userID).First(&user).Error; err != nil {
+		return ""
+	}
+	return user.CurrencySymbol
+}
+
+func (b *Bot) reply(ctx context.Context, chatID, replyTo int64, text string, keyboard *InlineKeyboardMarkup) {
+	_, err := b.client.SendMessage(ctx, SendMessageRequest{ChatID: chatID, Text: text, ReplyToMessageID: replyTo, ReplyMarkup: keyboard})
+	if err != nil {
+		log.Printf("[telegram] Failed to reply to chat %d: %v", chatID, err)
+	}
+}
+
+func (b *Bot) answer(ctx context.Context, callbackQueryID, text string) {
+	if err := b.client.AnswerCallbackQuery(ctx, callbackQueryID, text); err != nil {
+		log.Printf("[telegram] Failed to answer callback query: %v", err)
+	}
+}
+
+// removeButton drops the tapped button so a reminder cannot be paid twice.
+func (b *Bot) removeButton(ctx context.Context, message Message, data string) {
+	if message.ReplyMarkup == nil {
+		return
+	}
+	markup := InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	for _, row := range message.ReplyMarkup.InlineKeyboard {
+		kept := make([]InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			if button.CallbackData != data {
+				kept = append(kept, button)
+			}
+		}
+		if len(kept) > 0 {
+			markup.InlineKeyboard = append(markup.InlineKeyboard, kept)
+		}
+	}
+	if err := b.client.EditMessageReplyMarkup(ctx, message.Chat.ID, message.MessageID, markup); err != nil {
+		log.Printf("[telegram] Failed to update buttons: %v", err)
+	}
+}
+
+func (b *Bot) removeKeyboard(ctx context.Context, message Message) {
+	empty := InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	if err := b.client.EditMessageReplyMarkup(ctx, message.Chat.ID, message.MessageID, empty); err != nil {
+		log.Printf("[telegram] Failed to remove buttons: %v", err)
+	}
+}
+
+// parseCommand splits "/cmd@bot args" into the lower-cased command and the
+// trimmed arguments.
+func parseCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+	command, args, _ := strings.Cut(text, " ")
+	if at := strings.Index(command, "@"); at >= 0 {
+		command = command[:at]
+	}
+	return strings.ToLower(command), strings.TrimSpace(args)
+}
+
+// parseAddArgs parses "<amount> <text>". The amount may carry a leading
+// currency symbol. A comma followed by one or two digits is a decimal
+// separator; otherwise commas must group thousands, as in "1,200".
+func parseAddArgs(args string) (float64, string, bool) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return 0, "", false
+	}
+	value := strings.TrimLeft(fields[0], "$€£¥")
+	switch {
+	case decimalComma.MatchString(value):
+		value = strings.Replace(value, ",", ".", 1)
+	case thousandsCommas.MatchString(value):
+		value = strings.ReplaceAll(value, ",", "")
+	case strings.Contains(value, ","):
+		return 0, "", false
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount <= 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, "", false
+	}
+	return math.Round(amount*100) / 100, strings.Join(fields[1:], " "), true
+}
+
+// matchExpenseType finds the type whose name (or iOS category) starts text,
+// preferring the longest match, and returns the rest of text as the note.
+func matchExpenseType(types []expenses.ExpenseType, text string) (expenses.ExpenseType, string, bool) {
+	lower := strings.ToLower(text)
+	best := -1
+	bestLength := 0
+	for i, expenseType := range types {
+		for _, name := range []string{expenseType.Name, expenseType.IOSCategory} {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || len(name) <= bestLength || !strings.HasPrefix(lower, name) {
+				continue
+			}
+			if len(lower) > len(name) && lower[len(name)] != ' ' {
+				continue
+			}
+			best, bestLength = i, len(name)
+		}
+	}
+	if best < 0 {
+		return expenses.ExpenseType{}, "", false
+	}
+	return types[best], strings.TrimSpace(text[bestLength:]), true
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/notifications"
+)
+
+const ChannelTelegram = "telegram"
+
+var ErrInvalidChatID = fmt.Errorf("%w: telegram chat ID must be a number", notifications.ErrInvalidDestinationTarget)
+
+// Callback data prefixes of inline buttons. Telegram limits callback data to
+// 64 bytes, so buttons carry IDs only.
+const (
+	callbackPaid = "paid"
+	callbackAdd  = "add"
+)
+
+// maxPaidButtons caps the mark-as-paid buttons attached to one reminder.
+const maxPaidButtons = 8
+
+// Channel delivers notifications to a linked Telegram chat. Destinations are
+// normally created by linking a chat from the bot; ones added by chat ID
+// must be verified with a code sent to the chat.
+type Channel struct {
+	client *Client
+}
+
+var _ notifications.Channel = (*Channel)(nil)
+
+func NewChannel(client *Client) *Channel {
+	return &Channel{client: client}
+}
+
+func (c *Channel) Type() string {
+	return ChannelTelegram
+}
+
+func (c *Channel) RequiresVerification() bool {
+	return true
+}
+
+func (c *Channel) Validate(destination notifications.NotificationDestination) error {
+	_, err := parseChatID(destination.Target)
+	return err
+}
+
+// Deliver sends the message text. Reminders get a mark-as-paid button for
+// each due expense type; card bills need an amount, so they are paid from
+// the app.
+func (c *Channel) Deliver(destination notifications.NotificationDestination, message notifications.Message) error {
+	chatID, err := parseChatID(destination.Target)
+	if err != nil {
+		return err
+	}
+	req := SendMessageRequest{ChatID: chatID, Text: messageText(message)}
+	if message.Kind == notifications.MessageKindReminder {
+		req.ReplyMarkup = paidKeyboard(message.Items)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = c.client.SendMessage(ctx, req)
+	return err
+}
+
+func messageText(message notifications.Message) string {
+	if message.Body == "" {
+		return message.Title
+	}
+	return message.Title + "\n\n" + message.Body
+}
+
+func paidKeyboard(items []notifications.DueItem) *InlineKeyboardMarkup {
+	var rows [][]InlineKeyboardButton
+	for _, item := range items {
+		if item.Kind != notifications.DueItemExpenseType || len(rows) == maxPaidButtons {
+			continue
+		}
+		rows = append(rows, []InlineKeyboardButton{{
+			Text:         "✅ " + item.Name + " paid",
+			CallbackData: paidCallback(item.ID, item.DueDate),
+		}})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func paidCallback(expenseTypeID uint, due time.Time) string {
+	return fmt.Sprintf("%s:%d:%s", callbackPaid, expenseTypeID, due.Format("2006-01-02"))
+}
+
+func addCallback(expenseTypeID uint, cents int64) string {
+	return fmt.Sprintf("%s:%d:%d", callbackAdd, expenseTypeID, cents)
+}
+
+// parseCallback splits callback data into its action, expense type ID and
+// argument.
+func parseCallback(data string) (string, uint, string, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 {
+		return "", 0, "", false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || id == 0 {
+		return "", 0, "", false
+	}
+	return parts[0], uint(id), parts[2], true
+}
+
+func parseChatID(target string) (int64, error) {
+	chatID, err := strconv.ParseInt(strings.TrimSpace(target), 10, 64)
+	if err != nil || chatID == 0 {
+		return 0, ErrInvalidChatID
+	}
+	return chatID, nil
+}
@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the public Bot API server. Tests and self-hosted Bot API
+// servers use a different base URL.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// pollTimeout is how long getUpdates waits for new updates before returning
+// an empty result.
+const pollTimeout = 30 * time.Second
+
+// Client calls the Telegram Bot API.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		// Long polls hold the request open for pollTimeout.
+		httpClient: &http.Client{Timeout: pollTimeout + 10*time.Second},
+	}
+}
+
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username"`
+}
+
+type Chat struct {
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
+type Message struct {
+	MessageID      int64                 `json:"message_id"`
+	From           *User                 `json:"from,omitempty"`
+	Chat           Chat                  `json:"chat"`
+	Text           string                `json:"text"`
+	ReplyToMessage *Message              `json:"reply_to_message,omitempty"`
+	ReplyMarkup    *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data"`
+}
+
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type SendMessageRequest struct {
+	ChatID           int64                 `json:"chat_id"`
+	Text             string                `json:"text"`
+	ReplyToMessageID int64                 `json:"reply_to_message_id,omitempty"`
+	ReplyMarkup      *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+// GetMe returns the bot's own user.
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.call(ctx, "getMe", struct{}{}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUpdates long-polls for updates after offset.
+func (c *Client) GetUpdates(ctx context.Context, offset int64) ([]Update, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(pollTimeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}
+	var updates []Update
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func (c *Client) SendMessage(ctx context.Context, req SendMessageRequest) (*Message, error) {
+	var message Message
+	if err := c.call(ctx, "sendMessage", req, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// AnswerCallbackQuery acknowledges a button tap, showing text as a toast.
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string) error {
+	params := map[string]string{"callback_query_id": callbackQueryID, "text": text}
+	return c.call(ctx, "answerCallbackQuery", params, nil)
+}
+
+// EditMessageReplyMarkup replaces the inline keyboard of a sent message.
+func (c *Client) EditMessageReplyMarkup(ctx context.Context, chatID, messageID int64, markup InlineKeyboardMarkup) error {
+	params := map[string]interface{}{"chat_id": chatID, "message_id": messageID, "reply_markup": markup}
+	return c.call(ctx, "editMessageReplyMarkup", params, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode telegram %s request: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram %s request failed: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The URL contains the bot token, so report the method only.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("telegram %s request failed", method)
+	}
+	defer resp.Body.Close()
+
+	var response apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("telegram %s returned status %d", method, resp.StatusCode)
+	}
+	if !response.OK {
+		return fmt.Errorf("telegram %s failed: %s", method, response.Description)
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("failed to decode telegram %s response: %w", method, err)
+		}
+	}
+	return nil
+}
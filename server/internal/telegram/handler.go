@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"net/http"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler serves the bot's link endpoints. bot is nil when no bot token is
+// configured.
+type Handler struct {
+	bot *Bot
+}
+
+func NewHandler(bot *Bot) *Handler {
+	return &Handler{bot: bot}
+}
+
+// GetStatus handles GET /api/telegram
+func (h *Handler) GetStatus(c echo.Context) error {
+	if h.bot == nil {
+		return c.JSON(http.StatusOK, map[string]interface{}{"enabled": false, "bot_username": ""})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"enabled": true, "bot_username": h.bot.username})
+}
+
+// CreateLink handles POST /api/telegram/link
+func (h *Handler) CreateLink(c echo.Context) error {
+	if h.bot == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": ErrBotNotConfigured.Error()})
+	}
+	userID := auth.GetUserIDFromContext(c)
+	link, err := h.bot.CreateLinkCode(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create Telegram link"})
+	}
+	return c.JSON(http.StatusCreated, link)
+}
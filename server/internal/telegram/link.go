@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dannyswat/jiceot/internal/notifications"
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+const linkCodeTTL = 15 * time.Minute
+
+var (
+	ErrBotNotConfigured  = errors.New("telegram bot is not configured")
+	ErrInvalidLinkCode   = errors.New("link code is invalid or has expired")
+	ErrChatLinkedToOther = errors.New("this chat is linked to another account")
+)
+
+// LinkCode is a one-time code that links a Telegram chat to a user when it is
+// sent to the bot with /start. Only a hash of the code is stored.
+type LinkCode struct {
+	ID        uint      `json:"id" gorm:"primaryKey;type:bigint"`
+	UserID    uint      `json:"user_id" gorm:"type:bigint;not null;index"`
+	CodeHash  string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"type:timestamptz;not null"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User *users.User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (LinkCode) TableName() string {
+	return "telegram_link_codes"
+}
+
+// LinkResponse tells the user how to link a chat: open URL, or send
+// "/start <code>" to the bot.
+type LinkResponse struct {
+	Code        string    `json:"code"`
+	URL         string    `json:"url"`
+	BotUsername string    `json:"bot_username"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// CreateLinkCode issues a link code for userID, replacing any earlier one.
+func (b *Bot) CreateLinkCode(userID uint) (*LinkResponse, error) {
+	token := make([]byte, 12)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate link code: %w", err)
+	}
+	code := hex.EncodeToString(token)
+	link := LinkCode{UserID: userID, CodeHash: hashLinkCode(code), ExpiresAt: time.Now().UTC().Add(linkCodeTTL)}
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&LinkCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&link).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link code: %w", err)
+	}
+	response := &LinkResponse{Code: code, BotUsername: b.username, ExpiresAt: link.ExpiresAt}
+	if b.username != "" {
+		response.URL = "https://t.me/" + b.username + "?start=" + code
+	}
+	return response, nil
+}
+
+// linkChat consumes code and adds chat as a verified Telegram destination of
+// the code's user, replacing unverified destinations other users added for
+// the chat. It returns the user ID.
+func (b *Bot) linkChat(code string, chat Chat) (uint, error) {
+	var userID uint
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		var link LinkCode
+		if err := tx.Where("code_hash = ? AND expires_at > ?", hashLinkCode(code), time.Now().UTC()).First(&link).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidLinkCode
+			}
+			return fmt.Errorf("failed to find link code: %w", err)
+		}
+		if err := tx.Delete(&link).Error; err != nil {
+			return fmt.Errorf("failed to consume link code: %w", err)
+		}
+		userID = link.UserID
+
+		target := strconv.FormatInt(chat.ID, 10)
+		var linked int64
+		if err := tx.Model(&notifications.NotificationDestination{}).
+			Where("channel = ? AND target = ? AND pending_verification = ? AND user_id <> ?", ChannelTelegram, target, false, link.UserID).
+			Count(&linked).Error; err != nil {
+			return fmt.Errorf("failed to check linked chats: %w", err)
+		}
+		if linked > 0 {
+			return ErrChatLinkedToOther
+		}
+		// Other users' unverified destinations for this chat cannot block
+		// the chat's owner; linking proves who controls the chat.
+		if err := tx.Where("channel = ? AND target = ? AND pending_verification = ? AND user_id <> ?", ChannelTelegram, target, true, link.UserID).
+			Delete(&notifications.NotificationDestination{}).Error; err != nil {
+			return fmt.Errorf("failed to replace pending destinations: %w", err)
+		}
+
+		var existing notifications.NotificationDestination
+		err := tx.Where("channel = ? AND target = ? AND user_id = ?", ChannelTelegram, target, link.UserID).Order("id ASC").First(&existing).Error
+		if err == nil {
+			return tx.Model(&existing).Updates(map[string]interface{}{"pending_verification": false, "enabled": true}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check linked chats: %w", err)
+		}
+		destination := notifications.NotificationDestination{
+			UserID:  link.UserID,
+			Channel: ChannelTelegram,
+			Name:    chatName(chat),
+			Target:  target,
+			Enabled: true,
+		}
+		if err := tx.Create(&destination).Error; err != nil {
+			return fmt.Errorf("failed to link chat: %w", err)
+		}
+		return nil
+	})
+	return userID, err
+}
+
+// unlinkChat removes the chat's destinations of the user it is linked to.
+// Destinations other users added for the chat are left alone.
+func (b *Bot) unlinkChat(chatID int64) (bool, error) {
+	userID, linked, err := b.chatUser(chatID)
+	if err != nil || !linked {
+		return false, err
+	}
+	result := b.db.Where("channel = ? AND target = ? AND user_id = ?", ChannelTelegram, strconv.FormatInt(chatID, 10), userID).Delete(&notifications.NotificationDestination{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to unlink chat: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// chatUser returns the user a chat is linked to.
+func (b *Bot) chatUser(chatID int64) (uint, bool, error) {
+	var destination notifications.NotificationDestination
+	err := b.db.Where("channel = ? AND target = ? AND pending_verification = ?", ChannelTelegram, strconv.FormatInt(chatID, 10), false).
+		Order("id ASC").First(&destination).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to find linked chat: %w", err)
+	}
+	return destination.UserID, true, nil
+}
+
+func chatName(chat Chat) string {
+	switch {
+	case chat.Title != "":
+		return "Telegram: " + chat.Title
+	case chat.Username != "":
+		return "Telegram: @" + chat.Username
+	case chat.FirstName != "":
+		return "Telegram: " + chat.FirstName
+	default:
+		return "Telegram"
+	}
+}
+
+func hashLinkCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/notifications"
+)
+
+// fakeBotAPI records Bot API calls and answers them with ok results.
+type fakeBotAPI struct {
+	paths  []string
+	bodies []map[string]interface{}
+}
+
+func (f *fakeBotAPI) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.paths = append(f.paths, r.URL.Path)
+		f.bodies = append(f.bodies, body)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"username":"jiceot_bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			w.Write([]byte(`{"ok":true,"result":{"message_id":7,"chat":{"id":42}}}`))
+		default:
+			w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientCallsBotAPI(t *testing.T) {
+	api := &fakeBotAPI{}
+	client := NewClient(api.server(t).URL, "123:secret")
+
+	me, err := client.GetMe(context.Background())
+	if err != nil {
+		t.Fatalf("GetMe returned %v", err)
+	}
+	if me.Username != "jiceot_bot" || api.paths[0] != "/bot123:secret/getMe" {
+		t.Errorf("unexpected getMe result %+v at %s", me, api.paths[0])
+	}
+
+	err = client.AnswerCallbackQuery(context.Background(), "q1", "")
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("expected the API description in the error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected the error to omit the token, got %v", err)
+	}
+}
+
+func TestChannelDeliverAddsPaidButtons(t *testing.T) {
+	api := &fakeBotAPI{}
+	channel := NewChannel(NewClient(api.server(t).URL, "token"))
+	due := time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)
+	message := notifications.Message{
+		Kind:  notifications.MessageKindReminder,
+		Title: "Jiceot: 2 due",
+		Body:  "- Rent\n- Visa",
+		Items: []notifications.DueItem{
+			{Kind: notifications.DueItemExpenseType, ID: 5, Name: "Rent", DueDate: due},
+			{Kind: notifications.DueItemWallet, ID: 9, Name: "Visa", DueDate: due},
+		},
+	}
+
+	if err := channel.Deliver(notifications.NotificationDestination{Target: "42"}, message); err != nil {
+		t.Fatalf("Deliver returned %v", err)
+	}
+	body := api.bodies[0]
+	if body["chat_id"] != float64(42) || body["text"] != "Jiceot: 2 due\n\n- Rent\n- Visa" {
+		t.Errorf("unexpected message %v", body)
+	}
+	rows := body["reply_markup"].(map[string]interface{})["inline_keyboard"].([]interface{})
+	if len(rows) != 1 {
+		t.Fatalf("expected one paid button for the expense type, got %v", rows)
+	}
+	button := rows[0].([]interface{})[0].(map[string]interface{})
+	if button["callback_data"] != "paid:5:2026-05-03" {
+		t.Errorf("unexpected callback data %v", button["callback_data"])
+	}
+
+	if err := channel.Validate(notifications.NotificationDestination{Target: "@someone"}); err != ErrInvalidChatID {
+		t.Errorf("expected ErrInvalidChatID, got %v", err)
+	}
+}
+
+func TestParseCallback(t *testing.T) {
+	tests := []struct {
+		data   string
+		action string
+		id     uint
+		arg    string
+		ok     bool
+	}{
+		{data: paidCallback(5, time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)), action: callbackPaid, id: 5, arg: "2026-05-03", ok: true},
+		{data: addCallback(12, 4550), action: callbackAdd, id: 12, arg: "4550", ok: true},
+		{data: "paid:0:2026-05-03"},
+		{data: "paid:x:2026-05-03"},
+		{data: "paid:5"},
+	}
+	for _, tt := range tests {
+		action, id, arg, ok := parseCallback(tt.data)
+		if action != tt.action || id != tt.id || arg != tt.arg || ok != tt.ok {
+			t.Errorf("parseCallback(%q) = %q, %d, %q, %v", tt.data, action, id, arg, ok)
+		}
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text    string
+		command string
+		args    string
+	}{
+		{text: "/add 45 lunch", command: "/add", args: "45 lunch"},
+		{text: "/ADD@jiceot_bot  12.5  ", command: "/add", args: "12.5"},
+		{text: "/start", command: "/start", args: ""},
+		{text: "hello", command: "", args: "hello"},
+	}
+	for _, tt := range tests {
+		command, args := parseCommand(tt.text)
+		if command != tt.command || args != tt.args {
+			t.Errorf("parseCommand(%q) = %q, %q", tt.text, command, args)
+		}
+	}
+}
+
+func TestParseAddArgs(t *testing.T) {
+	tests := []struct {
+		args   string
+		amount float64
+		text   string
+		ok     bool
+	}{
+		{args: "45 lunch with Sam", amount: 45, text: "lunch with Sam", ok: true},
+		{args: "$12.345", amount: 12.35, text: "", ok: true},
+		{args: "8,50 coffee", amount: 8.5, text: "coffee", ok: true},
+		{args: "1,200 rent", amount: 1200, text: "rent", ok: true},
+		{args: "€1,234,567.891 car", amount: 1234567.89, text: "car", ok: true},
+		{args: "12,3456 typo"},
+		{args: "1,20,0 typo"},
+		{args: "lunch 45"},
+		{args: "-3 refund"},
+		{args: ""},
+	}
+	for _, tt := range tests {
+		amount, text, ok := parseAddArgs(tt.args)
+		if amount != tt.amount || text != tt.text || ok != tt.ok {
+			t.Errorf("parseAddArgs(%q) = %v, %q, %v", tt.args, amount, text, ok)
+		}
+	}
+}
+
+func TestMatchExpenseType(t *testing.T) {
+	types := []expenses.ExpenseType{
+		{ID: 1, Name: "Food"},
+		{ID: 2, Name: "Food Delivery"},
+		{ID: 3, Name: "Transport", IOSCategory: "Taxi"},
+	}
+	tests := []struct {
+		text string
+		id   uint
+		note string
+		ok   bool
+	}{
+		{text: "food", id: 1, note: "", ok: true},
+		{text: "Food delivery pizza night", id: 2, note: "pizza night", ok: true},
+		{text: "taxi home", id: 3, note: "home", ok: true},
+		{text: "foodcourt noodles"},
+		{text: "groceries"},
+	}
+	for _, tt := range tests {
+		expenseType, note, ok := matchExpenseType(types, tt.text)
+		if expenseType.ID != tt.id || note != tt.note || ok != tt.ok {
+			t.Errorf("matchExpenseType(%q) = %d, %q, %v", tt.text, expenseType.ID, note, ok)
+		}
+	}
+}
@@ -9,16 +9,18 @@ import (
 )
 
 type User struct {
-	ID               uint           `json:"id" gorm:"primaryKey;type:bigint"`
-	Email            string         `json:"email" gorm:"uniqueIndex;not null"`
-	PasswordHash     string         `json:"-" gorm:"not null"`
-	Name             string         `json:"name" gorm:"not null"`
-	CurrencySymbol   string         `json:"currency_symbol" gorm:"type:varchar(8);not null;default:'$'"`
-	Language         string         `json:"language" gorm:"type:varchar(16);not null;default:'en'"`
-	AutomationAPIKey string         `json:"automation_api_key" gorm:"type:varchar(64);uniqueIndex;not null;default:''"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	Email             string         `json:"email" gorm:"uniqueIndex;not null"`
+	PasswordHash      string         `json:"-" gorm:"not null"`
+	Name              string         `json:"name" gorm:"not null"`
+	CurrencySymbol    string         `json:"currency_symbol" gorm:"type:varchar(8);not null;default:'$'"`
+	Language          string         `json:"language" gorm:"type:varchar(16);not null;default:'en'"`
+	InstallmentView   string         `json:"installment_view" gorm:"type:varchar(16);not null;default:'monthly'"`
+	AutomationAPIKey  string         `json:"automation_api_key" gorm:"type:varchar(64);uniqueIndex;not null;default:''"`
+	CalendarFeedToken string         `json:"calendar_feed_token" gorm:"type:varchar(64);uniqueIndex:idx_users_calendar_feed_token,where:calendar_feed_token <> '';not null;default:''"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func (u *User) BeforeCreate(*gorm.DB) error {
@@ -39,9 +41,17 @@ func (u *User) ensureAutomationAPIKey() {
 }
 
 func generateAutomationAPIKey() string {
+	return generateSecretToken("automation API key")
+}
+
+func generateCalendarFeedToken() string {
+	return generateSecretToken("calendar feed token")
+}
+
+func generateSecretToken(kind string) string {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
-		panic("failed to generate automation API key: " + err.Error())
+		panic("failed to generate " + kind + ": " + err.Error())
 	}
 	return hex.EncodeToString(bytes)
 }
@@ -143,6 +143,43 @@ func (h *UserHandler) UpdateLanguage(c echo.Context) error {
 	return c.JSON(http.StatusOK, user)
 }
 
+// UpdateInstallmentView handles PUT /api/user/preferences/installment-view
+func (h *UserHandler) UpdateInstallmentView(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req UpdateInstallmentViewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+
+	user, err := h.userService.UpdateInstallmentView(userID, req.InstallmentView)
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		case ErrInvalidInstallmentView:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update installment view",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
 // GetUser handles GET /api/users/:id (for admin purposes if needed)
 func (h *UserHandler) GetUser(c echo.Context) error {
 	userID := getUserIDFromContext(c)
@@ -191,6 +228,58 @@ func (h *UserHandler) ListUsers(c echo.Context) error {
 	})
 }
 
+// RotateCalendarFeedToken handles POST /api/user/preferences/calendar-token/rotate
+func (h *UserHandler) RotateCalendarFeedToken(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	user, err := h.userService.RotateCalendarFeedToken(userID)
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to rotate calendar feed token",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// DisableCalendarFeed handles DELETE /api/user/preferences/calendar-token
+func (h *UserHandler) DisableCalendarFeed(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	user, err := h.userService.DisableCalendarFeed(userID)
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to disable calendar feed",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
 // RotateAutomationAPIKey handles POST /api/user/preferences/automation-key/rotate
 func (h *UserHandler) RotateAutomationAPIKey(c echo.Context) error {
 	userID := getUserIDFromContext(c)
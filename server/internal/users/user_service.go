@@ -12,6 +12,14 @@ import (
 const DefaultCurrencySymbol = "$"
 const DefaultLanguage = "en"
 
+// Installment views control how installment purchases count in reports:
+// either each monthly charge on its own date, or the full purchase on the
+// purchase date.
+const (
+	InstallmentViewMonthly = "monthly"
+	InstallmentViewFull    = "full"
+)
+
 type UserService struct {
 	db             *gorm.DB
 	passwordHasher PasswordHasher
@@ -36,20 +44,25 @@ type UpdateLanguageRequest struct {
 	Language string `json:"language"`
 }
 
+type UpdateInstallmentViewRequest struct {
+	InstallmentView string `json:"installment_view"`
+}
+
 type UserListResponse struct {
 	Users []User `json:"users"`
 	Total int64  `json:"total"`
 }
 
 var (
-	ErrUserNotFound          = errors.New("user not found")
-	ErrEmailExists           = errors.New("email already exists")
-	ErrInvalidPassword       = errors.New("invalid password")
-	ErrEmptyEmail            = errors.New("email cannot be empty")
-	ErrEmptyName             = errors.New("name cannot be empty")
-	ErrPasswordTooShort      = errors.New("password must be at least 6 characters")
-	ErrInvalidCurrencySymbol = errors.New("currency symbol must be 1-4 visible characters")
-	ErrInvalidLanguage       = errors.New("language must be one of: en, zh-Hant, zh-Hans")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrEmailExists            = errors.New("email already exists")
+	ErrInvalidPassword        = errors.New("invalid password")
+	ErrEmptyEmail             = errors.New("email cannot be empty")
+	ErrEmptyName              = errors.New("name cannot be empty")
+	ErrPasswordTooShort       = errors.New("password must be at least 6 characters")
+	ErrInvalidCurrencySymbol  = errors.New("currency symbol must be 1-4 visible characters")
+	ErrInvalidLanguage        = errors.New("language must be one of: en, zh-Hant, zh-Hans")
+	ErrInvalidInstallmentView = errors.New("installment view must be one of: monthly, full")
 )
 
 func NewUserService(db *gorm.DB, passwordHasher PasswordHasher) *UserService {
@@ -82,11 +95,12 @@ func (s *UserService) Register(req CreateUserRequest) (*User, error) {
 
 	// Create user
 	user := User{
-		Email:          strings.ToLower(req.Email),
-		PasswordHash:   hashedPassword,
-		Name:           strings.TrimSpace(req.Name),
-		CurrencySymbol: DefaultCurrencySymbol,
-		Language:       DefaultLanguage,
+		Email:           strings.ToLower(req.Email),
+		PasswordHash:    hashedPassword,
+		Name:            strings.TrimSpace(req.Name),
+		CurrencySymbol:  DefaultCurrencySymbol,
+		Language:        DefaultLanguage,
+		InstallmentView: InstallmentViewMonthly,
 	}
 
 	if err := s.db.Create(&user).Error; err != nil {
@@ -132,6 +146,29 @@ func (s *UserService) UpdateLanguage(userID uint, language string) (*User, error
 	return &user, nil
 }
 
+// UpdateInstallmentView updates how installment purchases are counted in reports.
+func (s *UserService) UpdateInstallmentView(userID uint, view string) (*User, error) {
+	var user User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	normalizedView, err := normalizeInstallmentView(view)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&user).Update("installment_view", normalizedView).Error; err != nil {
+		return nil, fmt.Errorf("failed to update installment view: %w", err)
+	}
+
+	user.InstallmentView = normalizedView
+	return &user, nil
+}
+
 // UpdateCurrencySymbol updates a user's preferred display currency symbol.
 func (s *UserService) UpdateCurrencySymbol(userID uint, symbol string) (*User, error) {
 	var user User
@@ -311,6 +348,53 @@ func (s *UserService) RotateAutomationAPIKey(userID uint) (*User, error) {
 	return user, nil
 }
 
+// RotateCalendarFeedToken issues a new calendar feed token, enabling the
+// feed if it was off. Subscriptions using the old URL stop working.
+func (s *UserService) RotateCalendarFeedToken(userID uint) (*User, error) {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.CalendarFeedToken = generateCalendarFeedToken()
+	if err := s.db.Model(user).Update("calendar_feed_token", user.CalendarFeedToken).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate calendar feed token: %w", err)
+	}
+
+	return user, nil
+}
+
+// DisableCalendarFeed clears the calendar feed token.
+func (s *UserService) DisableCalendarFeed(userID uint) (*User, error) {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.CalendarFeedToken = ""
+	if err := s.db.Model(user).Update("calendar_feed_token", "").Error; err != nil {
+		return nil, fmt.Errorf("failed to disable calendar feed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetUserByCalendarFeedToken(token string) (*User, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrUserNotFound
+	}
+	var user User
+	if err := s.db.Where("calendar_feed_token = ?", token).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by calendar feed token: %w", err)
+	}
+
+	return &user, nil
+}
+
 // validateCreateUserRequest validates the create user request
 func (s *UserService) validateCreateUserRequest(req CreateUserRequest) error {
 	if strings.TrimSpace(req.Email) == "" {
@@ -364,6 +448,20 @@ func normalizeLanguage(value string) (string, error) {
 	}
 }
 
+func normalizeInstallmentView(value string) (string, error) {
+	view := strings.ToLower(strings.TrimSpace(value))
+	if view == "" {
+		return InstallmentViewMonthly, nil
+	}
+
+	switch view {
+	case InstallmentViewMonthly, InstallmentViewFull:
+		return view, nil
+	default:
+		return "", ErrInvalidInstallmentView
+	}
+}
+
 // DeleteUserAccount hard deletes a user and all their associated data
 func (s *UserService) DeleteUserAccount(userID uint) error {
 	// Verify user exists
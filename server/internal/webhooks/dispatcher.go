@@ -0,0 +1,225 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Retry schedule: a failed attempt is retried after 30s, doubling each time,
+// until maxDeliveryAttempts have been made (about an hour in total).
+const (
+	maxDeliveryAttempts = 8
+	initialRetryDelay   = 30 * time.Second
+)
+
+const (
+	dispatchInterval    = 15 * time.Second
+	dispatchBatchSize   = 50
+	deliveryRetention   = 30 * 24 * time.Hour
+	maxStoredBodyLength = 1024
+)
+
+// Request headers sent with every delivery. The signature is the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	HeaderEvent     = "X-Jiceot-Event"
+	HeaderDelivery  = "X-Jiceot-Delivery"
+	HeaderTimestamp = "X-Jiceot-Timestamp"
+	HeaderSignature = "X-Jiceot-Signature"
+)
+
+// Dispatcher sends queued webhook deliveries in the background and retries
+// failures with exponential backoff. Deliveries are stored, so pending
+// retries survive a restart.
+type Dispatcher struct {
+	db         *gorm.DB
+	httpClient *http.Client
+	wake       chan struct{}
+	cancel     context.CancelFunc
+	done       chan struct{}
+	lastPrune  time.Time
+}
+
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		db:         db,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start begins the background delivery loop.
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+
+		log.Println("[webhooks] Dispatcher started")
+
+		d.dispatchDue()
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("[webhooks] Dispatcher stopped")
+				return
+			case <-ticker.C:
+				d.dispatchDue()
+			case <-d.wake:
+				d.dispatchDue()
+			}
+		}
+	}()
+}
+
+// Stop gracefully shuts down the dispatcher.
+func (d *Dispatcher) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+		<-d.done
+	}
+}
+
+// Wake asks the dispatcher to send newly queued deliveries without waiting
+// for the next tick.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) dispatchDue() {
+	now := time.Now().UTC()
+	var deliveries []WebhookDelivery
+	if err := d.db.Preload("Webhook", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("status = ? AND next_attempt_at <= ?", DeliveryStatusPending, now).
+		Order("next_attempt_at ASC").Limit(dispatchBatchSize).
+		Find(&deliveries).Error; err != nil {
+		log.Printf("[webhooks] Failed to load pending deliveries: %v", err)
+		return
+	}
+	for i := range deliveries {
+		d.deliver(&deliveries[i])
+	}
+
+	if now.Sub(d.lastPrune) >= time.Hour {
+		d.lastPrune = now
+		if err := d.db.Where("status <> ? AND created_at < ?", DeliveryStatusPending, now.Add(-deliveryRetention)).Delete(&WebhookDelivery{}).Error; err != nil {
+			log.Printf("[webhooks] Failed to prune old deliveries: %v", err)
+		}
+	}
+}
+
+// deliver makes one attempt at delivery and stores the outcome.
+func (d *Dispatcher) deliver(delivery *WebhookDelivery) {
+	now := time.Now().UTC()
+	webhook := delivery.Webhook
+	switch {
+	case webhook == nil || webhook.DeletedAt.Valid:
+		d.abandon(delivery, "webhook was deleted", now)
+	case !webhook.Enabled:
+		d.abandon(delivery, "webhook is disabled", now)
+	default:
+		d.attempt(*webhook, delivery, now)
+	}
+	if err := d.db.Omit(clause.Associations).Save(delivery).Error; err != nil {
+		log.Printf("[webhooks] Failed to record delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (d *Dispatcher) abandon(delivery *WebhookDelivery, reason string, at time.Time) {
+	delivery.Status = DeliveryStatusFailed
+	delivery.NextAttemptAt = nil
+	delivery.LastAttemptAt = &at
+	delivery.LastError = reason
+}
+
+// attempt posts delivery to webhook and updates its status, scheduling a
+// retry when the attempt failed and attempts remain.
+func (d *Dispatcher) attempt(webhook Webhook, delivery *WebhookDelivery, at time.Time) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = &at
+
+	status, body, err := d.post(webhook, delivery, at)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	if err == nil && (status < 200 || status >= 300) {
+		err = fmt.Errorf("endpoint returned status %d", status)
+	}
+	if err == nil {
+		delivery.Status = DeliveryStatusSucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= maxDeliveryAttempts {
+		delivery.Status = DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := at.Add(retryDelay(delivery.Attempts))
+	delivery.Status = DeliveryStatusPending
+	delivery.NextAttemptAt = &next
+}
+
+func (d *Dispatcher) post(webhook Webhook, delivery *WebhookDelivery, at time.Time) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid webhook request: %w", err)
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Jiceot-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxStoredBodyLength))
+	io.Copy(io.Discard, resp.Body)
+	// The body is stored in a text column, which rejects invalid UTF-8 and NUL.
+	stored := strings.ReplaceAll(strings.ToValidUTF8(string(body), ""), "\x00", "")
+	return resp.StatusCode, stored, nil
+}
+
+// Sign returns the hex HMAC-SHA256 signature of a delivery body, as sent in
+// the X-Jiceot-Signature header after "sha256=".
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay returns how long to wait after the given number of failed
+// attempts.
+func retryDelay(attempts int) time.Duration {
+	return initialRetryDelay << (attempts - 1)
+}
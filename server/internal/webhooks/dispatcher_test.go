@@ -0,0 +1,132 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/notifications"
+)
+
+func TestDispatcherAttemptSignsPayload(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(nil)
+	webhook := Webhook{URL: server.URL, Secret: "whsec_test"}
+	delivery := WebhookDelivery{ID: 12, EventType: expenses.EventExpenseCreated, Payload: `{"id":"abc","type":"expense.created"}`, Status: DeliveryStatusPending}
+	at := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	dispatcher.attempt(webhook, &delivery, at)
+
+	if delivery.Status != DeliveryStatusSucceeded || delivery.Attempts != 1 || delivery.NextAttemptAt != nil {
+		t.Fatalf("expected a successful first attempt, got %+v", delivery)
+	}
+	if delivery.ResponseStatus != http.StatusOK || delivery.ResponseBody != "ok" {
+		t.Errorf("unexpected response record %d %q", delivery.ResponseStatus, delivery.ResponseBody)
+	}
+	if string(body) != delivery.Payload {
+		t.Errorf("unexpected body %s", body)
+	}
+	if headers.Get(HeaderEvent) != expenses.EventExpenseCreated || headers.Get(HeaderDelivery) != "12" {
+		t.Errorf("unexpected event headers %v", headers)
+	}
+	timestamp := headers.Get(HeaderTimestamp)
+	if timestamp != "1777622400" {
+		t.Errorf("unexpected timestamp %q", timestamp)
+	}
+	want := "sha256=" + Sign("whsec_test", timestamp, body)
+	if !hmac.Equal([]byte(headers.Get(HeaderSignature)), []byte(want)) {
+		t.Errorf("signature = %q, want %q", headers.Get(HeaderSignature), want)
+	}
+	if Sign("other", timestamp, body) == Sign("whsec_test", timestamp, body) {
+		t.Error("expected the signature to depend on the secret")
+	}
+}
+
+func TestDispatcherAttemptRetriesWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(nil)
+	webhook := Webhook{URL: server.URL, Secret: "whsec_test"}
+	delivery := WebhookDelivery{Payload: `{}`, Status: DeliveryStatusPending}
+	at := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	dispatcher.attempt(webhook, &delivery, at)
+	if delivery.Status != DeliveryStatusPending || delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(at.Add(30*time.Second)) {
+		t.Fatalf("expected a retry in 30s, got %+v", delivery)
+	}
+	if delivery.ResponseStatus != http.StatusServiceUnavailable || !strings.Contains(delivery.LastError, "503") {
+		t.Errorf("expected the failure to be recorded, got %d %q", delivery.ResponseStatus, delivery.LastError)
+	}
+
+	dispatcher.attempt(webhook, &delivery, at)
+	if !delivery.NextAttemptAt.Equal(at.Add(time.Minute)) {
+		t.Errorf("expected the delay to double, got %v", delivery.NextAttemptAt)
+	}
+
+	delivery.Attempts = maxDeliveryAttempts - 1
+	dispatcher.attempt(webhook, &delivery, at)
+	if delivery.Status != DeliveryStatusFailed || delivery.NextAttemptAt != nil {
+		t.Errorf("expected the delivery to fail after %d attempts, got %+v", maxDeliveryAttempts, delivery)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, delay := range want {
+		if got := retryDelay(i + 1); got != delay {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+	var total time.Duration
+	for attempts := 1; attempts < maxDeliveryAttempts; attempts++ {
+		total += retryDelay(attempts)
+	}
+	if total < time.Hour || total > 2*time.Hour {
+		t.Errorf("expected retries to span about an hour, got %v", total)
+	}
+}
+
+func TestNormalizeEvents(t *testing.T) {
+	events, err := normalizeEvents([]string{" Payment.Created ", notifications.EventReminderDue, expenses.EventPaymentCreated})
+	if err != nil {
+		t.Fatalf("normalizeEvents returned %v", err)
+	}
+	if want := []string{notifications.EventReminderDue, expenses.EventPaymentCreated}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if _, err := normalizeEvents([]string{"budget.created"}); !errors.Is(err, ErrUnknownWebhookEvent) {
+		t.Errorf("expected ErrUnknownWebhookEvent, got %v", err)
+	}
+}
+
+func TestWebhookSubscribes(t *testing.T) {
+	all := Webhook{}
+	if !all.Subscribes(expenses.EventWalletDeleted) {
+		t.Error("expected an empty event list to subscribe to everything")
+	}
+	filtered := Webhook{Events: []string{notifications.EventReminderDue}}
+	if filtered.Subscribes(expenses.EventWalletDeleted) {
+		t.Error("expected a filtered webhook to skip other events")
+	}
+	if !filtered.Subscribes(notifications.EventReminderDue) || !filtered.Subscribes(EventPing) {
+		t.Error("expected a filtered webhook to receive its events and pings")
+	}
+}
@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"strings"
+	"time"
+
+	"dannyswat/jiceot/internal/expenses"
+	"dannyswat/jiceot/internal/notifications"
+	"dannyswat/jiceot/internal/users"
+
+	"gorm.io/gorm"
+)
+
+// EventPing is sent by the test endpoint and is delivered regardless of the
+// webhook's event filter.
+const EventPing = "ping"
+
+// SupportedEvents lists the event types a webhook can subscribe to.
+var SupportedEvents = []string{
+	notifications.EventReminderDue,
+	expenses.EventExpenseCreated,
+	expenses.EventExpenseUpdated,
+	expenses.EventExpenseDeleted,
+	expenses.EventPaymentCreated,
+	expenses.EventPaymentUpdated,
+	expenses.EventPaymentDeleted,
+	expenses.EventWalletCreated,
+	expenses.EventWalletUpdated,
+	expenses.EventWalletDeleted,
+}
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook is a user-configured endpoint that receives signed JSON events.
+// An empty Events list subscribes to every supported event.
+type Webhook struct {
+	ID        uint           `json:"id" gorm:"primaryKey;type:bigint"`
+	UserID    uint           `json:"user_id" gorm:"type:bigint;not null;index"`
+	Name      string         `json:"name" gorm:"type:varchar(100);not null;default:''"`
+	URL       string         `json:"url" gorm:"type:varchar(500);not null"`
+	Secret    string         `json:"secret" gorm:"type:varchar(100);not null"`
+	EventList string         `json:"-" gorm:"column:events;type:text;not null;default:''"`
+	Events    []string       `json:"events" gorm:"-"`
+	Enabled   bool           `json:"enabled" gorm:"not null;default:true"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	User *users.User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (w *Webhook) AfterFind(*gorm.DB) error {
+	w.Events = []string{}
+	if w.EventList != "" {
+		w.Events = strings.Split(w.EventList, ",")
+	}
+	return nil
+}
+
+func (w *Webhook) BeforeSave(*gorm.DB) error {
+	w.EventList = strings.Join(w.Events, ",")
+	return nil
+}
+
+// Subscribes reports whether the webhook should receive events of eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	if eventType == EventPing || len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for a webhook, together with the
+// outcome of its latest attempt. Redelivering an event creates a new row
+// with the same EventID.
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey;type:bigint"`
+	WebhookID      uint       `json:"webhook_id" gorm:"type:bigint;not null;index"`
+	UserID         uint       `json:"user_id" gorm:"type:bigint;not null;index"`
+	EventID        string     `json:"event_id" gorm:"type:varchar(32);not null;index"`
+	EventType      string     `json:"event_type" gorm:"type:varchar(50);not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"type:timestamptz;index"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at" gorm:"type:timestamptz"`
+	ResponseStatus int        `json:"response_status" gorm:"not null;default:0"`
+	ResponseBody   string     `json:"response_body" gorm:"type:text;not null;default:''"`
+	LastError      string     `json:"last_error" gorm:"type:text;not null;default:''"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Webhook *Webhook `json:"-" gorm:"foreignKey:WebhookID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// Event is the JSON body posted to webhooks.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -0,0 +1,154 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dannyswat/jiceot/internal/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	service *WebhookService
+}
+
+func NewWebhookHandler(service *WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// ListWebhooks handles GET /api/webhooks
+func (h *WebhookHandler) ListWebhooks(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	webhooks, err := h.service.ListWebhooks(userID)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list webhooks")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"webhooks": webhooks,
+		"events":   SupportedEvents,
+	})
+}
+
+// CreateWebhook handles POST /api/webhooks
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	webhook, err := h.service.CreateWebhook(userID, req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to create webhook")
+	}
+	return c.JSON(http.StatusCreated, webhook)
+}
+
+// UpdateWebhook handles PUT /api/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+	}
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	webhook, err := h.service.UpdateWebhook(userID, uint(webhookID), req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to update webhook")
+	}
+	return c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+	}
+	if err := h.service.DeleteWebhook(userID, uint(webhookID)); err != nil {
+		return h.webhookError(c, err, "Failed to delete webhook")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// RotateSecret handles POST /api/webhooks/:id/rotate-secret
+func (h *WebhookHandler) RotateSecret(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+	}
+	webhook, err := h.service.RotateSecret(userID, uint(webhookID))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to rotate webhook secret")
+	}
+	return c.JSON(http.StatusOK, webhook)
+}
+
+// TestWebhook handles POST /api/webhooks/:id/test
+func (h *WebhookHandler) TestWebhook(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+	}
+	delivery, err := h.service.TestWebhook(userID, uint(webhookID))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to send test event")
+	}
+	if delivery.Status != DeliveryStatusSucceeded {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":    "Failed to send test event: " + delivery.LastError,
+			"delivery": delivery,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  "Test event sent successfully",
+		"delivery": delivery,
+	})
+}
+
+// ListDeliveries handles GET /api/webhooks/:id/deliveries
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	deliveries, err := h.service.ListDeliveries(userID, uint(webhookID), limit)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list webhook deliveries")
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// Redeliver handles POST /api/webhook-deliveries/:id/redeliver
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	userID := auth.GetUserIDFromContext(c)
+	deliveryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid delivery ID"})
+	}
+	delivery, err := h.service.Redeliver(userID, uint(deliveryID))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to redeliver webhook event")
+	}
+	return c.JSON(http.StatusAccepted, delivery)
+}
+
+func (h *WebhookHandler) webhookError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrWebhookNotFound), errors.Is(err, ErrWebhookDeliveryNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvalidWebhookURL), errors.Is(err, ErrUnknownWebhookEvent):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+	}
+}
@@ -0,0 +1,318 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrUnknownWebhookEvent     = errors.New("unknown webhook event")
+)
+
+type WebhookService struct {
+	db         *gorm.DB
+	dispatcher *Dispatcher
+}
+
+func NewWebhookService(db *gorm.DB, dispatcher *Dispatcher) *WebhookService {
+	return &WebhookService{db: db, dispatcher: dispatcher}
+}
+
+// WebhookRequest creates or updates a webhook. Events lists the event types
+// to deliver; an empty list subscribes to all of them.
+type WebhookRequest struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+func (s *WebhookService) ListWebhooks(userID uint) ([]Webhook, error) {
+	var webhooks []Webhook
+	if err := s.db.Where("user_id = ?", userID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetWebhook(userID, webhookID uint) (*Webhook, error) {
+	var webhook Webhook
+	if err := s.db.Where("id = ? AND user_id = ?", webhookID, userID).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+// CreateWebhook saves a webhook with a newly generated signing secret.
+func (s *WebhookService) CreateWebhook(userID uint, req WebhookRequest) (*Webhook, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook := Webhook{UserID: userID, Secret: secret, Enabled: true}
+	if err := apply(&webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (s *WebhookService) UpdateWebhook(userID, webhookID uint, req WebhookRequest) (*Webhook, error) {
+	webhook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) DeleteWebhook(userID, webhookID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", webhookID, userID).Delete(&Webhook{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RotateSecret replaces the webhook's signing secret. Deliveries already
+// queued are signed with the new secret when they are next attempted.
+func (s *WebhookService) RotateSecret(userID, webhookID uint) (*Webhook, error) {
+	webhook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	if err := s.db.Save(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return webhook, nil
+}
+
+// TestWebhook sends a ping event straight away and returns the outcome of
+// the first attempt. A failed ping is retried like any other delivery.
+func (s *WebhookService) TestWebhook(userID, webhookID uint) (*WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.queue([]Webhook{*webhook}, userID, EventPing, map[string]uint{"webhook_id": webhook.ID}, false)
+	if err != nil {
+		return nil, err
+	}
+	delivery := deliveries[0]
+	delivery.Webhook = webhook
+	s.dispatcher.deliver(&delivery)
+	return &delivery, nil
+}
+
+// ListDeliveries returns the most recent deliveries of a webhook, newest
+// first.
+func (s *WebhookService) ListDeliveries(userID, webhookID uint, limit int) ([]WebhookDelivery, error) {
+	if _, err := s.GetWebhook(userID, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+	var deliveries []WebhookDelivery
+	if err := s.db.Where("webhook_id = ? AND user_id = ?", webhookID, userID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues the payload of an earlier delivery again as a new
+// delivery with the same event ID.
+func (s *WebhookService) Redeliver(userID, deliveryID uint) (*WebhookDelivery, error) {
+	var original WebhookDelivery
+	if err := s.db.Where("id = ? AND user_id = ?", deliveryID, userID).First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if _, err := s.GetWebhook(userID, original.WebhookID); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	delivery := WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		UserID:        userID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        DeliveryStatusPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	s.dispatcher.Wake()
+	return &delivery, nil
+}
+
+// Publish queues an event for each of the user's enabled webhooks that
+// subscribe to it and returns how many it was queued for. It implements the
+// event publisher interfaces of the expenses and notifications packages;
+// failures are logged rather than returned so they never fail the change
+// that raised the event.
+func (s *WebhookService) Publish(userID uint, eventType string, data interface{}) int {
+	var webhooks []Webhook
+	if err := s.db.Where("user_id = ? AND enabled = ?", userID, true).Order("id ASC").Find(&webhooks).Error; err != nil {
+		log.Printf("[webhooks] Failed to load webhooks for user %d: %v", userID, err)
+		return 0
+	}
+	subscribed := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(eventType) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return 0
+	}
+	if _, err := s.queue(subscribed, userID, eventType, data, true); err != nil {
+		log.Printf("[webhooks] Failed to queue %s for user %d: %v", eventType, userID, err)
+		return 0
+	}
+	s.dispatcher.Wake()
+	return len(subscribed)
+}
+
+// queue stores one pending delivery of a new event per webhook. Unscheduled
+// deliveries are left for the caller to attempt, so the dispatcher does not
+// pick them up at the same time.
+func (s *WebhookService) queue(webhooks []Webhook, userID uint, eventType string, data interface{}, scheduled bool) ([]WebhookDelivery, error) {
+	eventID, err := generateEventID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	payload, err := json.Marshal(Event{ID: eventID, Type: eventType, CreatedAt: now, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	var nextAttemptAt *time.Time
+	if scheduled {
+		nextAttemptAt = &now
+	}
+	deliveries := make([]WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, WebhookDelivery{
+			WebhookID:     webhook.ID,
+			UserID:        userID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        DeliveryStatusPending,
+			NextAttemptAt: nextAttemptAt,
+		})
+	}
+	if err := s.db.Create(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func apply(webhook *Webhook, req WebhookRequest) error {
+	webhook.Name = strings.TrimSpace(req.Name)
+	webhook.URL = strings.TrimSpace(req.URL)
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if webhook.Name == "" {
+		webhook.Name = parsed.Host
+	}
+	events, err := normalizeEvents(req.Events)
+	if err != nil {
+		return err
+	}
+	webhook.Events = events
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// normalizeEvents validates events and returns them deduplicated in the
+// order of SupportedEvents.
+func normalizeEvents(events []string) ([]string, error) {
+	requested := make(map[string]bool, len(events))
+	for _, event := range events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !isSupportedEvent(event) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, event)
+		}
+		requested[event] = true
+	}
+	normalized := []string{}
+	for _, event := range SupportedEvents {
+		if requested[event] {
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+func isSupportedEvent(event string) bool {
+	for _, supported := range SupportedEvents {
+		if event == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func generateSecret() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(token), nil
+}
+
+func generateEventID() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}